@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// inventory is the organisation-wide view of the dependencies of several projects, built by the aggregate command
+// from their JSON reports.
+type inventory struct {
+	Projects []inventoryProject `json:"projects"`
+	Licences []inventoryLicence `json:"licences"`
+	Families []inventoryFamily  `json:"families"`
+	Modules  []inventoryModule  `json:"modules"`
+}
+
+// inventoryProject summarises a report. Unknown lists the dependencies whose licence could not be determined.
+type inventoryProject struct {
+	Name         string   `json:"name"`
+	Report       string   `json:"report"`
+	Dependencies int      `json:"dependencies"`
+	Unknown      []string `json:"unknown,omitempty"`
+}
+
+type inventoryLicence struct {
+	Licence  string   `json:"licence"`
+	Modules  int      `json:"modules"`
+	Projects []string `json:"projects"`
+}
+
+// inventoryFamily rolls up the licences of a family, such as BSD-2-Clause and BSD-3-Clause.
+type inventoryFamily struct {
+	Family   string   `json:"family"`
+	Licences []string `json:"licences"`
+	Modules  int      `json:"modules"`
+	Projects []string `json:"projects"`
+}
+
+type inventoryModule struct {
+	Path     string   `json:"path"`
+	Versions []string `json:"versions"`
+	Licences []string `json:"licences"`
+	Projects []string `json:"projects"`
+}
+
+func init() {
+	subcommands["aggregate"] = aggregate
+}
+
+// aggregate reads the reports written by -format json given as arguments and writes the inventory of their
+// dependencies to -out. Projects are named after their report files.
+func aggregate() {
+	if flag.NArg() == 0 {
+		log.Fatal("Usage: aggregate [-out FILE] REPORT...")
+	}
+
+	inv, err := aggregateReports(flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w, cleanup, err := mkWriter(*outFlag)
+	if err != nil {
+		log.Fatalf("Failed to create output file %s: %v", *outFlag, err)
+	}
+	defer cleanup()
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(inv); err != nil {
+		log.Fatalf("Failed to write inventory: %v", err)
+	}
+}
+
+func aggregateReports(paths []string) (inventory, error) {
+	inv := inventory{Projects: []inventoryProject{}, Licences: []inventoryLicence{}, Families: []inventoryFamily{}, Modules: []inventoryModule{}}
+	licences := make(map[string]*inventoryLicence)
+	modules := make(map[string]*inventoryModule)
+
+	for _, path := range paths {
+		r, err := loadReport(path)
+		if err != nil {
+			return inv, fmt.Errorf("failed to load report %s: %w", path, err)
+		}
+
+		project := inventoryProject{Name: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), Report: path}
+		for _, deps := range [][]reportDependency{r.Direct, r.Indirect, r.Tools} {
+			for _, dep := range deps {
+				project.Dependencies++
+
+				version := dep.Version
+				if dep.Replace != nil {
+					version = dep.Replace.Version
+				}
+
+				licence := unknownLicence
+				if len(dep.Licences) > 0 {
+					licence = strings.Join(dep.Licences, " AND ")
+				} else {
+					project.Unknown = append(project.Unknown, dep.Path)
+				}
+
+				mod, ok := modules[dep.Path]
+				if !ok {
+					mod = &inventoryModule{Path: dep.Path}
+					modules[dep.Path] = mod
+				}
+				mod.Versions = appendUnique(mod.Versions, version)
+				mod.Licences = appendUnique(mod.Licences, licence)
+				mod.Projects = appendUnique(mod.Projects, project.Name)
+
+				l, ok := licences[licence]
+				if !ok {
+					l = &inventoryLicence{Licence: licence}
+					licences[licence] = l
+				}
+				l.Projects = appendUnique(l.Projects, project.Name)
+			}
+		}
+
+		inv.Projects = append(inv.Projects, project)
+	}
+
+	for _, mod := range modules {
+		sort.Strings(mod.Versions)
+		sort.Strings(mod.Licences)
+		sort.Strings(mod.Projects)
+		for _, licence := range mod.Licences {
+			licences[licence].Modules++
+		}
+		inv.Modules = append(inv.Modules, *mod)
+	}
+	// the modules used by most projects come first
+	sort.Slice(inv.Modules, func(i, j int) bool {
+		if ni, nj := len(inv.Modules[i].Projects), len(inv.Modules[j].Projects); ni != nj {
+			return ni > nj
+		}
+		return inv.Modules[i].Path < inv.Modules[j].Path
+	})
+
+	for _, l := range licences {
+		sort.Strings(l.Projects)
+		inv.Licences = append(inv.Licences, *l)
+	}
+	sort.Slice(inv.Licences, func(i, j int) bool { return inv.Licences[i].Licence < inv.Licences[j].Licence })
+
+	families := make(map[string]*inventoryFamily)
+	for _, mod := range inv.Modules {
+		var modFamilies []string
+		for _, licence := range mod.Licences {
+			family := unknownLicence
+			if licence != unknownLicence {
+				family = strings.Join(licenceFamilies(strings.Split(licence, " AND ")), " AND ")
+			}
+			f, ok := families[family]
+			if !ok {
+				f = &inventoryFamily{Family: family}
+				families[family] = f
+			}
+			f.Licences = appendUnique(f.Licences, licence)
+			for _, project := range mod.Projects {
+				f.Projects = appendUnique(f.Projects, project)
+			}
+			modFamilies = appendUnique(modFamilies, family)
+		}
+		for _, family := range modFamilies {
+			families[family].Modules++
+		}
+	}
+
+	for _, f := range families {
+		sort.Strings(f.Licences)
+		sort.Strings(f.Projects)
+		inv.Families = append(inv.Families, *f)
+	}
+	sort.Slice(inv.Families, func(i, j int) bool { return inv.Families[i].Family < inv.Families[j].Family })
+
+	return inv, nil
+}
+
+// loadReport reads a report written by -format json.
+func loadReport(path string) (report, error) {
+	var r report
+	f, err := os.Open(path)
+	if err != nil {
+		return r, err
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(&r); err != nil {
+		return r, fmt.Errorf("failed to parse report: %w", err)
+	}
+	return r, nil
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
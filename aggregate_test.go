@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregateReports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aggregate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	reports := map[string]report{
+		"api.json": {
+			Direct: []reportDependency{
+				{Path: "example.com/a", Version: "v1.0.0", Licences: []string{"MIT"}},
+				{Path: "example.com/b", Version: "v1.0.0"},
+				{Path: "example.com/d", Version: "v1.0.0", Licences: []string{"BSD-2-Clause"}},
+			},
+		},
+		"web.json": {
+			Direct: []reportDependency{{Path: "example.com/a", Version: "v1.1.0", Licences: []string{"MIT"}}},
+			Indirect: []reportDependency{
+				{Path: "example.com/c", Version: "v0.1.0", Licences: []string{"Apache-2.0"}},
+				{Path: "example.com/e", Version: "v1.0.0", Licences: []string{"BSD-3-Clause"}},
+			},
+		},
+	}
+
+	var paths []string
+	for name, r := range reports {
+		data, err := json.Marshal(r)
+		require.NoError(t, err)
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, data, 0644))
+		paths = append(paths, path)
+	}
+
+	inv, err := aggregateReports(paths)
+	require.NoError(t, err)
+
+	require.ElementsMatch(t, []inventoryProject{
+		{Name: "api", Report: filepath.Join(dir, "api.json"), Dependencies: 3, Unknown: []string{"example.com/b"}},
+		{Name: "web", Report: filepath.Join(dir, "web.json"), Dependencies: 3},
+	}, inv.Projects)
+	require.Equal(t, []inventoryLicence{
+		{Licence: "Apache-2.0", Modules: 1, Projects: []string{"web"}},
+		{Licence: "BSD-2-Clause", Modules: 1, Projects: []string{"api"}},
+		{Licence: "BSD-3-Clause", Modules: 1, Projects: []string{"web"}},
+		{Licence: "MIT", Modules: 1, Projects: []string{"api", "web"}},
+		{Licence: unknownLicence, Modules: 1, Projects: []string{"api"}},
+	}, inv.Licences)
+	require.Equal(t, []inventoryFamily{
+		{Family: "Apache", Licences: []string{"Apache-2.0"}, Modules: 1, Projects: []string{"web"}},
+		{Family: "BSD", Licences: []string{"BSD-2-Clause", "BSD-3-Clause"}, Modules: 2, Projects: []string{"api", "web"}},
+		{Family: "MIT", Licences: []string{"MIT"}, Modules: 1, Projects: []string{"api", "web"}},
+		{Family: unknownLicence, Licences: []string{unknownLicence}, Modules: 1, Projects: []string{"api"}},
+	}, inv.Families)
+	require.Equal(t, []inventoryModule{
+		{Path: "example.com/a", Versions: []string{"v1.0.0", "v1.1.0"}, Licences: []string{"MIT"}, Projects: []string{"api", "web"}},
+		{Path: "example.com/b", Versions: []string{"v1.0.0"}, Licences: []string{unknownLicence}, Projects: []string{"api"}},
+		{Path: "example.com/c", Versions: []string{"v0.1.0"}, Licences: []string{"Apache-2.0"}, Projects: []string{"web"}},
+		{Path: "example.com/d", Versions: []string{"v1.0.0"}, Licences: []string{"BSD-2-Clause"}, Projects: []string{"api"}},
+		{Path: "example.com/e", Versions: []string{"v1.0.0"}, Licences: []string{"BSD-3-Clause"}, Projects: []string{"web"}},
+	}, inv.Modules)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+const (
+	inTotoStatementType = "https://in-toto.io/Statement/v1"
+	licencePredicate    = "https://github.com/charith-elastic/licence-detector/licences/v1"
+)
+
+// stringsFlag is a flag that can be repeated to collect multiple values.
+type stringsFlag []string
+
+func (sf *stringsFlag) String() string {
+	return strings.Join(*sf, ",")
+}
+
+func (sf *stringsFlag) Set(value string) error {
+	*sf = append(*sf, value)
+	return nil
+}
+
+type inTotoStatement struct {
+	Type          string          `json:"_type"`
+	Subject       []inTotoSubject `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     report          `json:"predicate"`
+}
+
+type inTotoSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// exportInToto writes an in-toto attestation statement whose predicate holds the detection results and whose
+// subjects are the artifacts given by -attestation-subject.
+func exportInToto(w io.Writer, dependencies *detector.Dependencies) error {
+	if len(attestationSubjectsFlag) == 0 {
+		return errors.New("at least one -attestation-subject is required for the intoto format")
+	}
+
+	statement := inTotoStatement{
+		Type:          inTotoStatementType,
+		PredicateType: licencePredicate,
+		Predicate:     mkReport(dependencies),
+	}
+
+	for _, path := range attestationSubjectsFlag {
+		digest, err := sha256File(path)
+		if err != nil {
+			return fmt.Errorf("failed to compute digest of %s: %w", path, err)
+		}
+
+		statement.Subject = append(statement.Subject, inTotoSubject{
+			Name:   filepath.Base(path),
+			Digest: map[string]string{"sha256": digest},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(statement)
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportInToto(t *testing.T) {
+	dir, err := ioutil.TempDir("", "attest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the digests are computed with sha256sum
+	artifacts := []struct {
+		name     string
+		contents string
+		digest   string
+	}{
+		{name: "hello.bin", contents: "hello", digest: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{name: "empty.bin", contents: "", digest: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+	}
+
+	defer func(subjects stringsFlag) { attestationSubjectsFlag = subjects }(attestationSubjectsFlag)
+	attestationSubjectsFlag = nil
+	var wantSubjects []inTotoSubject
+	for _, a := range artifacts {
+		path := filepath.Join(dir, a.name)
+		require.NoError(t, ioutil.WriteFile(path, []byte(a.contents), 0644))
+		require.NoError(t, attestationSubjectsFlag.Set(path))
+		wantSubjects = append(wantSubjects, inTotoSubject{Name: a.name, Digest: map[string]string{"sha256": a.digest}})
+	}
+
+	dependencies := &detector.Dependencies{
+		Direct: []detector.LicenceInfo{{Module: detector.Module{Path: "example.com/a", Version: "v1.0.0"}, Licences: []string{"MIT"}}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, exportInToto(&buf, dependencies))
+
+	var statement inTotoStatement
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &statement))
+	require.Equal(t, inTotoStatementType, statement.Type)
+	require.Equal(t, licencePredicate, statement.PredicateType)
+	require.Equal(t, wantSubjects, statement.Subject)
+	require.Len(t, statement.Predicate.Direct, 1)
+	require.Equal(t, "example.com/a", statement.Predicate.Direct[0].Path)
+}
+
+func TestExportInTotoErrors(t *testing.T) {
+	defer func(subjects stringsFlag) { attestationSubjectsFlag = subjects }(attestationSubjectsFlag)
+
+	attestationSubjectsFlag = nil
+	err := exportInToto(ioutil.Discard, &detector.Dependencies{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "at least one -attestation-subject is required")
+
+	attestationSubjectsFlag = stringsFlag{"testdata/does-not-exist"}
+	err = exportInToto(ioutil.Discard, &detector.Dependencies{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to compute digest of testdata/does-not-exist")
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testNetrc = `machine proxy.example.com login alice password s3cret
+machine other.example.com
+	login bob
+	password hunter2
+	account ignored
+machine nologin.example.com password unused
+default login anonymous password anonymous
+macdef init
+	echo hello
+`
+
+func TestParseNetrc(t *testing.T) {
+	require.Equal(t, map[string]netrcEntry{
+		"proxy.example.com": {login: "alice", password: "s3cret"},
+		"other.example.com": {login: "bob", password: "hunter2"},
+	}, parseNetrc(testNetrc))
+}
+
+func TestAuthenticate(t *testing.T) {
+	f, err := ioutil.TempFile("", "netrc")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(testNetrc)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	defer os.Setenv("NETRC", os.Getenv("NETRC"))
+	require.NoError(t, os.Setenv("NETRC", f.Name()))
+
+	auth, err := newAuthenticator("")
+	require.NoError(t, err)
+	auth.headers = []authHeaders{
+		{prefix: "https://other.example.com/", headers: http.Header{"Authorization": {"Bearer short"}}},
+		{prefix: "https://other.example.com/private/", headers: http.Header{"Authorization": {"Bearer long"}}},
+	}
+
+	testCases := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{name: "NetrcMachine", url: "https://proxy.example.com/example.com/a/@v/v1.0.0.zip", want: "Basic YWxpY2U6czNjcmV0"},
+		{name: "NetrcMachineWithPort", url: "https://proxy.example.com:8443/example.com/a/@v/v1.0.0.zip", want: "Basic YWxpY2U6czNjcmV0"},
+		{name: "UnknownMachine", url: "https://unknown.example.com/example.com/a/@v/v1.0.0.zip", want: ""},
+		{name: "MachineWithoutLogin", url: "https://nologin.example.com/example.com/a/@v/v1.0.0.zip", want: ""},
+		{name: "CredentialsInURL", url: "https://carol:pw@proxy.example.com/example.com/a/@v/v1.0.0.zip", want: ""},
+		{name: "CommandHeaders", url: "https://other.example.com/example.com/a/@v/v1.0.0.zip", want: "Bearer short"},
+		{name: "LongestPrefix", url: "https://other.example.com/private/example.com/a/@v/v1.0.0.zip", want: "Bearer long"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+			auth.authenticate(req)
+			require.Equal(t, tc.want, req.Header.Get("Authorization"))
+		})
+	}
+
+	off, err := newAuthenticator("off")
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodGet, "https://proxy.example.com/", nil)
+	require.NoError(t, err)
+	off.authenticate(req)
+	require.Empty(t, req.Header.Get("Authorization"))
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// cacheSettingsFlags are the flags affecting the detection results, which are part of the cache keys.
+var cacheSettingsFlags = []string{
+	"all-candidates", "exec-detector", "ignore", "licence-preference", "max-depth", "max-licence-size", "strict-encoding",
+	"scancode", "subcomponents", "symlinks",
+}
+
+// cacheContentFlags are the settings flags naming files whose contents affect the detection results, whose digests
+// are also part of the cache keys.
+var cacheContentFlags = []string{"ignore", "scancode"}
+
+// moduleDirToken stands for the source directory of the module in the cached paths, so that the entries can be
+// shared by machines whose module caches are at different locations.
+const moduleDirToken = "$MODULE"
+
+// detectionCache stores the detection results of module versions in a store, keyed by the hash of the module zip
+// and the settings affecting the detection, so that the results can be shared by runs on different machines.
+// Failures of the store are logged once and disable the cache for the rest of the run, which then detects the
+// licences as if there were no cache.
+type detectionCache struct {
+	store    cacheStore
+	settings string // digest of the tool version and the settings affecting the detection
+	readOnly bool
+
+	mu       sync.Mutex
+	hits     map[string]struct{} // keys found in the store, which need not be stored again
+	disabled bool
+}
+
+// cacheEntry is the detection result of a module version. The paths are relative to the module directory.
+type cacheEntry struct {
+	Licences       []string            `json:"licences,omitempty"`
+	Expression     string              `json:"expression,omitempty"`
+	Language       string              `json:"language,omitempty"`
+	LicenceFile    string              `json:"licenceFile,omitempty"`
+	CopyrightFile  string              `json:"copyrightFile,omitempty"`
+	LicenceFiles   []string            `json:"licenceFiles,omitempty"`
+	CandidateFiles []string            `json:"candidateFiles,omitempty"`
+	Source         string              `json:"source,omitempty"`
+	Warnings       []string            `json:"warnings,omitempty"`
+	Subcomponents  []cacheSubcomponent `json:"subcomponents,omitempty"`
+	NotFound       bool                `json:"notFound,omitempty"` // no licence was found
+}
+
+type cacheSubcomponent struct {
+	Dir         string   `json:"dir"`
+	Licences    []string `json:"licences,omitempty"`
+	LicenceFile string   `json:"licenceFile,omitempty"`
+}
+
+func newDetectionCache(location string, readOnly bool) (*detectionCache, error) {
+	store, err := newCacheStore(location)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "version=%s\n", ToolVersion())
+	for _, name := range cacheSettingsFlags {
+		fmt.Fprintf(h, "%s=%s\n", name, flag.Lookup(name).Value.String())
+	}
+	for _, name := range cacheContentFlags {
+		path := flag.Lookup(name).Value.String()
+		if path == "" {
+			continue
+		}
+		digest, err := sha256File(path)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(h, "%s-digest=%s\n", name, digest)
+	}
+
+	return &detectionCache{store: store, settings: hex.EncodeToString(h.Sum(nil)), readOnly: readOnly, hits: make(map[string]struct{})}, nil
+}
+
+// key returns the key of the results of the module, which is only content-addressable if the hash of its zip was
+// recorded when it was downloaded, as well as the directory of its sources.
+func (c *detectionCache) key(mod detector.Module) (string, string, bool) {
+	src := mod
+	if mod.Replace != nil {
+		src = *mod.Replace
+	}
+	dir := mod.Dir
+	if dir == "" {
+		dir = src.Dir
+	}
+	if src.Version == "" || dir == "" {
+		return "", "", false
+	}
+
+	zipHash := moduleZipHash(src.Path, src.Version)
+	if zipHash == "" {
+		return "", "", false
+	}
+
+	sum := sha256.Sum256([]byte(zipHash + "\n" + c.settings))
+	return hex.EncodeToString(sum[:]), dir, true
+}
+
+// moduleZipHash returns the hash of the zip of the module version recorded in the Go module cache or the download
+// cache, or an empty string if none is.
+func moduleZipHash(modPath, version string) string {
+	roots := []string{goModCache}
+	if dir, err := downloadDir(); err == nil {
+		roots = append(roots, dir)
+	}
+
+	for _, root := range roots {
+		if hash, err := ioutil.ReadFile(detector.ModuleZipHashFile(root, modPath, version)); err == nil {
+			return strings.TrimSpace(string(hash))
+		}
+	}
+	return ""
+}
+
+// cached returns the Options.Cached function looking up the results of the modules in the cache if next, if any, has
+// none.
+func (c *detectionCache) cached(next func(detector.Module) (detector.LicenceInfo, bool)) func(detector.Module) (detector.LicenceInfo, bool) {
+	return func(mod detector.Module) (detector.LicenceInfo, bool) {
+		if next != nil {
+			if dep, ok := next(mod); ok {
+				return dep, true
+			}
+		}
+		return c.get(mod)
+	}
+}
+
+func (c *detectionCache) get(mod detector.Module) (detector.LicenceInfo, bool) {
+	key, dir, ok := c.key(mod)
+	if !ok || c.isDisabled() {
+		return detector.LicenceInfo{}, false
+	}
+
+	data, err := c.store.get(key)
+	if err != nil {
+		if err != errCacheMiss {
+			c.disable(err)
+		}
+		return detector.LicenceInfo{}, false
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return detector.LicenceInfo{}, false
+	}
+
+	c.mu.Lock()
+	c.hits[key] = struct{}{}
+	c.mu.Unlock()
+
+	return e.licenceInfo(dir), true
+}
+
+// record stores the results of a module detected during the run, as an Options.OnModuleDetected callback.
+func (c *detectionCache) record(dep detector.LicenceInfo, _ time.Duration) {
+	if c.isDisabled() {
+		return
+	}
+	if err := c.put(dep); err != nil {
+		c.disable(fmt.Errorf("failed to store the results of %s: %w", dep.Path, err))
+	}
+}
+
+// disable stops using the store after a failure, so that an unavailable remote store does not slow down the run
+// with a failing request per module.
+func (c *detectionCache) disable(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.disabled {
+		log.Printf("Detection cache disabled for the rest of the run: %v", err)
+		c.disabled = true
+	}
+}
+
+func (c *detectionCache) isDisabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.disabled
+}
+
+func (c *detectionCache) put(dep detector.LicenceInfo) error {
+	// results found after a timeout or from other sources than the module tree may differ in the next run
+	switch {
+	case c.readOnly, *moduleTimeoutFlag > 0:
+		return nil
+	case dep.Error == detector.ErrLicenceNotFound:
+	case dep.Error != nil, dep.Source != detector.SourceFile && dep.Source != detector.SourceReuse:
+		return nil
+	}
+
+	key, dir, ok := c.key(dep.Module)
+	if !ok {
+		return nil
+	}
+
+	// the entries of the same key are identical
+	c.mu.Lock()
+	_, hit := c.hits[key]
+	c.mu.Unlock()
+	if hit {
+		return nil
+	}
+
+	data, err := json.Marshal(mkCacheEntry(dep, dir))
+	if err != nil {
+		return err
+	}
+	return c.store.put(key, data)
+}
+
+func mkCacheEntry(dep detector.LicenceInfo, dir string) cacheEntry {
+	rel := func(s string) string {
+		return strings.Replace(s, dir, moduleDirToken, -1)
+	}
+
+	e := cacheEntry{
+		Licences:       dep.Licences,
+		Expression:     dep.LicenceExpression,
+		Language:       dep.Language,
+		LicenceFile:    rel(dep.LicenceFile),
+		CopyrightFile:  rel(dep.CopyrightFile),
+		LicenceFiles:   mapStrings(dep.LicenceFiles, rel),
+		CandidateFiles: mapStrings(dep.CandidateFiles, rel),
+		Source:         dep.Source,
+		Warnings:       mapStrings(dep.Warnings, rel),
+		NotFound:       dep.Error == detector.ErrLicenceNotFound,
+	}
+	for _, sc := range dep.Subcomponents {
+		e.Subcomponents = append(e.Subcomponents, cacheSubcomponent{Dir: rel(sc.Dir), Licences: sc.Licences, LicenceFile: rel(sc.LicenceFile)})
+	}
+	return e
+}
+
+func (e cacheEntry) licenceInfo(dir string) detector.LicenceInfo {
+	abs := func(s string) string {
+		return strings.Replace(s, moduleDirToken, dir, -1)
+	}
+
+	dep := detector.LicenceInfo{
+		Licences:          e.Licences,
+		LicenceExpression: e.Expression,
+		Language:          e.Language,
+		LicenceFile:       abs(e.LicenceFile),
+		CopyrightFile:     abs(e.CopyrightFile),
+		LicenceFiles:      mapStrings(e.LicenceFiles, abs),
+		CandidateFiles:    mapStrings(e.CandidateFiles, abs),
+		Source:            e.Source,
+		Warnings:          mapStrings(e.Warnings, abs),
+	}
+	if e.NotFound {
+		dep.Error = detector.ErrLicenceNotFound
+	}
+	for _, sc := range e.Subcomponents {
+		dep.Subcomponents = append(dep.Subcomponents, detector.Subcomponent{Dir: abs(sc.Dir), Licences: sc.Licences, LicenceFile: abs(sc.LicenceFile)})
+	}
+	return dep
+}
+
+func mapStrings(values []string, fn func(string) string) []string {
+	if values == nil {
+		return nil
+	}
+	mapped := make([]string, len(values))
+	for i, v := range values {
+		mapped[i] = fn(v)
+	}
+	return mapped
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+var errCacheMiss = errors.New("not in the detection cache")
+
+// cacheStore holds the entries of the detection cache by key. Entries are immutable: the entries of the same key are
+// identical, so a store never needs to replace one.
+type cacheStore interface {
+	// get returns the entry of the key, or errCacheMiss if there is none.
+	get(key string) ([]byte, error)
+	put(key string, data []byte) error
+}
+
+// newCacheStore returns the store at location, which is either a directory or the URL of a remote store:
+//
+//   - http:// or https:// URLs are read and written with GET and PUT requests, authenticated like the module
+//     proxies (GOAUTH, .netrc or credentials embedded in the URL);
+//   - s3://bucket/prefix is an S3 bucket, whose requests are signed with the AWS_ACCESS_KEY_ID,
+//     AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN credentials in AWS_REGION;
+//   - gs://bucket/prefix is a Google Cloud Storage bucket, authenticated with the OAuth token in
+//     GOOGLE_OAUTH_ACCESS_TOKEN, or like HTTP stores if it is not set.
+func newCacheStore(location string) (cacheStore, error) {
+	u, err := url.Parse(location)
+	if err != nil || u.Host == "" {
+		return dirCacheStore(location), nil
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	switch u.Scheme {
+	case "http", "https":
+		auth, err := newAuthenticator(os.Getenv("GOAUTH"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up authentication: %w", err)
+		}
+		return &httpCacheStore{base: location, client: client, sign: func(req *http.Request, _ []byte) { auth.authenticate(req) }}, nil
+	case "s3":
+		signer, err := newS3Signer()
+		if err != nil {
+			return nil, err
+		}
+		base := fmt.Sprintf("https://%s.s3.%s.amazonaws.com%s", u.Host, signer.region, u.Path)
+		sign := func(req *http.Request, body []byte) { signer.sign(req, body, time.Now()) }
+		return &httpCacheStore{base: base, client: client, sign: sign, forbiddenIsMissing: true}, nil
+	case "gs":
+		base := fmt.Sprintf("https://storage.googleapis.com/%s%s", u.Host, u.Path)
+		if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+			return &httpCacheStore{base: base, client: client, sign: func(req *http.Request, _ []byte) { req.Header.Set("Authorization", "Bearer "+token) }}, nil
+		}
+		auth, err := newAuthenticator(os.Getenv("GOAUTH"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up authentication: %w", err)
+		}
+		return &httpCacheStore{base: base, client: client, sign: func(req *http.Request, _ []byte) { auth.authenticate(req) }}, nil
+	default:
+		return nil, fmt.Errorf("unsupported detection cache URL scheme %q", u.Scheme)
+	}
+}
+
+// cacheEntryName returns the path of the entry relative to the root of the store. Entries are spread across
+// subdirectories named after the first two characters of their keys.
+func cacheEntryName(key string) string {
+	return key[:2] + "/" + key + ".json"
+}
+
+// dirCacheStore stores the entries in a directory. Entries are written atomically, by renaming complete files into
+// place, so that several processes, such as CI jobs sharing a cache volume, can read and write the cache
+// concurrently without locking.
+type dirCacheStore string
+
+func (d dirCacheStore) get(key string) ([]byte, error) {
+	// entries are complete once visible, so they can be read without locking
+	data, err := ioutil.ReadFile(filepath.Join(string(d), filepath.FromSlash(cacheEntryName(key))))
+	if os.IsNotExist(err) {
+		return nil, errCacheMiss
+	}
+	return data, err
+}
+
+func (d dirCacheStore) put(key string, data []byte) error {
+	path := filepath.Join(string(d), filepath.FromSlash(cacheEntryName(key)))
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic writes the file under a temporary name in the same directory and renames it into place, so that
+// readers see either no file or the complete file.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// httpCacheStore stores the entries under a URL, which is read with GET requests and written with PUT requests.
+// The URLs are left out of the errors as they may embed credentials.
+type httpCacheStore struct {
+	base   string
+	client *http.Client
+	sign   func(req *http.Request, body []byte) // adds the credentials to the request
+
+	// S3 answers 403 rather than 404 for missing objects when the credentials cannot list the bucket
+	forbiddenIsMissing bool
+}
+
+func (s *httpCacheStore) get(key string) ([]byte, error) {
+	var data []byte
+	err := remote.do(func() error {
+		resp, err := s.do(http.MethodGet, key, nil)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden && s.forbiddenIsMissing {
+			return permanentError{errCacheMiss}
+		}
+		if err := checkResponseStatus(resp); err != nil {
+			return fmt.Errorf("failed to get %s: %w", cacheEntryName(key), err)
+		}
+
+		data, err = ioutil.ReadAll(resp.Body)
+		return err
+	})
+	if errors.Is(err, errCacheMiss) {
+		return nil, errCacheMiss
+	}
+	return data, err
+}
+
+func (s *httpCacheStore) put(key string, data []byte) error {
+	return remote.do(func() error {
+		resp, err := s.do(http.MethodPut, key, data)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			return nil
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			return fmt.Errorf("failed to put %s: unexpected response status: %s", cacheEntryName(key), resp.Status)
+		default:
+			return permanentError{fmt.Errorf("failed to put %s: unexpected response status: %s", cacheEntryName(key), resp.Status)}
+		}
+	})
+}
+
+func (s *httpCacheStore) do(method, key string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, strings.TrimSuffix(s.base, "/")+"/"+cacheEntryName(key), bytes.NewReader(body))
+	if err != nil {
+		return nil, permanentError{fmt.Errorf("invalid detection cache URL")}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	s.sign(req, body)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("failed to reach the detection cache: %w", err)
+	}
+	return resp, nil
+}
+
+// s3Signer signs the requests to S3 with AWS Signature Version 4.
+type s3Signer struct {
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	region       string
+}
+
+func newS3Signer() (*s3Signer, error) {
+	s := &s3Signer{
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		region:       os.Getenv("AWS_REGION"),
+	}
+	if s.region == "" {
+		s.region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if s.region == "" {
+		s.region = "us-east-1"
+	}
+
+	if s.accessKey == "" || s.secretKey == "" {
+		return nil, errors.New("S3 detection caches require AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	return s, nil
+}
+
+// sign adds the authorization headers to the request. All the headers set on the request are signed.
+func (s *s3Signer) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	scope := amzDate[:8] + "/" + s.region + "/s3/aws4_request"
+	payloadHash := sha256.Sum256(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		strings.Replace(req.URL.Query().Encode(), "+", "%20", -1),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + s.secretKey)
+	for _, part := range []string{amzDate[:8], s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+const programName = "licence-detector"
+
+// completionValues lists the values offered when completing the argument of a flag.
+var completionValues = map[string]func() []string{
+	"color":        func() []string { return []string{"auto", "always", "never"} },
+	"errors":       func() []string { return []string{"fail-fast", "collect"} },
+	"format":       func() []string { return append([]string{"notice", streamFormat}, exportFormats()...) },
+	"input-format": inputFormatNames,
+	"overflow":     func() []string { return overflowStrategies },
+	"preset":       presetNames,
+	"sort":         sortKeyNames,
+	"symlinks":     func() []string { return []string{"follow", "skip", "error"} },
+}
+
+var completionShells = map[string]func(io.Writer) error{
+	"bash": writeBashCompletion,
+	"fish": writeFishCompletion,
+	"zsh":  writeZshCompletion,
+}
+
+func init() {
+	subcommands["completion"] = completion
+}
+
+// completion writes the completion script for the shell given as argument to stdout.
+func completion() {
+	if flag.NArg() != 1 {
+		log.Fatal("Usage: completion bash|zsh|fish")
+	}
+
+	gen, ok := completionShells[flag.Arg(0)]
+	if !ok {
+		log.Fatalf("Unsupported shell %q: must be one of bash, zsh, fish", flag.Arg(0))
+	}
+
+	if err := gen(os.Stdout); err != nil {
+		log.Fatalf("Failed to write completion script: %v", err)
+	}
+}
+
+func subcommandNames() []string {
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	bf, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && bf.IsBoolFlag()
+}
+
+func writeBashCompletion(w io.Writer) error {
+	var flagNames, argFlags []string
+	var valueCases strings.Builder
+	flag.VisitAll(func(f *flag.Flag) {
+		flagNames = append(flagNames, "-"+f.Name)
+		if isBoolFlag(f) {
+			return
+		}
+		if values, ok := completionValues[f.Name]; ok {
+			fmt.Fprintf(&valueCases, "\t\t-%s)\n\t\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n\t\t\treturn\n\t\t\t;;\n",
+				f.Name, strings.Join(values(), " "))
+			return
+		}
+		argFlags = append(argFlags, "-"+f.Name)
+	})
+
+	fn := "_" + strings.Replace(programName, "-", "_", -1)
+	_, err := fmt.Fprintf(w, `# bash completion for %[1]s
+
+%[2]s() {
+	local cur="${COMP_WORDS[COMP_CWORD]}"
+	local prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+	case "$prev" in
+%[3]s		%[4]s)
+			return
+			;;
+	esac
+
+	if [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then
+		COMPREPLY=($(compgen -W %[5]q -- "$cur"))
+		return
+	fi
+
+	COMPREPLY=($(compgen -W %[6]q -- "$cur"))
+}
+
+complete -o default -F %[2]s %[1]s
+`, programName, fn, valueCases.String(), strings.Join(argFlags, "|"),
+		strings.Join(subcommandNames(), " "), strings.Join(flagNames, " "))
+	return err
+}
+
+// writeZshCompletion reuses the bash completion through zsh's bash compatibility layer.
+func writeZshCompletion(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "#compdef %s\n\nautoload -U +X bashcompinit && bashcompinit\n\n", programName); err != nil {
+		return err
+	}
+	return writeBashCompletion(w)
+}
+
+func writeFishCompletion(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "# fish completion for %s\n\n", programName); err != nil {
+		return err
+	}
+
+	for _, name := range subcommandNames() {
+		if _, err := fmt.Fprintf(w, "complete -c %s -n '__fish_use_subcommand' -f -a %s\n", programName, name); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	flag.VisitAll(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+
+		line := fmt.Sprintf("complete -c %s -o %s -d %s", programName, f.Name, fishQuote(f.Usage))
+		if !isBoolFlag(f) {
+			line += " -r"
+			if values, ok := completionValues[f.Name]; ok {
+				line += " -f -a " + fishQuote(strings.Join(values(), " "))
+			}
+		}
+		_, err = fmt.Fprintln(w, line)
+	})
+	return err
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func registeredFlagNames() []string {
+	var names []string
+	flag.VisitAll(func(f *flag.Flag) {
+		names = append(names, f.Name)
+	})
+	sort.Strings(names)
+	return names
+}
+
+func registeredSubcommands() []string {
+	var names []string
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var compgenRegex = regexp.MustCompile(`compgen -W "([^"]*)" -- "\$cur"`)
+
+// bashCompletionWords returns the subcommands and the flags offered by a bash completion script, which are the last
+// two word lists of the script.
+func bashCompletionWords(t *testing.T, script string) (subcommands, flags []string) {
+	t.Helper()
+
+	matches := compgenRegex.FindAllStringSubmatch(script, -1)
+	require.True(t, len(matches) >= 2, "expected the subcommand and flag word lists")
+
+	subcommands = strings.Fields(matches[len(matches)-2][1])
+	for _, f := range strings.Fields(matches[len(matches)-1][1]) {
+		require.True(t, strings.HasPrefix(f, "-"), f)
+		flags = append(flags, strings.TrimPrefix(f, "-"))
+	}
+	sort.Strings(subcommands)
+	sort.Strings(flags)
+	return subcommands, flags
+}
+
+func TestCompletionScripts(t *testing.T) {
+	wantSubcommands := registeredSubcommands()
+	wantFlags := registeredFlagNames()
+	require.Contains(t, wantSubcommands, "completion")
+
+	testCases := []struct {
+		shell  string
+		header string
+		words  func(t *testing.T, script string) (subcommands, flags []string)
+	}{
+		{
+			shell:  "bash",
+			header: "# bash completion for licence-detector\n",
+			words:  bashCompletionWords,
+		},
+		{
+			shell:  "zsh",
+			header: "#compdef licence-detector\n",
+			words:  bashCompletionWords,
+		},
+		{
+			shell:  "fish",
+			header: "# fish completion for licence-detector\n",
+			words: func(t *testing.T, script string) (subcommands, flags []string) {
+				subcommandRegex := regexp.MustCompile(`(?m)^complete -c licence-detector -n '__fish_use_subcommand' -f -a (\S+)$`)
+				for _, m := range subcommandRegex.FindAllStringSubmatch(script, -1) {
+					subcommands = append(subcommands, m[1])
+				}
+				flagRegex := regexp.MustCompile(`(?m)^complete -c licence-detector -o (\S+) -d `)
+				for _, m := range flagRegex.FindAllStringSubmatch(script, -1) {
+					flags = append(flags, m[1])
+				}
+				sort.Strings(subcommands)
+				sort.Strings(flags)
+				return subcommands, flags
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, completionShells[tc.shell](&buf))
+			script := buf.String()
+			require.True(t, strings.HasPrefix(script, tc.header), script)
+
+			subcommands, flags := tc.words(t, script)
+			require.Equal(t, wantSubcommands, subcommands)
+			require.Equal(t, wantFlags, flags)
+		})
+	}
+}
+
+func TestCompletionValues(t *testing.T) {
+	// the values must belong to a registered flag that takes an argument
+	for name, values := range completionValues {
+		f := flag.Lookup(name)
+		require.NotNil(t, f, name)
+		require.False(t, isBoolFlag(f), name)
+		require.NotEmpty(t, values(), name)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeBashCompletion(&buf))
+	require.Contains(t, buf.String(), "\t\t-symlinks)\n\t\t\tCOMPREPLY=($(compgen -W \"follow skip error\" -- \"$cur\"))\n")
+
+	buf.Reset()
+	require.NoError(t, writeFishCompletion(&buf))
+	require.Contains(t, buf.String(), " -r -f -a 'follow skip error'\n")
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+var (
+	// copyrightLineRegex matches copyright statements such as "Copyright (c) 2015-2018, 2020 Google Inc.", capturing
+	// the years and the holder.
+	copyrightLineRegex = regexp.MustCompile(`(?i)^(?:copyright\b|©|\(c\))[\s,:]*(?:(?:\(c\)|©|copyright\b)[\s,:]*)*((?:\d{4}(?:\s*[-–]\s*\d{4})?[\s,]*)*)(.*)$`)
+	yearRangeRegex     = regexp.MustCompile(`(\d{4})(?:\s*[-–]\s*(\d{4}))?`)
+	// copyrightPlaceholderRegex matches the templates of copyright statements found in licence texts, as in the
+	// appendix of the Apache licence.
+	copyrightPlaceholderRegex = regexp.MustCompile(`(?i)[\[{]|<(?:year|name|owner|copyright|author)`)
+	allRightsReservedRegex    = regexp.MustCompile(`(?i)[\s,;]*all rights reserved[\s.]*$`)
+)
+
+// copyrightStatement is a copyright holder along with the years of their copyright.
+type copyrightStatement struct {
+	holder string
+	years  map[int]struct{}
+}
+
+// parseCopyrightLine returns the copyright statement of the line, if it is one.
+func parseCopyrightLine(line string) (string, []int, bool) {
+	line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#*/;-"))
+	m := copyrightLineRegex.FindStringSubmatch(line)
+	if m == nil || copyrightPlaceholderRegex.MatchString(line) {
+		return "", nil, false
+	}
+
+	holder := allRightsReservedRegex.ReplaceAllString(m[2], "")
+	holder = strings.TrimPrefix(strings.Join(strings.Fields(holder), " "), "by ")
+	holder = strings.TrimRight(holder, ",; ")
+	// licence texts refer to "copyright notices" and "copyright holders"
+	if holder == "" || startsWithAny(strings.ToLower(holder), "notice", "holder", "owner", "and ", "law", "statement") {
+		return "", nil, false
+	}
+
+	var years []int
+	for _, r := range yearRangeRegex.FindAllStringSubmatch(m[1], -1) {
+		from, _ := strconv.Atoi(r[1])
+		to := from
+		if r[2] != "" {
+			to, _ = strconv.Atoi(r[2])
+		}
+		if to < from || to-from > 100 {
+			years = append(years, from, to)
+			continue
+		}
+		for y := from; y <= to; y++ {
+			years = append(years, y)
+		}
+	}
+
+	return holder, years, true
+}
+
+func startsWithAny(s string, prefixes ...string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeCopyrights collapses the copyright statements of the texts by holder, compared regardless of case, and
+// renders them sorted by holder with their years merged into ranges.
+func mergeCopyrights(texts []string) []string {
+	byHolder := make(map[string]*copyrightStatement)
+	for _, text := range texts {
+		for _, line := range strings.Split(text, "\n") {
+			holder, years, ok := parseCopyrightLine(line)
+			if !ok {
+				continue
+			}
+
+			// the final dot may end the sentence or an abbreviation such as Inc.
+			key := strings.ToLower(strings.TrimSuffix(holder, "."))
+			s, ok := byHolder[key]
+			if !ok {
+				s = &copyrightStatement{holder: holder, years: make(map[int]struct{})}
+				byHolder[key] = s
+			}
+			for _, y := range years {
+				s.years[y] = struct{}{}
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(byHolder))
+	for key := range byHolder {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	statements := make([]string, len(keys))
+	for i, key := range keys {
+		s := byHolder[key]
+		if len(s.years) == 0 {
+			statements[i] = "Copyright (c) " + s.holder
+			continue
+		}
+		statements[i] = "Copyright (c) " + formatYears(s.years) + " " + s.holder
+	}
+	return statements
+}
+
+// formatYears renders the years as a comma-separated list of ranges, as in 2012, 2015-2018.
+func formatYears(years map[int]struct{}) string {
+	sorted := make([]int, 0, len(years))
+	for y := range years {
+		sorted = append(sorted, y)
+	}
+	sort.Ints(sorted)
+
+	var ranges []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] == sorted[j]+1 {
+			j++
+		}
+		if i == j {
+			ranges = append(ranges, strconv.Itoa(sorted[i]))
+		} else {
+			ranges = append(ranges, strconv.Itoa(sorted[i])+"-"+strconv.Itoa(sorted[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(ranges, ", ")
+}
+
+/* Template functions */
+
+// Copyrights returns the copyright statements found in the licence texts and copyright notices of the dependencies,
+// deduplicated by holder with their years merged, as in "Copyright (c) 2012, 2015-2018 Google Inc.".
+func Copyrights(deps []detector.LicenceInfo) []string {
+	var texts []string
+	for _, dep := range deps {
+		if dep.LicenceFile != "" {
+			for _, path := range licenceFiles(dep) {
+				texts = append(texts, readLicenceFile(path))
+			}
+		}
+		if dep.CopyrightFile != "" {
+			texts = append(texts, readLicenceFile(dep.CopyrightFile))
+		}
+	}
+	return mergeCopyrights(texts)
+}
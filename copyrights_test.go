@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeCopyrights(t *testing.T) {
+	texts := []string{
+		"Copyright (c) 2015-2017 Google Inc. All rights reserved.\n\nRedistribution and use in source and binary forms...\n" +
+			"THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS \"AS IS\"\n",
+		"Copyright 2012, 2018 Google Inc.\n",
+		"// Copyright © 2019 google inc.\n",
+		"The above copyright notice and this permission notice shall be included...\n" +
+			"Copyright notice\n" +
+			"Copyright [yyyy] [name of copyright owner]\n",
+		"Copyright The Go Authors\n",
+		"(c) 2020 Jane Doe <jane@example.com>\n",
+	}
+
+	require.Equal(t, []string{
+		"Copyright (c) 2012, 2015-2019 Google Inc.",
+		"Copyright (c) 2020 Jane Doe <jane@example.com>",
+		"Copyright (c) The Go Authors",
+	}, mergeCopyrights(texts))
+}
+
+func TestCopyrightYears(t *testing.T) {
+	defer func(run runMetadata) { currentRun = run }(currentRun)
+	defer func(year int) { *inceptionYearFlag = year }(*inceptionYearFlag)
+
+	currentRun = runMetadata{time: time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)}
+
+	testCases := []struct {
+		name          string
+		inceptionYear int
+		start         []int
+		want          string
+		wantErr       bool
+	}{
+		{name: "NoInceptionYear", want: "2020"},
+		{name: "InceptionYear", inceptionYear: 2015, want: "2015–2020"},
+		{name: "InceptionYearIsCurrentYear", inceptionYear: 2020, want: "2020"},
+		{name: "InceptionYearInFuture", inceptionYear: 2021, want: "2020"},
+		{name: "Start", start: []int{2018}, want: "2018–2020"},
+		{name: "StartOverridesInceptionYear", inceptionYear: 2015, start: []int{2019}, want: "2019–2020"},
+		{name: "StartIsCurrentYear", inceptionYear: 2015, start: []int{2020}, want: "2020"},
+		{name: "ZeroStart", inceptionYear: 2015, start: []int{0}, want: "2020"},
+		{name: "TooManyArguments", start: []int{2015, 2018}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			*inceptionYearFlag = tc.inceptionYear
+
+			have, err := CopyrightYears(tc.start...)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, have)
+		})
+	}
+}
+
+func TestCopyrightYearsCurrentYear(t *testing.T) {
+	defer func(run runMetadata) { currentRun = run }(currentRun)
+	defer func(year int) { *inceptionYearFlag = year }(*inceptionYearFlag)
+
+	// before the run has started, the range ends at the current year
+	currentRun = runMetadata{}
+	*inceptionYearFlag = 2000
+
+	have, err := CopyrightYears()
+	require.NoError(t, err)
+	require.Equal(t, "2000–"+strconv.Itoa(time.Now().Year()), have)
+}
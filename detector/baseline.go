@@ -0,0 +1,121 @@
+package detector
+
+import (
+	"sort"
+	"strings"
+)
+
+type Change string
+
+const (
+	ChangeNone           Change = ""
+	ChangeNew            Change = "new"
+	ChangeVersionChanged Change = "version-changed"
+	ChangeLicenceChanged Change = "licence-changed"
+	ChangeRemoved        Change = "removed"
+)
+
+// BaselineEntry describes a dependency as recorded by a previous run.
+type BaselineEntry struct {
+	Path     string
+	Version  string
+	Licences []string
+}
+
+// Changelog lists the differences between a baseline and the current dependencies.
+type Changelog struct {
+	New            []ChangelogEntry
+	Removed        []ChangelogEntry
+	VersionChanged []ChangelogEntry
+	LicenceChanged []ChangelogEntry
+	Relicensed     []ChangelogEntry // licence changes between two known licences, a subset of LicenceChanged
+}
+
+type ChangelogEntry struct {
+	Path        string
+	OldVersion  string
+	NewVersion  string
+	OldLicences []string
+	NewLicences []string
+}
+
+func (c *Changelog) Empty() bool {
+	return len(c.New) == 0 && len(c.Removed) == 0 && len(c.VersionChanged) == 0 && len(c.LicenceChanged) == 0
+}
+
+// CompareBaseline annotates each dependency with how it changed since the baseline and records the changelog in
+// deps.Changelog. A dependency whose version and licences both changed is reported as licence-changed.
+func CompareBaseline(deps *Dependencies, baseline []BaselineEntry) {
+	previous := make(map[string]BaselineEntry, len(baseline))
+	for _, e := range baseline {
+		previous[e.Path] = e
+	}
+
+	changelog := &Changelog{}
+	seen := make(map[string]struct{})
+	for _, depList := range [][]LicenceInfo{deps.Direct, deps.Indirect, deps.Tools} {
+		for i := range depList {
+			dep := &depList[i]
+			seen[dep.Path] = struct{}{}
+			version := effectiveVersion(dep.Module)
+
+			old, ok := previous[dep.Path]
+			entry := ChangelogEntry{
+				Path:        dep.Path,
+				OldVersion:  old.Version,
+				NewVersion:  version,
+				OldLicences: old.Licences,
+				NewLicences: dep.Licences,
+			}
+
+			switch {
+			case !ok:
+				dep.Change = ChangeNew
+				changelog.New = append(changelog.New, entry)
+			case !sameLicences(old.Licences, dep.Licences):
+				dep.Change = ChangeLicenceChanged
+				changelog.LicenceChanged = append(changelog.LicenceChanged, entry)
+				if len(old.Licences) > 0 && len(dep.Licences) > 0 {
+					changelog.Relicensed = append(changelog.Relicensed, entry)
+				}
+			case old.Version != version:
+				dep.Change = ChangeVersionChanged
+				changelog.VersionChanged = append(changelog.VersionChanged, entry)
+			default:
+				dep.Change = ChangeNone
+			}
+		}
+	}
+
+	for _, e := range baseline {
+		if _, ok := seen[e.Path]; !ok {
+			changelog.Removed = append(changelog.Removed, ChangelogEntry{Path: e.Path, OldVersion: e.Version, OldLicences: e.Licences})
+		}
+	}
+
+	for _, entries := range [][]ChangelogEntry{changelog.New, changelog.Removed, changelog.VersionChanged, changelog.LicenceChanged, changelog.Relicensed} {
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Path < entries[j].Path
+		})
+	}
+
+	deps.Changelog = changelog
+}
+
+func effectiveVersion(mod Module) string {
+	if mod.Replace != nil {
+		return mod.Replace.Version
+	}
+	return mod.Version
+}
+
+func sameLicences(a, b []string) bool {
+	return normaliseLicences(a) == normaliseLicences(b)
+}
+
+func normaliseLicences(licences []string) string {
+	sorted := make([]string, len(licences))
+	copy(sorted, licences)
+	sort.Strings(sorted)
+	return strings.Join(sorted, " AND ")
+}
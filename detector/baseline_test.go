@@ -0,0 +1,60 @@
+package detector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareBaseline(t *testing.T) {
+	deps := &Dependencies{
+		Direct: []LicenceInfo{
+			{Module: Module{Path: "github.com/a/same", Version: "v1.0.0"}, Licences: []string{"MIT"}},
+			{Module: Module{Path: "github.com/b/upgraded", Version: "v1.1.0"}, Licences: []string{"MIT"}},
+			{Module: Module{Path: "github.com/c/new", Version: "v0.1.0"}},
+		},
+		Indirect: []LicenceInfo{
+			{
+				Module: Module{
+					Path:    "github.com/d/relicensed",
+					Version: "v2.0.0",
+					Replace: &Module{Path: "github.com/fork/relicensed", Version: "v2.0.1"},
+				},
+				Licences: []string{"BUSL-1.1"},
+			},
+			{Module: Module{Path: "github.com/f/classified", Version: "v1.0.0"}, Licences: []string{"Apache-2.0"}},
+		},
+	}
+
+	CompareBaseline(deps, []BaselineEntry{
+		{Path: "github.com/a/same", Version: "v1.0.0", Licences: []string{"MIT"}},
+		{Path: "github.com/b/upgraded", Version: "v1.0.0", Licences: []string{"MIT"}},
+		{Path: "github.com/d/relicensed", Version: "v2.0.0", Licences: []string{"MIT"}},
+		{Path: "github.com/e/removed", Version: "v3.0.0", Licences: []string{"ISC"}},
+		{Path: "github.com/f/classified", Version: "v1.0.0"},
+	})
+
+	require.Equal(t, ChangeNone, deps.Direct[0].Change)
+	require.Equal(t, ChangeVersionChanged, deps.Direct[1].Change)
+	require.Equal(t, ChangeNew, deps.Direct[2].Change)
+	require.Equal(t, ChangeLicenceChanged, deps.Indirect[0].Change)
+
+	require.Equal(t, &Changelog{
+		New: []ChangelogEntry{
+			{Path: "github.com/c/new", NewVersion: "v0.1.0"},
+		},
+		Removed: []ChangelogEntry{
+			{Path: "github.com/e/removed", OldVersion: "v3.0.0", OldLicences: []string{"ISC"}},
+		},
+		VersionChanged: []ChangelogEntry{
+			{Path: "github.com/b/upgraded", OldVersion: "v1.0.0", NewVersion: "v1.1.0", OldLicences: []string{"MIT"}, NewLicences: []string{"MIT"}},
+		},
+		LicenceChanged: []ChangelogEntry{
+			{Path: "github.com/d/relicensed", OldVersion: "v2.0.0", NewVersion: "v2.0.1", OldLicences: []string{"MIT"}, NewLicences: []string{"BUSL-1.1"}},
+			{Path: "github.com/f/classified", OldVersion: "v1.0.0", NewVersion: "v1.0.0", NewLicences: []string{"Apache-2.0"}},
+		},
+		Relicensed: []ChangelogEntry{
+			{Path: "github.com/d/relicensed", OldVersion: "v2.0.0", NewVersion: "v2.0.1", OldLicences: []string{"MIT"}, NewLicences: []string{"BUSL-1.1"}},
+		},
+	}, deps.Changelog)
+}
@@ -0,0 +1,112 @@
+package detector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type bazelQueryResult struct {
+	Results []bazelTarget `json:"results"`
+	bazelTarget
+}
+
+type bazelTarget struct {
+	Type string     `json:"type"`
+	Rule *bazelRule `json:"rule"`
+}
+
+type bazelRule struct {
+	Name      string           `json:"name"`
+	RuleClass string           `json:"ruleClass"`
+	Attribute []bazelAttribute `json:"attribute"`
+}
+
+type bazelAttribute struct {
+	Name        string `json:"name"`
+	StringValue string `json:"stringValue"`
+}
+
+// ParseBazelQuery reads the go_repository rules printed by bazel query with --output=jsonproto or
+// --output=streamed_jsonproto and returns the modules they fetch. The directory of each module is obtained from
+// dirFn, which is given the name of the external repository. Bazel does not distinguish indirect dependencies, so
+// all modules are reported as direct dependencies.
+func ParseBazelQuery(r io.Reader, dirFn func(repo string, mod Module) string) ([]Module, error) {
+	var mods []Module
+	decoder := json.NewDecoder(r)
+	for {
+		var res bazelQueryResult
+		if err := decoder.Decode(&res); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse bazel query output: %w", err)
+		}
+
+		// streamed_jsonproto prints one target per line instead of a list of results
+		targets := res.Results
+		if res.Rule != nil {
+			targets = append(targets, res.bazelTarget)
+		}
+
+		for _, t := range targets {
+			if t.Rule == nil || t.Rule.RuleClass != "go_repository" {
+				continue
+			}
+
+			repo, mod, err := t.Rule.module()
+			if err != nil {
+				return nil, err
+			}
+
+			// as with go list, Dir holds the directory of the replacement if there is one
+			if mod.Replace != nil {
+				mod.Replace.Dir = dirFn(repo, *mod.Replace)
+				mod.Dir = mod.Replace.Dir
+			} else {
+				mod.Dir = dirFn(repo, mod)
+			}
+			mods = append(mods, mod)
+		}
+	}
+
+	sort.Slice(mods, func(i, j int) bool {
+		return mods[i].Path < mods[j].Path
+	})
+
+	return mods, nil
+}
+
+func (r *bazelRule) module() (string, Module, error) {
+	attrs := make(map[string]string, len(r.Attribute))
+	for _, a := range r.Attribute {
+		attrs[a.Name] = a.StringValue
+	}
+
+	repo := attrs["name"]
+	if repo == "" {
+		repo = bazelRepoName(r.Name)
+	}
+
+	mod := Module{Path: attrs["importpath"], Version: attrs["version"]}
+	if mod.Path == "" {
+		return "", mod, fmt.Errorf("go_repository %s has no importpath", r.Name)
+	}
+
+	if replace := attrs["replace"]; replace != "" {
+		mod.Replace = &Module{Path: replace, Version: mod.Version}
+	}
+
+	return repo, mod, nil
+}
+
+// bazelRepoName extracts the repository name from labels such as //external:com_github_foo_bar or
+// @com_github_foo_bar//:com_github_foo_bar.
+func bazelRepoName(label string) string {
+	if strings.HasPrefix(label, "@") {
+		return strings.SplitN(strings.TrimPrefix(label, "@"), "//", 2)[0]
+	}
+	return strings.TrimPrefix(label, "//external:")
+}
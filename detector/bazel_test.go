@@ -0,0 +1,36 @@
+package detector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBazelQuery(t *testing.T) {
+	f, err := os.Open("testdata/bazel.json")
+	require.NoError(t, err)
+	defer f.Close()
+
+	mods, err := ParseBazelQuery(f, func(repo string, mod Module) string {
+		return ModuleCacheDir("/cache", mod.Path, mod.Version) + ":" + repo
+	})
+	require.NoError(t, err)
+
+	spewDir := filepath.Join("/cache", "github.com", "!example", "go-spew@v1.1.0") + ":com_github_davecgh_go_spew"
+	want := []Module{
+		{
+			Path:    "github.com/davecgh/go-spew",
+			Version: "v1.1.0",
+			Dir:     spewDir,
+			Replace: &Module{Path: "github.com/Example/go-spew", Version: "v1.1.0", Dir: spewDir},
+		},
+		{
+			Path:    "github.com/russross/blackfriday",
+			Version: "v1.5.2",
+			Dir:     filepath.Join("/cache", "github.com", "russross", "blackfriday@v1.5.2") + ":com_github_russross_blackfriday",
+		},
+	}
+	require.Equal(t, want, mods)
+}
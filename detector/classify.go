@@ -0,0 +1,150 @@
+package detector
+
+import (
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+type licenceSignature struct {
+	id       string
+	title    string // phrase that must appear in the header of the text, where licences state their name and version
+	required []string
+	excluded []string
+}
+
+// headerLength is the number of normalised characters at the start of a text in which the title of a licence is
+// looked up. It leaves room for a copyright line or a short preamble before the licence itself.
+const headerLength = 500
+
+// Signatures are checked in order and the first match wins. More specific licences must therefore come before the
+// licences whose texts they quote (e.g. the LGPL quotes the GPL). The GNU and Mozilla licences refer to each other in
+// their terms (the GPL-3.0 mentions the AGPL-3.0 and the MPL-2.0 lists the GNU licences as secondary licences), so
+// their full texts are recognised by their titles and their short notices by the standard notice wording.
+var licenceSignatures = []licenceSignature{
+	{id: "AGPL-3.0", title: "gnu affero general public license version 3"},
+	{id: "LGPL-3.0", title: "gnu lesser general public license version 3"},
+	{id: "LGPL-2.1", title: "gnu lesser general public license version 2.1"},
+	{id: "LGPL-2.0", title: "gnu library general public license version 2"},
+	{id: "GPL-3.0", title: "gnu general public license version 3"},
+	{id: "GPL-2.0", title: "gnu general public license version 2"},
+	{id: "MPL-2.0", title: "mozilla public license version 2.0"},
+	{id: "AGPL-3.0", required: []string{"gnu affero general public license as published by the free software foundation", "either version 3 of the license"}},
+	{id: "LGPL-3.0", required: []string{"gnu lesser general public license as published by the free software foundation", "either version 3 of the license"}},
+	{id: "LGPL-2.1", required: []string{"gnu lesser general public license as published by the free software foundation", "either version 2.1 of the license"}},
+	{id: "LGPL-2.0", required: []string{"gnu library general public license as published by the free software foundation", "either version 2 of the license"}},
+	{id: "GPL-3.0", required: []string{"gnu general public license as published by the free software foundation", "either version 3 of the license"}},
+	{id: "GPL-2.0", required: []string{"gnu general public license as published by the free software foundation", "either version 2 of the license"}},
+	{id: "MPL-2.0", required: []string{"subject to the terms of the mozilla public license, v. 2.0"}},
+	{id: "EPL-2.0", required: []string{"eclipse public license - v 2.0"}},
+	{id: "EPL-1.0", required: []string{"eclipse public license - v 1.0"}},
+	{id: "BUSL-1.1", required: []string{"business source license"}},
+	{id: "Apache-2.0", required: []string{"apache license", "version 2.0"}},
+	{id: "BSL-1.0", required: []string{"boost software license - version 1.0"}},
+	{id: "CC0-1.0", required: []string{"cc0 1.0 universal"}},
+	{id: "Unlicense", required: []string{"this is free and unencumbered software released into the public domain"}},
+	{id: "BSD-3-Clause", required: []string{"redistribution and use in source and binary forms", "endorse or promote products derived from this software"}},
+	{id: "BSD-2-Clause", required: []string{"redistribution and use in source and binary forms"}, excluded: []string{"endorse or promote products derived from this software"}},
+	{id: "MIT", required: []string{"permission is hereby granted, free of charge, to any person obtaining a copy"}},
+	{id: "ISC", required: []string{"permission to use, copy, modify, and", "distribute this software for any purpose with or without fee is hereby granted"}},
+	{id: "Zlib", required: []string{"altered source versions must be plainly marked as such"}},
+}
+
+var whitespaceRegex = regexp.MustCompile(`\s+`)
+
+// licenceClassification is the result of the classification of a licence file.
+type licenceClassification struct {
+	licences   []string // SPDX identifier of the licence, if it could be recognised
+	encoding   string
+	language   string
+	translated bool // the licence was recognised from a known translation
+}
+
+// classifyLicenceFile returns the licence contained in the given file. Texts in other languages than English are
+// first compared with the known translations of the licences.
+func classifyLicenceFile(path string) (licenceClassification, error) {
+	var c licenceClassification
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+
+	decoded, err := DecodeLicenceText(text, false)
+	if err != nil {
+		return c, err
+	}
+
+	c.encoding, c.language = DetectEncoding(text), DetectLanguage(decoded)
+	if c.language != LanguageEnglish {
+		if id := classifyTranslatedText(decoded, c.language); id != "" {
+			c.licences, c.translated = []string{id}, true
+			return c, nil
+		}
+	}
+
+	if id := classifyLicenceText(decoded); id != "" {
+		c.licences = []string{id}
+	}
+
+	return c, nil
+}
+
+func classifyLicenceText(text string) string {
+	normalised := normaliseLicenceText(text)
+	for _, sig := range licenceSignatures {
+		if sig.matches(normalised) {
+			return sig.id
+		}
+	}
+
+	return ""
+}
+
+func normaliseLicenceText(text string) string {
+	text = strings.NewReplacer("#", " ", "*", " ", "//", " ").Replace(strings.ToLower(text))
+	return whitespaceRegex.ReplaceAllString(text, " ")
+}
+
+func (sig licenceSignature) matches(text string) bool {
+	if len(sig.missing(text)) > 0 {
+		return false
+	}
+
+	for _, s := range sig.excluded {
+		if strings.Contains(text, s) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// phrases returns the phrases that a text must contain to match the signature.
+func (sig licenceSignature) phrases() []string {
+	if sig.title == "" {
+		return sig.required
+	}
+	return append([]string{sig.title}, sig.required...)
+}
+
+// missing returns the phrases of the signature that the normalised text does not contain.
+func (sig licenceSignature) missing(text string) []string {
+	var missing []string
+	if sig.title != "" {
+		header := text
+		if len(header) > headerLength {
+			header = header[:headerLength]
+		}
+		if !strings.Contains(header, sig.title) {
+			missing = append(missing, sig.title)
+		}
+	}
+
+	for _, s := range sig.required {
+		if !strings.Contains(text, s) {
+			missing = append(missing, s)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,67 @@
+package detector
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// isCopyrightFileName reports whether the file name is the name of a copyright notice, such as COPYRIGHT or
+// copyright.txt.
+func isCopyrightFileName(name string) bool {
+	name = strings.ToLower(name)
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		if _, ok := textExtensions[name[idx+1:]]; ok {
+			name = name[:idx]
+		}
+	}
+	return name == "copyright"
+}
+
+// findCopyrightFile returns the copyright notice at the module root, which some modules ship separately from their
+// licence, or an empty string if there is none.
+func findCopyrightFile(root string, w *walker) (string, error) {
+	d, err := os.Open(root)
+	if err != nil {
+		if os.IsPermission(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer d.Close()
+
+	names, err := d.Readdirnames(-1)
+	if err != nil {
+		if os.IsPermission(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := filepath.Join(root, name)
+		if !isCopyrightFileName(name) || w.ignored(root, path) {
+			continue
+		}
+
+		fi, err := w.stat(path)
+		if err != nil {
+			return "", err
+		}
+		if fi == nil || !fi.Mode().IsRegular() {
+			continue
+		}
+
+		ok, err := w.isLicenceCandidate(path)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return path, nil
+		}
+	}
+
+	return "", nil
+}
@@ -5,29 +5,66 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"os"
+	"path"
 	"path/filepath"
-	"regexp"
 	"sort"
 	"strings"
 	"time"
-
-	"github.com/karrick/godirwalk"
 )
 
-var errLicenceNotFound = errors.New("failed to detect licence")
+// ErrLicenceNotFound is the error of the dependencies in which no licence file was found.
+var ErrLicenceNotFound = errors.New("failed to detect licence")
 
 type Dependencies struct {
-	Direct   []LicenceInfo
-	Indirect []LicenceInfo
+	Direct    []LicenceInfo
+	Indirect  []LicenceInfo
+	Tools     []LicenceInfo   // modules providing the build tools of Options.Tools, whatever the indirect policy
+	Skipped   []SkippedModule // modules of the input left out of the results
+	Changelog *Changelog      // changes since the baseline, if one was compared
+}
+
+// Reasons for leaving a module of the input out of the results.
+const (
+	SkipMain     = "main module"
+	SkipIndirect = "indirect dependency"
+	SkipMissing  = "sources not available"
+	SkipPolicy   = "excluded by the indirect dependency policy"
+)
+
+// SkippedModule is a module of the input that is not part of the results. Detail gives more information about the
+// reason, if any.
+type SkippedModule struct {
+	Module
+	Reason string
+	Detail string
 }
 
 type LicenceInfo struct {
 	Module
-	LicenceFile string
-	Error       error
+	LicenceFile       string
+	CopyrightFile     string         // copyright notice shipped separately from the licence
+	LicenceFiles      []string       // every licence file of a REUSE-compliant module
+	CandidateFiles    []string       // every file that looks like a licence, LicenceFile being the one chosen
+	Licences          []string       // SPDX identifiers of the licences declared by the module
+	LicenceExpression string         // SPDX licence expression declared by the module, if any, such as MIT OR Apache-2.0
+	Language          string         // ISO 639-1 code of the language of the licence text, if it is not English
+	Source            string         // how the licence was detected
+	Warnings          []string       // non-fatal problems encountered during detection
+	Subcomponents     []Subcomponent // copies of other projects inside the module, with Options.Subcomponents
+	Evidence          []FileEvidence // files examined during the detection, with Options.RecordEvidence
+	Change            Change         // how the dependency changed since the baseline
+	Error             error
 }
 
+const (
+	SourceExec     = "exec"
+	SourceFile     = "file"
+	SourceParent   = "parent"
+	SourceReuse    = "reuse"
+	SourceScanCode = "scancode"
+)
+
 type Module struct {
 	Path     string     // module path
 	Version  string     // module version
@@ -36,41 +73,155 @@ type Module struct {
 	Indirect bool       // is this module only an indirect dependency of main module?
 	Dir      string     // directory holding files for this module, if any
 	Replace  *Module    // replace directive
+
+	// Verification records how the sources were checked against their expected hash when they were not provided by
+	// the go command.
+	Verification string `json:",omitempty"`
+}
+
+type Options struct {
+	IncludeIndirect bool             // include indirect dependencies (same as IndirectAll, ignored if Indirect is set)
+	Indirect        IndirectPolicy   // which indirect dependencies are included
+	ScanCode        *ScanCodeResults // ScanCode toolkit results used to enrich detection
+	MaxDepth        int              // maximum directory depth of the fallback walk (0 means unlimited)
+	Symlinks        SymlinkPolicy    // how symlinks in module trees are handled (defaults to follow)
+	MaxLicenceSize  int64            // licence candidates larger than this many bytes are skipped (0 means unlimited)
+	ExecDetector    []string         // command and arguments of an external detector invoked for each module
+	Supplement      *Supplement      // dependencies missing from the input, such as cgo-linked libraries
+	SortedWalk      bool             // walk directories in lexical order so that warnings are reported in a stable order
+	SkipMissing     bool             // leave out the dependencies whose sources are not available instead of failing
+	Subcomponents   bool             // record the licences of the third_party/ and vendor/ trees as sub-components
+	Ignore          []IgnoreRule     // files and directories excluded from the detection
+	ModuleTimeout   time.Duration    // maximum time spent walking the tree of a module (0 means unlimited)
+	Errors          ErrorStrategy    // how failures to process a module are handled (defaults to fail-fast)
+	Tools           []string         // package paths of the build tools, whose modules are listed in Dependencies.Tools
+	RecordEvidence  bool             // record the files examined in LicenceInfo.Evidence, with the rejection reasons
+	AllCandidates   bool             // walk the whole tree even when the root holds a licence (implied by Subcomponents and LicencePreference)
+
+	// LinkedModules holds the paths of the modules providing packages linked into the binary, as returned by
+	// ParseLinkedModules. It is required by the IndirectLinked policy.
+	LinkedModules map[string]struct{}
+
+	// LicencePreference ranks the candidate licence files when a module has several. Each entry is a
+	// case-insensitive pattern, as accepted by path.Match, matched against the slash-separated path relative to the
+	// module root. Files that match no pattern come last.
+	LicencePreference []string
+
+	// LicenceDirs holds the directories in which the licence of the modules that have none of their own is looked
+	// up, by module path. It takes precedence over the lookup in the enclosing directories of local replacements.
+	LicenceDirs map[string]string
+
+	// GOPATH holds the source directories of the dependencies resolved from the GOPATH by legacy builds, by import
+	// path, as returned by ParseGOPATHDependencies. They are detected as direct dependencies without a version.
+	GOPATH map[string]string
+
+	// OnModuleDetected is called after the licence of each module has been detected with the time it took. The
+	// results passed to it are final, which allows them to be streamed.
+	OnModuleDetected func(dep LicenceInfo, elapsed time.Duration)
+
+	// OnWarning is called with the non-fatal findings of the detection of each module as they are found. Skipped
+	// files and timeouts are recorded in LicenceInfo.Warnings too.
+	OnWarning func(mod Module, w Warning)
+
+	// Cached returns the results of a previous detection of the module, which are used instead of detecting its
+	// licence again. The module of the results is replaced by the one of the dependency list.
+	Cached func(mod Module) (LicenceInfo, bool)
+
+	// trace records the steps of the detection, as used by Explain.
+	trace func(format string, args ...interface{})
 }
 
 func Detect(data io.Reader, includeIndirect bool) (*Dependencies, error) {
-	dependencies, err := parseDependencies(data, includeIndirect)
+	return DetectWithOptions(data, &Options{IncludeIndirect: includeIndirect})
+}
+
+func DetectWithOptions(data io.Reader, opts *Options) (*Dependencies, error) {
+	if opts.Symlinks == "" {
+		opts.Symlinks = SymlinkFollow
+	}
+
+	dependencies, err := parseDependencies(data, opts)
 	if err != nil {
-		log.Fatalf("Failed to parse dependencies: %v", err)
+		return nil, err
+	}
+
+	if opts.Supplement != nil {
+		opts.Supplement.addTo(dependencies, opts.indirectPolicy() != IndirectNone)
+		sortDependencies(dependencies)
+	}
+
+	if len(opts.GOPATH) > 0 {
+		addGOPATHDependencies(dependencies, opts.GOPATH)
+		sortDependencies(dependencies)
+	}
+
+	collected := &DetectionErrors{}
+	if missing := checkModuleDirs(dependencies); len(missing) > 0 {
+		switch {
+		case opts.SkipMissing:
+		case opts.Errors == ErrorsCollect:
+			collected.Missing = missing
+		default:
+			return dependencies, &MissingModulesError{Modules: missing}
+		}
+		removeMissing(dependencies, missing)
 	}
 
-	err = detectLicences(dependencies)
-	return dependencies, err
+	if err := detectLicences(dependencies, opts, collected); err != nil {
+		return dependencies, err
+	}
+
+	if len(collected.Missing) > 0 || len(collected.Failed) > 0 {
+		return dependencies, collected
+	}
+	return dependencies, nil
 }
 
-func parseDependencies(data io.Reader, includeIndirect bool) (*Dependencies, error) {
+func parseDependencies(data io.Reader, opts *Options) (*Dependencies, error) {
 	deps := &Dependencies{}
+	var mods []Module
 	decoder := json.NewDecoder(data)
 	for {
 		var mod Module
 		if err := decoder.Decode(&mod); err != nil {
 			if err == io.EOF {
-				return deps, nil
+				break
 			}
 			return deps, fmt.Errorf("failed to parse dependencies: %w", err)
 		}
+		mods = append(mods, mod)
+	}
 
-		if !mod.Main && mod.Dir != "" {
-			if mod.Indirect {
-				if includeIndirect {
-					deps.Indirect = append(deps.Indirect, LicenceInfo{Module: mod})
-				}
-				continue
-			}
+	policy := opts.indirectPolicy()
+	tools := toolModules(opts.Tools, mods)
+	for _, mod := range mods {
+		_, isTool := tools[mod.Path]
+		switch {
+		case mod.Main:
+			deps.Skipped = append(deps.Skipped, SkippedModule{Module: mod, Reason: SkipMain})
+		case isTool:
+			deps.Tools = append(deps.Tools, LicenceInfo{Module: mod})
+		case mod.Indirect && policy == IndirectNone:
+			deps.Skipped = append(deps.Skipped, SkippedModule{Module: mod, Reason: SkipIndirect})
+		case mod.Indirect && policy == IndirectLinked && !isLinked(mod, opts.LinkedModules):
+			deps.Skipped = append(deps.Skipped, SkippedModule{Module: mod, Reason: SkipPolicy, Detail: "no package linked into the binary"})
+		case mod.Indirect:
+			deps.Indirect = append(deps.Indirect, LicenceInfo{Module: mod})
+		default:
 			deps.Direct = append(deps.Direct, LicenceInfo{Module: mod})
 		}
 	}
 
+	sortDependencies(deps)
+	return deps, nil
+}
+
+func isLinked(mod Module, linked map[string]struct{}) bool {
+	_, ok := linked[mod.Path]
+	return ok
+}
+
+func sortDependencies(deps *Dependencies) {
 	sort.Slice(deps.Direct, func(i, j int) bool {
 		return deps.Direct[i].Path < deps.Direct[j].Path
 	})
@@ -79,68 +230,378 @@ func parseDependencies(data io.Reader, includeIndirect bool) (*Dependencies, err
 		return deps.Indirect[i].Path < deps.Indirect[j].Path
 	})
 
-	return deps, nil
+	sort.Slice(deps.Tools, func(i, j int) bool {
+		return deps.Tools[i].Path < deps.Tools[j].Path
+	})
 }
 
-func detectLicences(deps *Dependencies) error {
-	licenceRegex := buildLicenceRegex()
-	for _, depList := range [][]LicenceInfo{deps.Direct, deps.Indirect} {
-		for i, dep := range depList {
-			srcDir := dep.Dir
-			if dep.Replace != nil {
-				srcDir = dep.Replace.Dir
+// detectLicences detects the licence of every dependency. With the collect strategy, the failures are recorded in
+// collected instead of aborting the detection.
+func detectLicences(deps *Dependencies, opts *Options, collected *DetectionErrors) error {
+	for n, depList := range [][]LicenceInfo{deps.Direct, deps.Indirect, deps.Tools} {
+		tools := n == 2
+		for i := range depList {
+			start := time.Now()
+			if cached, ok := opts.cached(depList[i].Module); ok {
+				depList[i] = cached
+			} else if err := detectModule(&depList[i], opts); err != nil {
+				if opts.Errors != ErrorsCollect {
+					return err
+				}
+				depList[i].Error = err
+				collected.Failed = append(collected.Failed, ModuleError{Module: depList[i].Module, Err: err})
+			}
+
+			// the copyleft policy can only be applied once the licence is known
+			if reason := opts.excludeIndirect(depList[i]); reason != "" && !tools {
+				deps.Skipped = append(deps.Skipped, SkippedModule{Module: depList[i].Module, Reason: SkipPolicy, Detail: reason})
+				continue
+			}
+
+			if opts.OnModuleDetected != nil {
+				opts.OnModuleDetected(depList[i], time.Since(start))
 			}
+		}
+	}
 
-			depList[i].LicenceFile, depList[i].Error = findLicenceFile(srcDir, licenceRegex)
-			if depList[i].Error != nil && depList[i].Error != errLicenceNotFound {
-				return fmt.Errorf("unexpected error while finding licence for %s in %s: %w", dep.Path, srcDir, depList[i].Error)
+	if opts.indirectPolicy() == IndirectCopyleft {
+		var kept []LicenceInfo
+		for _, dep := range deps.Indirect {
+			if opts.excludeIndirect(dep) == "" {
+				kept = append(kept, dep)
 			}
 		}
+		deps.Indirect = kept
+	}
+
+	return nil
+}
+
+// detectModule detects the licence of the module, then applies the external detector and the ScanCode results.
+func detectModule(dep *LicenceInfo, opts *Options) error {
+	if err := detectLicence(dep, opts); err != nil {
+		return err
+	}
+
+	if len(opts.ExecDetector) > 0 {
+		before := strings.Join(dep.Licences, " AND ")
+		if err := runExecDetector(opts.ExecDetector, dep); err != nil {
+			return fmt.Errorf("failed to run external detector for %s: %w", dep.Path, err)
+		}
+		if after := strings.Join(dep.Licences, " AND "); after != before {
+			opts.tracef("the external detector reported %s", after)
+		}
+	}
+
+	if opts.ScanCode != nil {
+		before := dep.LicenceFile + strings.Join(dep.Licences, " AND ")
+		opts.ScanCode.applyTo(dep)
+		if dep.LicenceFile+strings.Join(dep.Licences, " AND ") != before {
+			opts.tracef("ScanCode results override the detection: %s from %s", strings.Join(dep.Licences, " AND "), dep.LicenceFile)
+		}
 	}
 
 	return nil
 }
 
-func buildLicenceRegex() *regexp.Regexp {
-	// inspired by https://github.com/src-d/go-license-detector/blob/7961dd6009019bc12778175ef7f074ede24bd128/licensedb/internal/investigation.go#L29
-	licenceFileNames := []string{
-		`li[cs]en[cs]es?`,
-		`legal`,
-		`copy(left|right|ing)`,
-		`unlicense`,
-		`l?gpl([-_ v]?)(\d\.?\d)?`,
-		`bsd`,
-		`mit`,
-		`apache`,
+func (opts *Options) cached(mod Module) (LicenceInfo, bool) {
+	if opts.Cached == nil {
+		return LicenceInfo{}, false
+	}
+
+	dep, ok := opts.Cached(mod)
+	dep.Module = mod
+	return dep, ok
+}
+
+func (opts *Options) tracef(format string, args ...interface{}) {
+	if opts.trace != nil {
+		opts.trace(format, args...)
+	}
+}
+
+func detectLicence(dep *LicenceInfo, opts *Options) error {
+	srcDir := sourceDir(dep.Module)
+
+	w := newWalker(opts, dep.Module)
+	defer func() {
+		dep.Warnings = w.warnings
+		dep.Evidence = w.evidence
+	}()
+
+	var err error
+	dep.CopyrightFile, err = findCopyrightFile(srcDir, w)
+	if err != nil {
+		return fmt.Errorf("unexpected error while finding copyright notice for %s in %s: %w", dep.Path, srcDir, err)
+	}
+	if dep.CopyrightFile != "" {
+		w.tracef("copyright notice found in %s", w.rel(srcDir, dep.CopyrightFile))
+	}
+
+	reuse, err := detectReuse(srcDir, w)
+	if errors.Is(err, ErrModuleTimeout) {
+		dep.Error = err
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unexpected error while reading REUSE information for %s in %s: %w", dep.Path, srcDir, err)
+	}
+
+	if reuse != nil {
+		w.tracef("REUSE information declares %s in %d licence files", strings.Join(reuse.identifiers, ", "), len(reuse.licenceFiles))
+		dep.LicenceFiles = reuse.licenceFiles
+		dep.Licences = reuse.identifiers
+		dep.LicenceExpression = reuse.expression
+		if len(reuse.licenceFiles) > 0 {
+			dep.LicenceFile = reuse.licenceFiles[0]
+			dep.Source = SourceReuse
+			return nil
+		}
+	}
+
+	source := SourceFile
+	dep.CandidateFiles, dep.Error = findLicenceFiles(srcDir, w)
+	if dep.Error == ErrLicenceNotFound {
+		files, err := findEnclosingLicence(dep, srcDir, opts, w)
+		if err != nil {
+			return err
+		}
+		if len(files) > 0 {
+			dep.CandidateFiles, dep.Error, source = files, nil, SourceParent
+		}
+	}
+	if dep.Error != nil {
+		if dep.Error != ErrLicenceNotFound && !errors.Is(dep.Error, ErrModuleTimeout) {
+			return fmt.Errorf("unexpected error while finding licence for %s in %s: %w", dep.Path, srcDir, dep.Error)
+		}
+		return nil
+	}
+
+	own := dep.CandidateFiles
+	if opts.Subcomponents && source == SourceFile {
+		if own, err = detectSubcomponents(dep, srcDir, dep.CandidateFiles); err != nil {
+			return err
+		}
+		for _, sub := range dep.Subcomponents {
+			w.tracef("%s is the licence of the sub-component %s", w.rel(srcDir, sub.LicenceFile), sub.Dir)
+		}
+		// the licences of the sub-components are not the licence of the module
+		if len(own) == 0 {
+			dep.Error = ErrLicenceNotFound
+			return nil
+		}
+	}
+
+	rankLicenceFiles(srcDir, own, opts.LicencePreference)
+	dep.LicenceFile = own[0]
+	w.tracef("chose %s among %d candidates: %s", w.rel(srcDir, dep.LicenceFile), len(own), choiceReason(srcDir, own, opts.LicencePreference))
+
+	dep.Source = source
+	if len(dep.Licences) == 0 {
+		c, err := classifyLicenceFile(dep.LicenceFile)
+		if err != nil {
+			return fmt.Errorf("failed to classify licence of %s: %w", dep.Path, err)
+		}
+		dep.Licences = c.licences
+		w.traceClassification(srcDir, dep.LicenceFile)
+
+		if c.encoding != EncodingUTF8 {
+			w.notify(Warning{Kind: WarningEncoding, Path: dep.LicenceFile, Message: fmt.Sprintf("%s was transcoded from %s to UTF-8", dep.LicenceFile, c.encoding)})
+		}
+
+		if c.language != "" && c.language != LanguageEnglish {
+			dep.Language = c.language
+			// translations are not legally binding in general, so they are always reviewed
+			if c.translated {
+				w.addWarning(Warning{Kind: WarningTranslation, Path: dep.LicenceFile, Message: fmt.Sprintf("%s is written in %s and was classified as %s from a known translation: review it manually", dep.LicenceFile, c.language, dep.Licences[0])})
+			} else {
+				w.addWarning(Warning{Kind: WarningTranslation, Path: dep.LicenceFile, Message: fmt.Sprintf("%s is written in %s and matches no known translation: review it manually", dep.LicenceFile, c.language)})
+			}
+		} else if len(dep.Licences) == 0 {
+			w.notify(Warning{Kind: WarningLowConfidence, Path: dep.LicenceFile, Message: fmt.Sprintf("%s matches no known licence", dep.LicenceFile)})
+		}
 	}
 
-	regexStr := fmt.Sprintf(`^(?i:(%s)(\.(txt|md|rst))?)$`, strings.Join(licenceFileNames, "|"))
-	return regexp.MustCompile(regexStr)
+	return nil
+}
+
+func sourceDir(mod Module) string {
+	if mod.Replace != nil {
+		return mod.Replace.Dir
+	}
+	return mod.Dir
 }
 
-func findLicenceFile(root string, licenceRegex *regexp.Regexp) (string, error) {
-	errStopWalk := errors.New("stop walk")
-	var licenceFile string
-	err := godirwalk.Walk(root, &godirwalk.Options{
-		Callback: func(osPathName string, dirent *godirwalk.Dirent) error {
-			if licenceRegex.MatchString(dirent.Name()) {
-				if dirent.IsDir() {
-					return filepath.SkipDir
+// findLicenceFiles returns the licence files of the module. Files at the module root come first, followed by the
+// files found deeper in the tree from the shallowest to the deepest. The tree is only walked when the root holds no
+// licence file, unless every candidate is asked for. The first file is the licence of the module.
+// Directories named like licence files, such as LICENSES, are not searched. If the walk times out, the files found
+// so far are returned.
+func findLicenceFiles(root string, w *walker) ([]string, error) {
+	rootFiles, err := findRootLicenceFiles(root, w)
+	if err != nil {
+		return nil, err
+	}
+	// most modules have their licence at the root, which saves walking large trees
+	if len(rootFiles) > 0 && !w.allCandidates {
+		return rootFiles, nil
+	}
+
+	var nestedFiles []string
+	err = w.walk(root, func(path, name string, mode os.FileMode) error {
+		nested := filepath.Dir(path) != filepath.Clean(root)
+		if nested && mode.IsRegular() {
+			w.examineName(path, name)
+		}
+
+		if isLicenceFileName(name) {
+			if mode.IsDir() {
+				return filepath.SkipDir
+			}
+			// files at the root were already checked by findRootLicenceFiles
+			if mode.IsRegular() && nested {
+				w.traceFileName(root, path, name)
+				ok, err := w.isLicenceCandidate(path)
+				if err != nil {
+					return err
+				}
+				if ok {
+					w.examine(path, "")
+					nestedFiles = append(nestedFiles, path)
 				}
-				licenceFile = osPathName
-				return errStopWalk
 			}
-			return nil
-		},
-		Unsorted: true,
+		}
+
+		if w.maxDepth > 0 && mode.IsDir() && path != root && pathDepth(root, path) >= w.maxDepth {
+			return filepath.SkipDir
+		}
+		return nil
 	})
+	if errors.Is(err, ErrModuleTimeout) {
+		// keep the files found so far as a partial result
+		if len(rootFiles) == 0 && len(nestedFiles) == 0 {
+			return nil, err
+		}
+		w.addWarning(Warning{Kind: WarningTimeout, Path: root, Message: fmt.Sprintf("%v: the licence was chosen among the files found so far", err)})
+	} else if err != nil {
+		return nil, err
+	}
+
+	// the walk is unsorted
+	sort.Slice(nestedFiles, func(i, j int) bool {
+		di, dj := pathDepth(root, nestedFiles[i]), pathDepth(root, nestedFiles[j])
+		if di != dj {
+			return di < dj
+		}
+		return nestedFiles[i] < nestedFiles[j]
+	})
+
+	files := append(rootFiles, nestedFiles...)
+	if len(files) == 0 {
+		return nil, ErrLicenceNotFound
+	}
+
+	return files, nil
+}
+
+// findRootLicenceFiles returns the licence files directly under the module root, where most licences live.
+func findRootLicenceFiles(root string, w *walker) ([]string, error) {
+	d, err := os.Open(root)
+	if err != nil {
+		if os.IsPermission(err) {
+			w.warn(root, err)
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer d.Close()
+
+	names, err := d.Readdirnames(-1)
+	if err != nil {
+		if os.IsPermission(err) {
+			w.warn(root, err)
+			return nil, nil
+		}
+		return nil, err
+	}
+	sort.Strings(names)
+
+	var files []string
+	for _, name := range names {
+		path := filepath.Join(root, name)
+		w.traceFileName(root, path, name)
+		if !isLicenceFileName(name) {
+			w.examineName(path, name)
+			continue
+		}
+		if w.ignored(root, path) {
+			w.examine(path, "ignored by the ignore rules")
+			continue
+		}
 
+		fi, err := w.stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case fi == nil:
+			w.examine(path, "symlink skipped")
+			continue
+		case fi.IsDir():
+			w.examine(path, "directory")
+			continue
+		case !fi.Mode().IsRegular():
+			w.examine(path, "not a regular file")
+			continue
+		}
+
+		ok, err := w.isLicenceCandidate(path)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			w.examine(path, "")
+			files = append(files, path)
+		}
+	}
+
+	return files, nil
+}
+
+// rankLicenceFiles sorts the candidate licence files by the first preference pattern they match, keeping the
+// default order otherwise.
+func rankLicenceFiles(root string, files []string, preference []string) {
+	if len(preference) == 0 {
+		return
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return preferenceRank(root, files[i], preference) < preferenceRank(root, files[j], preference)
+	})
+}
+
+// preferenceRank returns the index of the first preference pattern matched by the file, or the number of patterns
+// if it matches none.
+func preferenceRank(root, file string, preference []string) int {
+	rel, err := filepath.Rel(root, file)
 	if err != nil {
-		if errors.Is(err, errStopWalk) {
-			return licenceFile, nil
+		return len(preference)
+	}
+	rel = strings.ToLower(filepath.ToSlash(rel))
+
+	for i, pattern := range preference {
+		if ok, _ := path.Match(strings.ToLower(pattern), rel); ok {
+			return i
 		}
-		return "", err
 	}
+	return len(preference)
+}
 
-	return "", errLicenceNotFound
+func pathDepth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
 }
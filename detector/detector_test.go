@@ -1,7 +1,12 @@
 package detector
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -21,13 +26,15 @@ func TestDetect(t *testing.T) {
 			wantDependencies: &Dependencies{
 				Indirect: mkIndirectDeps(),
 				Direct:   mkDirectDeps(),
+				Skipped:  mkSkippedDeps(true),
 			},
 		},
 		{
 			name:            "DirectOnly",
 			includeIndirect: false,
 			wantDependencies: &Dependencies{
-				Direct: mkDirectDeps(),
+				Direct:  mkDirectDeps(),
+				Skipped: mkSkippedDeps(false),
 			},
 		},
 	}
@@ -60,7 +67,9 @@ func mkIndirectDeps() []LicenceInfo {
 				Indirect: true,
 				Dir:      "testdata/github.com/davecgh/go-spew@v1.1.0",
 			},
-			LicenceFile: "testdata/github.com/davecgh/go-spew@v1.1.0/LICENCE.txt",
+			LicenceFile:    "testdata/github.com/davecgh/go-spew@v1.1.0/LICENCE.txt",
+			CandidateFiles: []string{"testdata/github.com/davecgh/go-spew@v1.1.0/LICENCE.txt"},
+			Source:         SourceFile,
 		},
 		{
 			Module: Module{
@@ -70,7 +79,9 @@ func mkIndirectDeps() []LicenceInfo {
 				Indirect: true,
 				Dir:      "testdata/github.com/dgryski/go-minhash@v0.0.0-20170608043002-7fe510aff544",
 			},
-			LicenceFile: "testdata/github.com/dgryski/go-minhash@v0.0.0-20170608043002-7fe510aff544/licence",
+			LicenceFile:    "testdata/github.com/dgryski/go-minhash@v0.0.0-20170608043002-7fe510aff544/licence",
+			CandidateFiles: []string{"testdata/github.com/dgryski/go-minhash@v0.0.0-20170608043002-7fe510aff544/licence"},
+			Source:         SourceFile,
 		},
 		{
 			Module: Module{
@@ -80,11 +91,31 @@ func mkIndirectDeps() []LicenceInfo {
 				Indirect: true,
 				Dir:      "testdata/github.com/dgryski/go-spooky@v0.0.0-20170606183049-ed3d087f40e2",
 			},
-			LicenceFile: "testdata/github.com/dgryski/go-spooky@v0.0.0-20170606183049-ed3d087f40e2/COPYING",
+			LicenceFile:    "testdata/github.com/dgryski/go-spooky@v0.0.0-20170606183049-ed3d087f40e2/COPYING",
+			CandidateFiles: []string{"testdata/github.com/dgryski/go-spooky@v0.0.0-20170606183049-ed3d087f40e2/COPYING"},
+			Source:         SourceFile,
 		},
 	}
 }
 
+// mkSkippedDeps returns the modules of testdata/deps.json that are left out of the results.
+func mkSkippedDeps(includeIndirect bool) []SkippedModule {
+	skipped := []SkippedModule{
+		{
+			Module: Module{Path: "github.com/charith-elastic/licence-detector", Main: true, Dir: "testdata/github.com/charith-elastic/license-detector"},
+			Reason: SkipMain,
+		},
+	}
+
+	if !includeIndirect {
+		for _, dep := range mkIndirectDeps() {
+			skipped = append(skipped, SkippedModule{Module: dep.Module, Reason: SkipIndirect})
+		}
+	}
+
+	return skipped
+}
+
 func mkDirectDeps() []LicenceInfo {
 	return []LicenceInfo{
 		{
@@ -94,7 +125,7 @@ func mkDirectDeps() []LicenceInfo {
 				Time:    mustParseTime("2017-12-25T07:10:31Z"),
 				Dir:     "testdata/github.com/ekzhu/minhash-lsh@v0.0.0-20171225071031-5c06ee8586a1",
 			},
-			Error: errLicenceNotFound,
+			Error: ErrLicenceNotFound,
 		},
 		{
 			Module: Module{
@@ -108,7 +139,9 @@ func mkDirectDeps() []LicenceInfo {
 				},
 				Dir: "testdata/github.com/russross/blackfriday/v2@v2.0.1",
 			},
-			LicenceFile: "testdata/github.com/russross/blackfriday/v2@v2.0.1/LICENSE.rst",
+			LicenceFile:    "testdata/github.com/russross/blackfriday/v2@v2.0.1/LICENSE.rst",
+			CandidateFiles: []string{"testdata/github.com/russross/blackfriday/v2@v2.0.1/LICENSE.rst"},
+			Source:         SourceFile,
 		},
 	}
 }
@@ -120,3 +153,629 @@ func mustParseTime(value string) *time.Time {
 	}
 	return &t
 }
+
+func TestDetectReuse(t *testing.T) {
+	root := "testdata/github.com/fsfe/reuse-example@v0.1.0"
+
+	got, err := detectReuse(root, &walker{symlinks: SymlinkFollow})
+	require.NoError(t, err)
+	require.Equal(t, &reuseInfo{
+		licenceFiles: []string{
+			root + "/LICENSES/CC0-1.0.txt",
+			root + "/LICENSES/MIT.txt",
+		},
+		identifiers: []string{"Apache-2.0", "CC-BY-4.0", "CC0-1.0", "MIT"},
+		expression:  "CC-BY-4.0 AND CC0-1.0 AND (MIT OR Apache-2.0)",
+	}, got)
+
+	got, err = detectReuse("testdata/github.com/davecgh/go-spew@v1.1.0", &walker{symlinks: SymlinkFollow})
+	require.NoError(t, err)
+	require.Nil(t, got)
+}
+
+func TestSPDXLicencesExpression(t *testing.T) {
+	testCases := []struct {
+		name        string
+		ids         []string
+		expressions []string
+		want        string
+		wantIDs     []string
+	}{
+		{name: "NoExpression", ids: []string{"MIT", "Apache-2.0"}, want: "", wantIDs: []string{"Apache-2.0", "MIT"}},
+		{name: "Single", expressions: []string{"MIT OR Apache-2.0"}, want: "MIT OR Apache-2.0", wantIDs: []string{"Apache-2.0", "MIT"}},
+		{name: "Duplicate", expressions: []string{"MIT OR Apache-2.0", " MIT  OR Apache-2.0 */"}, want: "MIT OR Apache-2.0", wantIDs: []string{"Apache-2.0", "MIT"}},
+		{name: "CoveredIDs", ids: []string{"MIT"}, expressions: []string{"MIT OR Apache-2.0"}, want: "MIT OR Apache-2.0", wantIDs: []string{"Apache-2.0", "MIT"}},
+		{name: "Several", expressions: []string{"MIT OR Apache-2.0", "BSD-3-Clause"}, want: "BSD-3-Clause AND (MIT OR Apache-2.0)", wantIDs: []string{"Apache-2.0", "BSD-3-Clause", "MIT"}},
+		{name: "UncoveredIDs", ids: []string{"CC0-1.0"}, expressions: []string{"GPL-2.0-only WITH Classpath-exception-2.0"}, want: "CC0-1.0 AND (GPL-2.0-only WITH Classpath-exception-2.0)", wantIDs: []string{"CC0-1.0", "Classpath-exception-2.0", "GPL-2.0-only"}},
+		{name: "Empty", ids: []string{"MIT"}, expressions: []string{" -->"}, want: "", wantIDs: []string{"MIT"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := newSPDXLicences()
+			for _, id := range tc.ids {
+				l.addID(id)
+			}
+			for _, expr := range tc.expressions {
+				l.addExpression(expr)
+			}
+			require.Equal(t, tc.want, l.expression())
+			require.Equal(t, tc.wantIDs, l.identifiers())
+		})
+	}
+}
+
+func TestDetectWithScanCode(t *testing.T) {
+	sf, err := os.Open("testdata/scancode.json")
+	require.NoError(t, err)
+	defer sf.Close()
+
+	scanCode, err := ParseScanCode(sf)
+	require.NoError(t, err)
+
+	f, err := os.Open("testdata/deps.json")
+	require.NoError(t, err)
+	defer f.Close()
+
+	gotDependencies, err := DetectWithOptions(f, &Options{ScanCode: scanCode})
+	require.NoError(t, err)
+
+	wantDirect := mkDirectDeps()
+	wantDirect[0].LicenceFile = "testdata/github.com/ekzhu/minhash-lsh@v0.0.0-20171225071031-5c06ee8586a1/README"
+	wantDirect[0].Licences = []string{"MIT"}
+	wantDirect[0].LicenceExpression = "MIT"
+	wantDirect[0].Source = SourceScanCode
+	wantDirect[0].Error = nil
+	wantDirect[1].Licences = []string{"BSD-2-Clause"}
+	wantDirect[1].Source = SourceScanCode
+
+	require.Equal(t, &Dependencies{Direct: wantDirect, Skipped: mkSkippedDeps(false)}, gotDependencies)
+}
+
+func TestClassifyLicenceText(t *testing.T) {
+	testCases := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "MIT",
+			text: `Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal`,
+			want: "MIT",
+		},
+		{
+			name: "Apache-2.0",
+			text: `
+                                 Apache License
+                           Version 2.0, January 2004
+                        http://www.apache.org/licenses/`,
+			want: "Apache-2.0",
+		},
+		{
+			name: "BSD-3-Clause",
+			text: `Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+   * Neither the name of Google Inc. nor the names of its contributors may be used to
+     endorse or promote products derived from this software without specific prior written permission.`,
+			want: "BSD-3-Clause",
+		},
+		{
+			name: "BSD-2-Clause",
+			text: `Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:`,
+			want: "BSD-2-Clause",
+		},
+		{
+			name: "LGPL-2.1",
+			text: `GNU LESSER GENERAL PUBLIC LICENSE
+Version 2.1, February 1999
+[This is the first released version of the Lesser GPL.  It also counts
+ as the successor of the GNU Library Public License, version 2, hence
+ the version number 2.1.] GNU General Public License`,
+			want: "LGPL-2.1",
+		},
+		{
+			name: "AGPL-3.0",
+			text: `                    GNU AFFERO GENERAL PUBLIC LICENSE
+                       Version 3, 19 November 2007`,
+			want: "AGPL-3.0",
+		},
+		{
+			name: "GPL-3.0 notice",
+			text: `This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.`,
+			want: "GPL-3.0",
+		},
+		{
+			name: "LGPL-2.1 notice",
+			text: `This library is free software; you can redistribute it and/or
+modify it under the terms of the GNU Lesser General Public
+License as published by the Free Software Foundation; either
+version 2.1 of the License, or (at your option) any later version.`,
+			want: "LGPL-2.1",
+		},
+		{
+			name: "MPL-2.0 notice",
+			text: `This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at http://mozilla.org/MPL/2.0/.`,
+			want: "MPL-2.0",
+		},
+		{
+			name: "GNU licence mentioned past the header",
+			text: strings.Repeat("Copyright (c) Example Authors. ", 20) + "GNU General Public License, Version 3",
+			want: "",
+		},
+		{
+			name: "Unknown",
+			text: "All rights reserved.",
+			want: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, classifyLicenceText(tc.text))
+		})
+	}
+}
+
+// TestClassifyLicenceFullText checks the classification of complete licence texts, named after their SPDX
+// identifiers, which quote or mention other licences.
+func TestClassifyLicenceFullText(t *testing.T) {
+	files, err := filepath.Glob("testdata/licences/*.txt")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		want := strings.TrimSuffix(filepath.Base(file), ".txt")
+		t.Run(want, func(t *testing.T) {
+			c, err := classifyLicenceFile(file)
+			require.NoError(t, err)
+			require.Equal(t, []string{want}, c.licences)
+		})
+	}
+}
+
+func TestFindLicenceFile(t *testing.T) {
+	root := "testdata/github.com/example/nested@v1.0.0"
+
+	testCases := []struct {
+		name     string
+		maxDepth int
+		want     []string
+		wantErr  error
+	}{
+		{
+			name: "Unlimited",
+			want: []string{root + "/a/b/LICENSE"},
+		},
+		{
+			name:     "WithinMaxDepth",
+			maxDepth: 3,
+			want:     []string{root + "/a/b/LICENSE"},
+		},
+		{
+			name:     "BeyondMaxDepth",
+			maxDepth: 2,
+			wantErr:  ErrLicenceNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := findLicenceFiles(root, &walker{symlinks: SymlinkFollow, maxDepth: tc.maxDepth})
+			require.Equal(t, tc.wantErr, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestFindLicenceFileSymlinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "symlinks")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	licenceTarget := filepath.Join(dir, "upstream", "LICENSE")
+	root := filepath.Join(dir, "module")
+	require.NoError(t, os.MkdirAll(filepath.Dir(licenceTarget), 0755))
+	require.NoError(t, ioutil.WriteFile(licenceTarget, []byte("licence"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sub"), 0755))
+	require.NoError(t, os.Symlink(root, filepath.Join(root, "sub", "loop")))
+	require.NoError(t, os.Symlink(filepath.Dir(licenceTarget), filepath.Join(root, "sub", "vendor")))
+
+	got, err := findLicenceFiles(root, &walker{symlinks: SymlinkFollow})
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(root, "sub", "vendor", "LICENSE")}, got)
+
+	_, err = findLicenceFiles(root, &walker{symlinks: SymlinkSkip})
+	require.Equal(t, ErrLicenceNotFound, err)
+
+	_, err = findLicenceFiles(root, &walker{symlinks: SymlinkError})
+	require.True(t, errors.Is(err, ErrSymlink))
+
+	require.NoError(t, os.Remove(filepath.Join(root, "sub", "vendor")))
+	_, err = findLicenceFiles(root, &walker{symlinks: SymlinkFollow})
+	require.Equal(t, ErrLicenceNotFound, err)
+}
+
+func TestWalkSorted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sorted")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// created out of lexical order so that the directory order differs from it on most file systems
+	for _, name := range []string{"zeta/NOTICE", "b/LICENSE", "alpha/COPYING", "a.txt", "m/n/LICENCE"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte("licence"), 0644))
+	}
+
+	walkOrder := func() []string {
+		var paths []string
+		w := newWalker(&Options{SortedWalk: true}, Module{})
+		require.NoError(t, w.walk(dir, func(path, name string, mode os.FileMode) error {
+			rel, err := filepath.Rel(dir, path)
+			require.NoError(t, err)
+			paths = append(paths, filepath.ToSlash(rel))
+			return nil
+		}))
+		return paths
+	}
+
+	want := []string{".", "a.txt", "alpha", "alpha/COPYING", "b", "b/LICENSE", "m", "m/n", "m/n/LICENCE", "zeta", "zeta/NOTICE"}
+	require.Equal(t, want, walkOrder())
+	require.Equal(t, want, walkOrder())
+}
+
+func TestFindLicenceFileUnreadableDir(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced for root")
+	}
+
+	dir, err := ioutil.TempDir("", "unreadable")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	unreadable := filepath.Join(dir, "unreadable")
+	require.NoError(t, os.MkdirAll(filepath.Join(unreadable, "sub"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "docs"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "docs", "COPYING"), []byte("licence"), 0644))
+	require.NoError(t, os.Chmod(unreadable, 0))
+	defer os.Chmod(unreadable, 0755)
+
+	w := &walker{symlinks: SymlinkFollow}
+	got, err := findLicenceFiles(dir, w)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "docs", "COPYING")}, got)
+	require.Len(t, w.warnings, 1)
+	require.Contains(t, w.warnings[0], unreadable)
+}
+
+func TestFindLicenceFileSkipsUnsuitableCandidates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "candidates")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "LEGAL"), []byte("too large for the limit"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "LICENSE"), []byte{0x7f, 'E', 'L', 'F', 0, 0}, 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "docs"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "docs", "COPYING"), []byte("licence"), 0644))
+
+	w := &walker{symlinks: SymlinkFollow, maxSize: 10}
+	got, err := findLicenceFiles(dir, w)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "docs", "COPYING")}, got)
+	require.Len(t, w.warnings, 2)
+}
+
+func TestFindLicenceFilesOrder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "candidates")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"LICENSE", "COPYING", "z/LICENSE", "a/b/LICENSE", "b/COPYING"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte("licence"), 0644))
+	}
+
+	got, err := findLicenceFiles(dir, &walker{symlinks: SymlinkFollow, allCandidates: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(dir, "COPYING"),
+		filepath.Join(dir, "LICENSE"),
+		filepath.Join(dir, "b", "COPYING"),
+		filepath.Join(dir, "z", "LICENSE"),
+		filepath.Join(dir, "a", "b", "LICENSE"),
+	}, got)
+}
+
+func TestFindLicenceFilesRootFastPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fastpath")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	root := filepath.Join(dir, "module")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sub"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "LICENSE"), []byte("licence"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "sub", "COPYING"), []byte("licence"), 0644))
+	// walking the tree would fail on the symlink
+	require.NoError(t, os.Symlink(root, filepath.Join(root, "sub", "loop")))
+
+	got, err := findLicenceFiles(root, &walker{symlinks: SymlinkError})
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(root, "LICENSE")}, got)
+
+	_, err = findLicenceFiles(root, &walker{symlinks: SymlinkError, allCandidates: true})
+	require.True(t, errors.Is(err, ErrSymlink))
+
+	got, err = findLicenceFiles(root, &walker{symlinks: SymlinkSkip, allCandidates: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(root, "LICENSE"), filepath.Join(root, "sub", "COPYING")}, got)
+
+	// the tree is walked when the root has no licence
+	require.NoError(t, os.Remove(filepath.Join(root, "LICENSE")))
+	_, err = findLicenceFiles(root, &walker{symlinks: SymlinkError})
+	require.True(t, errors.Is(err, ErrSymlink))
+}
+
+func TestRankLicenceFiles(t *testing.T) {
+	files := []string{"/m/COPYING", "/m/LICENSE", "/m/LICENSE.md", "/m/license/MIT"}
+	rankLicenceFiles("/m", files, []string{"license.md", "LICENSE", "license/*"})
+	require.Equal(t, []string{"/m/LICENSE.md", "/m/LICENSE", "/m/license/MIT", "/m/COPYING"}, files)
+}
+
+func TestDetectWithExecDetector(t *testing.T) {
+	f, err := os.Open("testdata/deps.json")
+	require.NoError(t, err)
+	defer f.Close()
+
+	gotDependencies, err := DetectWithOptions(f, &Options{
+		IncludeIndirect: true,
+		ExecDetector:    []string{"testdata/exec/detector.sh"},
+	})
+	require.NoError(t, err)
+
+	wantIndirect := mkIndirectDeps()
+	for _, i := range []int{1, 2} {
+		wantIndirect[i].Licences = []string{"MIT"}
+		wantIndirect[i].Source = SourceExec
+	}
+
+	require.Equal(t, &Dependencies{Direct: mkDirectDeps(), Indirect: wantIndirect, Skipped: mkSkippedDeps(true)}, gotDependencies)
+}
+
+func TestDetectWithSupplement(t *testing.T) {
+	sf, err := os.Open("testdata/supplement.json")
+	require.NoError(t, err)
+	defer sf.Close()
+
+	supplement, err := ParseSupplement(sf, "testdata")
+	require.NoError(t, err)
+
+	f, err := os.Open("testdata/deps.json")
+	require.NoError(t, err)
+	defer f.Close()
+
+	gotDependencies, err := DetectWithOptions(f, &Options{Supplement: supplement})
+	require.NoError(t, err)
+
+	lib := LicenceInfo{
+		Module:         Module{Path: "libexample", Version: "1.2.3", Dir: filepath.Join("testdata", "cgo", "libexample")},
+		LicenceFile:    filepath.Join("testdata", "cgo", "libexample", "COPYING"),
+		CandidateFiles: []string{filepath.Join("testdata", "cgo", "libexample", "COPYING")},
+		Licences:       []string{"Zlib"},
+		Source:         SourceFile,
+	}
+	require.Equal(t, append(mkDirectDeps(), lib), gotDependencies.Direct)
+}
+
+func TestDetectMissingModules(t *testing.T) {
+	deps := `{"Path": "example.com/nodir", "Version": "v1.0.0"}
+{"Path": "example.com/gone", "Version": "v1.0.0", "Dir": "testdata/example.com/gone@v1.0.0"}
+{"Path": "example.com/indirect", "Version": "v1.0.0", "Indirect": true}
+`
+
+	_, err := DetectWithOptions(strings.NewReader(deps), &Options{})
+	var missingErr *MissingModulesError
+	require.True(t, errors.As(err, &missingErr))
+	require.Len(t, missingErr.Modules, 2)
+	require.Equal(t, "example.com/gone", missingErr.Modules[0].Path)
+	require.Contains(t, missingErr.Modules[0].Reason, "does not exist")
+	require.Equal(t, "example.com/nodir", missingErr.Modules[1].Path)
+	require.Contains(t, err.Error(), "go mod download")
+
+	gotDependencies, err := DetectWithOptions(strings.NewReader(deps), &Options{SkipMissing: true})
+	require.NoError(t, err)
+	require.Empty(t, gotDependencies.Direct)
+	require.Len(t, gotDependencies.Skipped, 3)
+	require.Equal(t, SkipIndirect, gotDependencies.Skipped[0].Reason)
+	require.Equal(t, SkipMissing, gotDependencies.Skipped[1].Reason)
+	require.Contains(t, gotDependencies.Skipped[1].Detail, "does not exist")
+}
+
+func TestDetectIndirectPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "indirect")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	licences := map[string]string{
+		"gpl": "GNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007",
+		"mit": "Permission is hereby granted, free of charge, to any person obtaining a copy of this software",
+	}
+	var deps strings.Builder
+	for name, text := range licences {
+		modDir := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(modDir, 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(modDir, "LICENSE"), []byte(text), 0644))
+		fmt.Fprintf(&deps, `{"Path": "example.com/%s", "Version": "v1.0.0", "Indirect": true, "Dir": %q}`+"\n", name, modDir)
+	}
+
+	testCases := []struct {
+		name         string
+		policy       IndirectPolicy
+		linked       map[string]struct{}
+		wantIndirect []string
+		wantSkipped  []string
+	}{
+		{name: "None", policy: IndirectNone, wantSkipped: []string{SkipIndirect, SkipIndirect}},
+		{name: "All", policy: IndirectAll, wantIndirect: []string{"example.com/gpl", "example.com/mit"}},
+		{name: "Copyleft", policy: IndirectCopyleft, wantIndirect: []string{"example.com/gpl"}, wantSkipped: []string{SkipPolicy}},
+		{
+			name:         "Linked",
+			policy:       IndirectLinked,
+			linked:       map[string]struct{}{"example.com/mit": {}},
+			wantIndirect: []string{"example.com/mit"},
+			wantSkipped:  []string{SkipPolicy},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := DetectWithOptions(strings.NewReader(deps.String()), &Options{Indirect: tc.policy, LinkedModules: tc.linked})
+			require.NoError(t, err)
+
+			var gotIndirect, gotSkipped []string
+			for _, dep := range got.Indirect {
+				gotIndirect = append(gotIndirect, dep.Path)
+			}
+			for _, s := range got.Skipped {
+				gotSkipped = append(gotSkipped, s.Reason)
+			}
+			require.Equal(t, tc.wantIndirect, gotIndirect)
+			require.Equal(t, tc.wantSkipped, gotSkipped)
+		})
+	}
+}
+
+func TestParseLinkedModules(t *testing.T) {
+	pkgs := `{"ImportPath": "fmt", "Standard": true}
+{"ImportPath": "example.com/a/pkg", "Module": {"Path": "example.com/a", "Version": "v1.0.0"}}
+{"ImportPath": "example.com/a/other", "Module": {"Path": "example.com/a", "Version": "v1.0.0"}}
+{"ImportPath": "example.com/b", "Module": {"Path": "example.com/b", "Version": "v0.1.0"}}
+`
+	got, err := ParseLinkedModules(strings.NewReader(pkgs))
+	require.NoError(t, err)
+	require.Equal(t, map[string]struct{}{"example.com/a": {}, "example.com/b": {}}, got)
+}
+
+func TestDetectSubcomponents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "subcomponents")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"LICENSE":                          "Permission is hereby granted, free of charge, to any person obtaining a copy of this software",
+		"third_party/zlib/LICENSE":         "Apache License\nVersion 2.0, January 2004",
+		"third_party/zlib/contrib/LICENSE": "GNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007",
+		"internal/vendor/sha3/COPYING":     "Redistribution and use in source and binary forms, with or without modification, are permitted.",
+	}
+	for name, text := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(text), 0644))
+	}
+
+	deps := fmt.Sprintf(`{"Path": "example.com/mod", "Version": "v1.0.0", "Dir": %q}`, dir)
+	got, err := DetectWithOptions(strings.NewReader(deps), &Options{Subcomponents: true})
+	require.NoError(t, err)
+	require.Len(t, got.Direct, 1)
+
+	dep := got.Direct[0]
+	require.Equal(t, filepath.Join(dir, "LICENSE"), dep.LicenceFile)
+	require.Equal(t, []string{"MIT"}, dep.Licences)
+	require.Equal(t, []Subcomponent{
+		{Dir: "third_party/zlib", LicenceFile: filepath.Join(dir, "third_party", "zlib", "LICENSE"), Licences: []string{"Apache-2.0"}},
+		{Dir: "internal/vendor/sha3", LicenceFile: filepath.Join(dir, "internal", "vendor", "sha3", "COPYING"), Licences: []string{"BSD-2-Clause"}},
+	}, dep.Subcomponents)
+}
+
+func TestDetectIgnore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"LICENSE.md":                "# Licensing FAQ",
+		"docs/licence.md":           "# Licensing FAQ",
+		"pkg/sub/LICENSE":           "Permission is hereby granted, free of charge, to any person obtaining a copy of this software",
+		"testdata/fixtures/COPYING": "GNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007",
+	}
+	for name, text := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(text), 0644))
+	}
+
+	rules, err := ParseIgnoreFile(strings.NewReader(`# false positives
+example.com/mod: LICENSE.md
+example.com/mod: docs/*.md
+example.com/mod: testdata
+example.com/other: pkg
+`))
+	require.NoError(t, err)
+	require.Len(t, rules, 4)
+
+	deps := fmt.Sprintf(`{"Path": "example.com/mod", "Version": "v1.0.0", "Dir": %q}`, dir)
+	got, err := DetectWithOptions(strings.NewReader(deps), &Options{Ignore: rules})
+	require.NoError(t, err)
+	require.Len(t, got.Direct, 1)
+	require.Equal(t, []string{filepath.Join(dir, "pkg", "sub", "LICENSE")}, got.Direct[0].CandidateFiles)
+	require.Equal(t, []string{"MIT"}, got.Direct[0].Licences)
+
+	_, err = ParseIgnoreFile(strings.NewReader("example.com/mod docs\n"))
+	require.Error(t, err)
+}
+
+func TestDetectCopyrightFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "copyright")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "COPYRIGHT.txt"), []byte("Copyright 2020 The Authors"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "LICENSE"), []byte("Apache License\nVersion 2.0, January 2004"), 0644))
+
+	deps := fmt.Sprintf(`{"Path": "example.com/mod", "Version": "v1.0.0", "Dir": %q}`, dir)
+	got, err := DetectWithOptions(strings.NewReader(deps), &Options{})
+	require.NoError(t, err)
+	require.Len(t, got.Direct, 1)
+	require.Equal(t, filepath.Join(dir, "COPYRIGHT.txt"), got.Direct[0].CopyrightFile)
+	require.Equal(t, filepath.Join(dir, "LICENSE"), got.Direct[0].LicenceFile)
+	require.Equal(t, []string{filepath.Join(dir, "LICENSE")}, got.Direct[0].CandidateFiles)
+}
+
+func TestDetectModuleTimeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "timeout")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"root/LICENSE":       "Permission is hereby granted, free of charge, to any person obtaining a copy of this software",
+		"nested/pkg/LICENSE": "Permission is hereby granted, free of charge, to any person obtaining a copy of this software",
+	}
+	for name, text := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(text), 0644))
+	}
+
+	deps := fmt.Sprintf(`{"Path": "example.com/nested", "Version": "v1.0.0", "Dir": %q}
+{"Path": "example.com/root", "Version": "v1.0.0", "Dir": %q}`, filepath.Join(dir, "nested"), filepath.Join(dir, "root"))
+	got, err := DetectWithOptions(strings.NewReader(deps), &Options{ModuleTimeout: time.Nanosecond, AllCandidates: true})
+	require.NoError(t, err)
+	require.Len(t, got.Direct, 2)
+
+	// nothing found before the timeout
+	require.True(t, errors.Is(got.Direct[0].Error, ErrModuleTimeout))
+	require.Empty(t, got.Direct[0].LicenceFile)
+
+	// partial result from the files at the root
+	require.NoError(t, got.Direct[1].Error)
+	require.Equal(t, []string{"MIT"}, got.Direct[1].Licences)
+	require.Len(t, got.Direct[1].Warnings, 1)
+	require.Contains(t, got.Direct[1].Warnings[0], "timed out")
+}
@@ -0,0 +1,56 @@
+package detector
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// HashModuleZip returns the hash of a module zip in the h1: format recorded in go.sum files: the base64-encoded
+// SHA-256 of a summary listing the SHA-256 of every file, sorted by name.
+func HashModuleZip(zipPath string) (string, error) {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+
+	files := make([]*zip.File, len(zr.File))
+	copy(files, zr.File)
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+
+	summary := sha256.New()
+	for _, f := range files {
+		if strings.Contains(f.Name, "\n") {
+			return "", fmt.Errorf("file name %q contains a newline", f.Name)
+		}
+
+		h, err := hashZipFile(f)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(summary, "%x  %s\n", h, f.Name)
+	}
+
+	return "h1:" + base64.StdEncoding.EncodeToString(summary.Sum(nil)), nil
+}
+
+func hashZipFile(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
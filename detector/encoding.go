@@ -0,0 +1,131 @@
+package detector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	EncodingUTF8        = "UTF-8"
+	EncodingUTF16LE     = "UTF-16LE"
+	EncodingUTF16BE     = "UTF-16BE"
+	EncodingWindows1252 = "Windows-1252"
+)
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// windows1252 maps the bytes 0x80-0x9f, which differ from Latin-1, to their Unicode code points.
+var windows1252 = [32]rune{
+	'€', '�', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '�', 'Ž', '�',
+	'�', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '�', 'ž', 'Ÿ',
+}
+
+// DetectEncoding guesses the character encoding of a licence text. Texts that are neither UTF-8 nor UTF-16 are
+// assumed to be Windows-1252, a superset of Latin-1.
+func DetectEncoding(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return EncodingUTF8
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return EncodingUTF16BE
+	}
+
+	if order, ok := sniffUTF16(data); ok {
+		if order == binary.LittleEndian {
+			return EncodingUTF16LE
+		}
+		return EncodingUTF16BE
+	}
+
+	if utf8.Valid(data) {
+		return EncodingUTF8
+	}
+
+	return EncodingWindows1252
+}
+
+// DecodeLicenceText converts a licence text to UTF-8. In strict mode, texts that are not already UTF-8 are
+// rejected instead of being transcoded.
+func DecodeLicenceText(data []byte, strict bool) (string, error) {
+	enc := DetectEncoding(data)
+	if strict && enc != EncodingUTF8 {
+		return "", fmt.Errorf("licence text is encoded as %s instead of UTF-8", enc)
+	}
+
+	switch enc {
+	case EncodingUTF16LE:
+		return decodeUTF16(bytes.TrimPrefix(data, bomUTF16LE), binary.LittleEndian), nil
+	case EncodingUTF16BE:
+		return decodeUTF16(bytes.TrimPrefix(data, bomUTF16BE), binary.BigEndian), nil
+	case EncodingWindows1252:
+		return decodeWindows1252(data), nil
+	default:
+		return string(bytes.TrimPrefix(data, bomUTF8)), nil
+	}
+}
+
+// sniffUTF16 recognises UTF-16 texts without a byte order mark from the position of the NUL bytes, which is
+// reliable for the mostly ASCII licence texts.
+func sniffUTF16(data []byte) (binary.ByteOrder, bool) {
+	if len(data) < 2 || len(data)%2 != 0 {
+		return nil, false
+	}
+
+	var evenNULs, oddNULs int
+	for i, b := range data {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenNULs++
+		} else {
+			oddNULs++
+		}
+	}
+
+	half := len(data) / 2
+	switch {
+	case oddNULs > half*3/4 && evenNULs == 0:
+		return binary.LittleEndian, true
+	case evenNULs > half*3/4 && oddNULs == 0:
+		return binary.BigEndian, true
+	default:
+		return nil, false
+	}
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+
+	return string(utf16.Decode(units))
+}
+
+func decodeWindows1252(data []byte) string {
+	var sb strings.Builder
+	sb.Grow(len(data))
+	for _, b := range data {
+		switch {
+		case b < 0x80:
+			sb.WriteByte(b)
+		case b < 0xa0:
+			sb.WriteRune(windows1252[b-0x80])
+		default:
+			sb.WriteRune(rune(b))
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,71 @@
+package detector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeLicenceText(t *testing.T) {
+	testCases := []struct {
+		name         string
+		data         []byte
+		strict       bool
+		wantEncoding string
+		want         string
+		wantErr      bool
+	}{
+		{
+			name:         "UTF8",
+			data:         []byte("Copyright © 2019 Jörg"),
+			wantEncoding: EncodingUTF8,
+			want:         "Copyright © 2019 Jörg",
+		},
+		{
+			name:         "UTF8WithBOM",
+			data:         []byte("\xef\xbb\xbfMIT"),
+			wantEncoding: EncodingUTF8,
+			want:         "MIT",
+		},
+		{
+			name:         "UTF16LEWithBOM",
+			data:         []byte{0xff, 0xfe, 'M', 0, 'I', 0, 'T', 0},
+			wantEncoding: EncodingUTF16LE,
+			want:         "MIT",
+		},
+		{
+			name:         "UTF16BEWithoutBOM",
+			data:         []byte{0, 'M', 0, 'I', 0, 'T', 0, 0xe9},
+			wantEncoding: EncodingUTF16BE,
+			want:         "MITé",
+		},
+		{
+			name:         "Latin1",
+			data:         []byte("Copyright \xa9 2019 J\xf6rg \x93quoted\x94"),
+			wantEncoding: EncodingWindows1252,
+			want:         "Copyright © 2019 Jörg “quoted”",
+		},
+		{
+			name:         "StrictRejectsLatin1",
+			data:         []byte("J\xf6rg"),
+			strict:       true,
+			wantEncoding: EncodingWindows1252,
+			wantErr:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.wantEncoding, DetectEncoding(tc.data))
+
+			got, err := DecodeLicenceText(tc.data, tc.strict)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
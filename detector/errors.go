@@ -0,0 +1,62 @@
+package detector
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrorStrategy selects how the detection handles the modules it fails to process.
+type ErrorStrategy string
+
+const (
+	ErrorsFailFast ErrorStrategy = "fail-fast" // abort on the first failure
+	ErrorsCollect  ErrorStrategy = "collect"   // process every module and report the failures together at the end
+)
+
+func ParseErrorStrategy(value string) (ErrorStrategy, error) {
+	switch s := ErrorStrategy(value); s {
+	case ErrorsFailFast, ErrorsCollect:
+		return s, nil
+	default:
+		return "", fmt.Errorf("invalid error strategy %q: must be one of fail-fast, collect", value)
+	}
+}
+
+// ModuleError is a failure to detect the licence of a module.
+type ModuleError struct {
+	Module
+	Err error
+}
+
+// DetectionErrors is returned by the collect strategy once every module has been processed, if the sources of some
+// modules were not available or the detection failed for some modules. The failed modules have their Error set.
+type DetectionErrors struct {
+	Missing []MissingModule
+	Failed  []ModuleError
+}
+
+func (e *DetectionErrors) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "failed to process %d modules:", len(e.Missing)+len(e.Failed))
+	for _, m := range e.Missing {
+		fmt.Fprintf(&sb, "\n  %s: sources not available: %s", moduleID(m.Module), m.Reason)
+	}
+	for _, m := range e.Failed {
+		fmt.Fprintf(&sb, "\n  %s: %v", moduleID(m.Module), m.Err)
+	}
+	return sb.String()
+}
+
+func (e *DetectionErrors) Unwrap() error {
+	if len(e.Failed) > 0 {
+		return e.Failed[0].Err
+	}
+	return nil
+}
+
+func moduleID(mod Module) string {
+	if mod.Version == "" {
+		return mod.Path
+	}
+	return mod.Path + "@" + mod.Version
+}
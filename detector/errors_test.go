@@ -0,0 +1,31 @@
+package detector
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectErrorStrategies(t *testing.T) {
+	var input bytes.Buffer
+	enc := json.NewEncoder(&input)
+	require.NoError(t, enc.Encode(Module{Path: "github.com/davecgh/go-spew", Version: "v1.1.0", Dir: "testdata/github.com/davecgh/go-spew@v1.1.0"}))
+	require.NoError(t, enc.Encode(Module{Path: "example.com/missing", Version: "v1.0.0", Dir: "testdata/missing"}))
+
+	_, err := DetectWithOptions(bytes.NewReader(input.Bytes()), &Options{Errors: ErrorsFailFast})
+	var missingErr *MissingModulesError
+	require.True(t, errors.As(err, &missingErr))
+
+	deps, err := DetectWithOptions(bytes.NewReader(input.Bytes()), &Options{Errors: ErrorsCollect, ExecDetector: []string{"false"}})
+	var detectionErrs *DetectionErrors
+	require.True(t, errors.As(err, &detectionErrs))
+	require.Len(t, detectionErrs.Missing, 1)
+	require.Equal(t, "example.com/missing", detectionErrs.Missing[0].Path)
+	require.Len(t, detectionErrs.Failed, 1)
+	require.Equal(t, "github.com/davecgh/go-spew", detectionErrs.Failed[0].Path)
+	require.Len(t, deps.Direct, 1)
+	require.Error(t, deps.Direct[0].Error)
+}
@@ -0,0 +1,30 @@
+package detector
+
+import "fmt"
+
+// FileEvidence is a file examined during the detection of a module, recorded with Options.RecordEvidence so that the
+// thoroughness of the detection can be audited without running it again. Reason is empty for the files kept as
+// licence candidates and explains why the other files were rejected.
+type FileEvidence struct {
+	Path   string
+	Reason string
+}
+
+// examine records a file examined during the detection in the evidence.
+func (w *walker) examine(path, reason string) {
+	if w.recordEvidence {
+		w.evidence = append(w.evidence, FileEvidence{Path: path, Reason: reason})
+	}
+}
+
+// examineName records the files whose name looks partly like the name of a licence file but scores below the
+// threshold, the others being unrelated to licences.
+func (w *walker) examineName(path, name string) {
+	if !w.recordEvidence {
+		return
+	}
+
+	if score := scoreLicenceFileName(name); score > 0 && score < licenceFileThreshold {
+		w.examine(path, fmt.Sprintf("name score %d is below the threshold of %d", score, licenceFileThreshold))
+	}
+}
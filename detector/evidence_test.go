@@ -0,0 +1,39 @@
+package detector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectEvidence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "evidence")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	mit := []byte(`Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal`)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "LICENSE"), mit, 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "COPYING"), []byte("binary\x00licence"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "third_party", "lib"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "third_party", "lib", "LICENSE.txt"), mit, 0644))
+
+	input := `{"Path": "example.com/evidence", "Version": "v1.0.0", "Dir": "` + filepath.ToSlash(dir) + `"}`
+
+	deps, err := DetectWithOptions(strings.NewReader(input), &Options{RecordEvidence: true, AllCandidates: true})
+	require.NoError(t, err)
+	require.Equal(t, []FileEvidence{
+		{Path: filepath.Join(dir, "COPYING"), Reason: "contains binary data"},
+		{Path: filepath.Join(dir, "LICENSE")},
+		{Path: filepath.Join(dir, "third_party", "lib", "LICENSE.txt")},
+	}, deps.Direct[0].Evidence)
+
+	deps, err = DetectWithOptions(strings.NewReader(input), &Options{})
+	require.NoError(t, err)
+	require.Empty(t, deps.Direct[0].Evidence)
+}
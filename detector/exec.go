@@ -0,0 +1,76 @@
+package detector
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// execRequest is written to the stdin of an external detector.
+type execRequest struct {
+	Module
+	LicenceFile string   `json:",omitempty"`
+	Licences    []string `json:",omitempty"`
+}
+
+// execResponse is read from the stdout of an external detector. Empty fields leave the detection results unchanged.
+type execResponse struct {
+	LicenceFile string   `json:"licenceFile"`
+	Licences    []string `json:"licences"`
+	Error       string   `json:"error"`
+}
+
+// runExecDetector invokes an external detector for the module. The detector receives the module and the built-in
+// detection results as JSON on stdin and may answer with a JSON object on stdout to override them.
+func runExecDetector(command []string, dep *LicenceInfo) error {
+	req, err := json.Marshal(execRequest{Module: dep.Module, LicenceFile: dep.LicenceFile, Licences: dep.Licences})
+	if err != nil {
+		return err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(req)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("external detector %s failed: %w: %s", command[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return nil
+	}
+
+	var resp execResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return fmt.Errorf("failed to parse output of external detector %s: %w", command[0], err)
+	}
+
+	if resp.Error != "" {
+		dep.Error = errors.New(resp.Error)
+		return nil
+	}
+
+	if resp.LicenceFile != "" {
+		licenceFile := resp.LicenceFile
+		if !filepath.IsAbs(licenceFile) {
+			licenceFile = filepath.Join(sourceDir(dep.Module), licenceFile)
+		}
+		dep.LicenceFile = licenceFile
+		dep.LicenceFiles = nil
+		dep.Source = SourceExec
+		dep.Error = nil
+	}
+
+	if len(resp.Licences) > 0 {
+		dep.Licences = resp.Licences
+		dep.LicenceExpression = ""
+		dep.Source = SourceExec
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package detector
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Explanation is the trace of the detection of the licence of a single module.
+type Explanation struct {
+	Dependency LicenceInfo
+	Trace      []string
+}
+
+// Explain detects the licence of the module of the dependency list with the given path, or replaced by the module
+// with the given path, recording how the candidate licence files were found, ranked and classified. The module is
+// looked up among every dependency, regardless of the indirect dependency policy.
+func Explain(data io.Reader, modPath string, opts *Options) (*Explanation, error) {
+	explainOpts := *opts
+	explainOpts.Indirect = IndirectAll
+	if explainOpts.Symlinks == "" {
+		explainOpts.Symlinks = SymlinkFollow
+	}
+
+	deps, err := parseDependencies(data, &explainOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var dep *LicenceInfo
+	for _, depList := range [][]LicenceInfo{deps.Direct, deps.Indirect} {
+		for i := range depList {
+			if mod := depList[i]; mod.Path == modPath || (mod.Replace != nil && mod.Replace.Path == modPath) {
+				dep = &depList[i]
+			}
+		}
+	}
+	if dep == nil {
+		return nil, fmt.Errorf("module %s is not a dependency", modPath)
+	}
+	if reason := checkModuleDir(sourceDir(dep.Module)); reason != "" {
+		return nil, fmt.Errorf("sources of %s are not available: %s", modPath, reason)
+	}
+
+	e := &Explanation{}
+	explainOpts.trace = func(format string, args ...interface{}) {
+		e.Trace = append(e.Trace, fmt.Sprintf(format, args...))
+	}
+
+	if err := detectModule(dep, &explainOpts); err != nil {
+		return nil, err
+	}
+	e.Dependency = *dep
+
+	return e, nil
+}
+
+func (w *walker) tracef(format string, args ...interface{}) {
+	if w.trace != nil {
+		w.trace(format, args...)
+	}
+}
+
+// rel returns the slash-separated path relative to root used in traces.
+func (w *walker) rel(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// traceFileName records the score of the names that look at least partly like the name of a licence file.
+func (w *walker) traceFileName(root, path, name string) {
+	if w.trace == nil {
+		return
+	}
+
+	switch score := scoreLicenceFileName(name); {
+	case score >= licenceFileThreshold:
+		w.tracef("%s: name score %d", w.rel(root, path), score)
+	case score > 0:
+		w.tracef("%s: name score %d is below the threshold of %d", w.rel(root, path), score, licenceFileThreshold)
+	}
+}
+
+// traceClassification records the phrases of the licence text that identified the licence or, if none was
+// recognised, how close the text came to the most similar licence.
+func (w *walker) traceClassification(root, path string) {
+	if w.trace == nil {
+		return
+	}
+
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	decoded, err := DecodeLicenceText(text, false)
+	if err != nil {
+		return
+	}
+	if enc := DetectEncoding(text); enc != EncodingUTF8 {
+		w.tracef("%s was transcoded from %s to UTF-8", w.rel(root, path), enc)
+	}
+	if lang := DetectLanguage(decoded); lang != "" && lang != LanguageEnglish {
+		if id := classifyTranslatedText(decoded, lang); id != "" {
+			w.tracef("%s is written in %s and classified as %s from a known translation", w.rel(root, path), lang, id)
+			return
+		}
+		w.tracef("%s is written in %s, for which no translation matches", w.rel(root, path), lang)
+	}
+	normalised := normaliseLicenceText(decoded)
+
+	var closest *licenceSignature
+	var closestMissing []string
+	for i, sig := range licenceSignatures {
+		if sig.matches(normalised) {
+			w.tracef("%s classified as %s: contains %s", w.rel(root, path), sig.id, quoteAll(sig.phrases()))
+			return
+		}
+
+		missing := sig.missing(normalised)
+		if len(missing) < len(sig.phrases()) && (closest == nil || len(missing) < len(closestMissing)) {
+			closest, closestMissing = &licenceSignatures[i], missing
+		}
+	}
+
+	switch {
+	case closest == nil:
+		w.tracef("%s matches no known licence", w.rel(root, path))
+	case len(closestMissing) == 0:
+		w.tracef("%s matches no known licence: closest is %s, rejected as it contains one of %s", w.rel(root, path), closest.id, quoteAll(closest.excluded))
+	default:
+		w.tracef("%s matches no known licence: closest is %s, missing %s", w.rel(root, path), closest.id, quoteAll(closestMissing))
+	}
+}
+
+// choiceReason explains why the first candidate was chosen as the licence of the module.
+func choiceReason(root string, candidates []string, preference []string) string {
+	if rank := preferenceRank(root, candidates[0], preference); rank < len(preference) {
+		return fmt.Sprintf("it matches the preference pattern %q", preference[rank])
+	}
+	if len(candidates) == 1 {
+		return "it is the only candidate"
+	}
+	if filepath.Dir(candidates[0]) == filepath.Clean(root) {
+		return "it is the first licence file at the module root in lexical order"
+	}
+	return "it is the shallowest licence file"
+}
+
+func quoteAll(phrases []string) string {
+	quoted := make([]string, len(phrases))
+	for i, p := range phrases {
+		quoted[i] = fmt.Sprintf("%q", p)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,46 @@
+package detector
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "explain")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"LICENSE":                "Permission is hereby granted, free of charge, to any person obtaining a copy of this software.\n",
+		"license-checker-config": "{}\n",
+		"docs/COPYING":           "Redistribution and use in source and binary forms, with or without modification, are permitted.\nNeither the name of the copyright holder may be used to endorse or promote products derived from this software.\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+
+	input, err := json.Marshal(Module{Path: "example.com/explain", Version: "v1.0.0", Indirect: true, Dir: dir})
+	require.NoError(t, err)
+
+	e, err := Explain(bytes.NewReader(input), "example.com/explain", &Options{SortedWalk: true, LicencePreference: []string{"docs/*"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"BSD-3-Clause"}, e.Dependency.Licences)
+	require.Equal(t, []string{
+		"LICENSE: name score 4",
+		"license-checker-config: name score 2 is below the threshold of 3",
+		"docs/COPYING: name score 4",
+		`chose docs/COPYING among 2 candidates: it matches the preference pattern "docs/*"`,
+		`docs/COPYING classified as BSD-3-Clause: contains "redistribution and use in source and binary forms", "endorse or promote products derived from this software"`,
+	}, e.Trace)
+
+	_, err = Explain(bytes.NewReader(input), "example.com/missing", &Options{})
+	require.Error(t, err)
+}
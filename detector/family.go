@@ -0,0 +1,43 @@
+package detector
+
+import "strings"
+
+// licenceFamilies maps the prefixes of SPDX identifiers to the family of licences they belong to. Licences of a
+// family differ in details that matter little for summaries, such as the number of clauses of the BSD licences.
+var licenceFamilies = []struct {
+	prefix string
+	family string
+}{
+	{"0BSD", "BSD"},
+	{"AFL-", "Academic Free"},
+	{"AGPL-", "GPL"},
+	{"Apache-", "Apache"},
+	{"Artistic-", "Artistic"},
+	{"BSD-", "BSD"},
+	{"BSL-", "Boost"},
+	{"CC-BY-", "Creative Commons"},
+	{"CC0-", "Public Domain"},
+	{"CDDL-", "CDDL"},
+	{"EPL-", "EPL"},
+	{"EUPL-", "EUPL"},
+	{"GPL-", "GPL"},
+	{"ISC", "MIT"},
+	{"LGPL-", "GPL"},
+	{"MIT", "MIT"},
+	{"MPL-", "MPL"},
+	{"Unlicense", "Public Domain"},
+	{"X11", "MIT"},
+	{"Zlib", "Zlib"},
+}
+
+// LicenceFamily returns the family of the licence denoted by the SPDX identifier, such as BSD for BSD-3-Clause. The
+// base identifier is returned for licences outside the known families.
+func LicenceFamily(id string) string {
+	id = baseLicenceID(id)
+	for _, f := range licenceFamilies {
+		if strings.HasPrefix(id, f.prefix) {
+			return f.family
+		}
+	}
+	return id
+}
@@ -0,0 +1,30 @@
+package detector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLicenceFamily(t *testing.T) {
+	testCases := []struct {
+		id   string
+		want string
+	}{
+		{id: "BSD-2-Clause", want: "BSD"},
+		{id: "BSD-3-Clause", want: "BSD"},
+		{id: "0BSD", want: "BSD"},
+		{id: "GPL-3.0-or-later", want: "GPL"},
+		{id: "LGPL-2.1-only", want: "GPL"},
+		{id: "Apache-2.0", want: "Apache"},
+		{id: "MIT-0", want: "MIT"},
+		{id: "CC0-1.0", want: "Public Domain"},
+		{id: "WTFPL", want: "WTFPL"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.id, func(t *testing.T) {
+			require.Equal(t, tc.want, LicenceFamily(tc.id))
+		})
+	}
+}
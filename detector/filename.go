@@ -0,0 +1,112 @@
+package detector
+
+import (
+	"regexp"
+	"strings"
+)
+
+// licenceFileThreshold is the minimum score of the names of licence files.
+const licenceFileThreshold = 3
+
+// licenceKeywords are the words naming licence files, as in LICENSE, COPYING or MIT-LICENSE.
+var licenceKeywords = map[string]struct{}{
+	"copying":   {},
+	"copyleft":  {},
+	"legal":     {},
+	"licence":   {},
+	"licences":  {},
+	"license":   {},
+	"licenses":  {},
+	"unlicense": {},
+}
+
+// licenceNameRegex matches the names of licences used as file names, such as MIT, APACHE2 or lgplv3.
+var licenceNameRegex = regexp.MustCompile(`^(agpl|apache|bsd|isc|l?gpl|mit|mpl)(v?\d+)?$`)
+
+var versionRegex = regexp.MustCompile(`^v?\d+$`)
+
+// textExtensions are the extensions of the formats licences are written in.
+var textExtensions = map[string]struct{}{
+	"markdown": {},
+	"md":       {},
+	"rst":      {},
+	"txt":      {},
+}
+
+// nonLicenceExtensions are the extensions of source and data files, which are never licence texts even if named
+// after one, such as license_test.go or licenses.json.
+var nonLicenceExtensions = map[string]struct{}{
+	"c": {}, "cc": {}, "cpp": {}, "cs": {}, "css": {}, "csv": {}, "go": {}, "h": {}, "html": {}, "java": {}, "js": {},
+	"json": {}, "mod": {}, "php": {}, "proto": {}, "py": {}, "rb": {}, "rs": {}, "s": {}, "sh": {}, "sum": {},
+	"tmpl": {}, "toml": {}, "ts": {}, "xml": {}, "yaml": {}, "yml": {},
+}
+
+// nonLicenceWords are the words marking files about licences rather than licence texts, such as test fixtures.
+var nonLicenceWords = map[string]struct{}{
+	"example":  {},
+	"faq":      {},
+	"fixture":  {},
+	"fixtures": {},
+	"mock":     {},
+	"test":     {},
+	"tests":    {},
+}
+
+// isLicenceFileName reports whether the file name looks like the name of a licence file.
+func isLicenceFileName(name string) bool {
+	return scoreLicenceFileName(name) >= licenceFileThreshold
+}
+
+// scoreLicenceFileName scores how much the file name looks like the name of a licence file. The name is split into
+// words on dots, dashes, underscores and spaces, after removing a text extension:
+//
+//   - a licence keyword scores 4 as the first word (LICENSE-APACHE) and 3 otherwise (MIT-LICENSE)
+//   - licence names and version numbers score 3 if they are the only words (MIT, gpl-3.0), a licence name scores 1
+//     otherwise
+//   - any other word costs 1 (licence.code scores 3, license-checker-config 2)
+//   - source and data file extensions and words such as test or fixture reject the name with a score of 0
+func scoreLicenceFileName(name string) int {
+	name = strings.ToLower(name)
+
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		ext := name[idx+1:]
+		if _, ok := nonLicenceExtensions[ext]; ok {
+			return 0
+		}
+		if _, ok := textExtensions[ext]; ok {
+			name = name[:idx]
+		}
+	}
+
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '.' || r == '-' || r == '_' || r == ' '
+	})
+	if len(words) == 0 {
+		return 0
+	}
+
+	score, names, others := 0, 0, 0
+	for i, word := range words {
+		if _, ok := nonLicenceWords[word]; ok {
+			return 0
+		}
+
+		switch _, keyword := licenceKeywords[word]; {
+		case keyword && i == 0:
+			score += 4
+		case keyword:
+			score += 3
+		case licenceNameRegex.MatchString(word):
+			names++
+		case versionRegex.MatchString(word):
+			// version numbers are neutral
+		default:
+			others++
+		}
+	}
+
+	if score == 0 && names > 0 && others == 0 {
+		return 3
+	}
+	return score + names - others
+}
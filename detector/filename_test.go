@@ -0,0 +1,44 @@
+package detector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreLicenceFileName(t *testing.T) {
+	testCases := []struct {
+		name string
+		want int
+	}{
+		{name: "LICENSE", want: 4},
+		{name: "LICENCE.txt", want: 4},
+		{name: "COPYING", want: 4},
+		{name: "UNLICENSE", want: 4},
+		{name: "legal.md", want: 4},
+		{name: "LICENSE-APACHE2.0", want: 5},
+		{name: "LICENSE.MIT", want: 5},
+		{name: "MIT-LICENSE.txt", want: 4},
+		{name: "licence.code.md", want: 3},
+		{name: "COPYING.LESSER", want: 3},
+		{name: "MIT", want: 3},
+		{name: "apache", want: 3},
+		{name: "gpl-3.0.txt", want: 3},
+		{name: "lgplv3", want: 3},
+		{name: "license-checker-config", want: 2},
+		{name: "THIRD-PARTY-LICENSES", want: 1},
+		{name: "license_test.go", want: 0},
+		{name: "licenses.json", want: 0},
+		{name: "licence.go", want: 0},
+		{name: "licensing-faq.md", want: 0},
+		{name: "license-fixtures", want: 0},
+		{name: "README.md", want: -1},
+		{name: "main.go", want: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, scoreLicenceFileName(tc.name))
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package detector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GoMod holds the parts of a go.mod file needed to list the dependencies of a module without running go list.
+type GoMod struct {
+	Module   string
+	Requires []Module // Indirect is set for requirements marked with an // indirect comment
+	Replaces []GoModReplace
+	Tools    []string // package paths of the tool directives
+}
+
+// GoModReplace is a replace directive. Old.Version is empty if all versions are replaced and New.Version is empty
+// if the replacement is a local directory.
+type GoModReplace struct {
+	Old Module
+	New Module
+}
+
+// ParseGoMod parses a go.mod file.
+func ParseGoMod(r io.Reader) (*GoMod, error) {
+	gomod := &GoMod{}
+	scanner := bufio.NewScanner(r)
+	block := ""
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		fields, comment, err := splitGoModLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("go.mod:%d: %w", lineNum, err)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		verb := block
+		switch {
+		case block != "" && fields[0] == ")":
+			block = ""
+			continue
+		case block == "" && len(fields) == 2 && fields[1] == "(":
+			block = fields[0]
+			continue
+		case block == "":
+			verb, fields = fields[0], fields[1:]
+		}
+
+		if err := gomod.addDirective(verb, fields, comment); err != nil {
+			return nil, fmt.Errorf("go.mod:%d: %w", lineNum, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return gomod, nil
+}
+
+func (m *GoMod) addDirective(verb string, args []string, comment string) error {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, `"`) || strings.HasPrefix(arg, "`") {
+			unquoted, err := strconv.Unquote(arg)
+			if err != nil {
+				return fmt.Errorf("invalid quoted string %s: %w", arg, err)
+			}
+			args[i] = unquoted
+		}
+	}
+
+	switch verb {
+	case "module":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: module path")
+		}
+		m.Module = args[0]
+	case "require":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: require module/path v1.2.3")
+		}
+		m.Requires = append(m.Requires, Module{Path: args[0], Version: args[1], Indirect: comment == "indirect"})
+	case "replace":
+		arrow := -1
+		for i, arg := range args {
+			if arg == "=>" {
+				arrow = i
+			}
+		}
+		if arrow < 1 || arrow > 2 || len(args)-arrow-1 < 1 || len(args)-arrow-1 > 2 {
+			return fmt.Errorf("usage: replace module/path [v1.2.3] => other/module v1.4.5 | local/directory")
+		}
+
+		var rep GoModReplace
+		rep.Old.Path = args[0]
+		if arrow == 2 {
+			rep.Old.Version = args[1]
+		}
+		rep.New.Path = args[arrow+1]
+		if len(args) == arrow+3 {
+			rep.New.Version = args[arrow+2]
+		}
+		m.Replaces = append(m.Replaces, rep)
+	case "tool":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: tool package/path")
+		}
+		m.Tools = append(m.Tools, args[0])
+	}
+
+	// go, toolchain, exclude and retract directives do not affect the licences
+	return nil
+}
+
+// splitGoModLine splits a line of a go.mod file into its tokens and returns the comment ending it. Quoted strings
+// are kept whole, still quoted, so that they may hold spaces or "//" without starting a comment.
+func splitGoModLine(line string) ([]string, string, error) {
+	var tokens []string
+	for i := 0; i < len(line); {
+		switch c := line[i]; {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(line[i:], "//"):
+			return tokens, strings.TrimSpace(line[i+2:]), nil
+		case c == '(' || c == ')':
+			tokens = append(tokens, line[i:i+1])
+			i++
+		case c == '"' || c == '`':
+			end := i + 1
+			for end < len(line) && line[end] != c {
+				if c == '"' && line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil, "", fmt.Errorf("unterminated quoted string %s", line[i:])
+			}
+			tokens = append(tokens, line[i:end+1])
+			i = end + 1
+		default:
+			end := i
+			for end < len(line) && !strings.ContainsRune(" \t\r()\"`", rune(line[end])) && !strings.HasPrefix(line[end:], "//") {
+				end++
+			}
+			tokens = append(tokens, line[i:end])
+			i = end
+		}
+	}
+	return tokens, "", nil
+}
+
+// GoSum holds the hashes of module sources recorded in a go.sum file, indexed by module path and version.
+type GoSum map[string]map[string]string
+
+// Hash returns the hash of the sources of the module version, or an empty string if go.sum does not record it.
+func (s GoSum) Hash(modPath, version string) string {
+	return s[modPath][version]
+}
+
+// ParseGoSum returns the module versions whose sources are recorded in a go.sum file.
+func ParseGoSum(r io.Reader) (GoSum, error) {
+	sums := make(GoSum)
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("go.sum:%d: malformed line", lineNum)
+		}
+
+		// entries for the go.mod file alone do not mean that the module sources are needed
+		if strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		if sums[fields[0]] == nil {
+			sums[fields[0]] = make(map[string]string)
+		}
+		sums[fields[0]][fields[1]] = fields[2]
+	}
+
+	return sums, scanner.Err()
+}
+
+// Modules lists the dependencies required by go.mod with the replace directives applied. Modules that only appear
+// in go.sum, which is the case for the transitive dependencies of modules older than Go 1.17, are added as
+// indirect dependencies at the highest version recorded. This may not be the version selected by the go command.
+func (m *GoMod) Modules(sums GoSum) []Module {
+	var mods []Module
+	seen := make(map[string]struct{})
+	for _, req := range m.Requires {
+		seen[req.Path] = struct{}{}
+		mods = append(mods, m.replace(req))
+	}
+
+	for modPath, versions := range sums {
+		if _, ok := seen[modPath]; ok || modPath == m.Module {
+			continue
+		}
+
+		var highest string
+		for v := range versions {
+			if highest == "" || compareVersions(v, highest) > 0 {
+				highest = v
+			}
+		}
+		mods = append(mods, m.replace(Module{Path: modPath, Version: highest, Indirect: true}))
+	}
+
+	sort.Slice(mods, func(i, j int) bool {
+		return mods[i].Path < mods[j].Path
+	})
+
+	return mods
+}
+
+func (m *GoMod) replace(mod Module) Module {
+	// a replacement of a specific version takes precedence over one of all versions
+	var match *GoModReplace
+	for i, rep := range m.Replaces {
+		if rep.Old.Path != mod.Path || (rep.Old.Version != "" && rep.Old.Version != mod.Version) {
+			continue
+		}
+		if match == nil || rep.Old.Version != "" {
+			match = &m.Replaces[i]
+		}
+	}
+
+	if match != nil {
+		replacement := match.New
+		mod.Replace = &replacement
+	}
+
+	return mod
+}
+
+// compareVersions compares two semantic versions, returning -1, 0 or 1.
+func compareVersions(a, b string) int {
+	aRel, aPre := splitVersion(a)
+	bRel, bPre := splitVersion(b)
+
+	for i := 0; i < 3; i++ {
+		if c := compareNumeric(aRel[i], bRel[i]); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+
+	aIDs, bIDs := strings.Split(aPre, "."), strings.Split(bPre, ".")
+	for i := 0; i < len(aIDs) && i < len(bIDs); i++ {
+		if c := comparePrerelease(aIDs[i], bIDs[i]); c != 0 {
+			return c
+		}
+	}
+
+	return compareInts(len(aIDs), len(bIDs))
+}
+
+func splitVersion(v string) ([3]string, string) {
+	v = strings.TrimPrefix(v, "v")
+	if idx := strings.Index(v, "+"); idx >= 0 {
+		v = v[:idx]
+	}
+
+	var pre string
+	if idx := strings.Index(v, "-"); idx >= 0 {
+		v, pre = v[:idx], v[idx+1:]
+	}
+
+	var rel [3]string
+	copy(rel[:], strings.SplitN(v, ".", 3))
+	return rel, pre
+}
+
+func comparePrerelease(a, b string) int {
+	_, aErr := strconv.Atoi(a)
+	_, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return compareNumeric(a, b)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareNumeric(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if c := compareInts(len(a), len(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,195 @@
+package detector
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoModModules(t *testing.T) {
+	modFile, err := os.Open("testdata/gomod/go.mod")
+	require.NoError(t, err)
+	defer modFile.Close()
+
+	gomod, err := ParseGoMod(modFile)
+	require.NoError(t, err)
+	require.Equal(t, "example.com/app", gomod.Module)
+
+	sumFile, err := os.Open("testdata/gomod/go.sum")
+	require.NoError(t, err)
+	defer sumFile.Close()
+
+	sums, err := ParseGoSum(sumFile)
+	require.NoError(t, err)
+
+	want := []Module{
+		{Path: "github.com/davecgh/go-spew", Version: "v1.1.0", Replace: &Module{Path: "github.com/example/go-spew", Version: "v1.1.0"}},
+		{Path: "github.com/dgryski/go-minhash", Version: "v0.0.0-20170608043002-7fe510aff544", Indirect: true},
+		{Path: "github.com/dgryski/go-spooky", Version: "v0.0.0-20170606183049-ed3d087f40e2", Indirect: true},
+		{Path: "github.com/ekzhu/minhash-lsh", Version: "v0.0.0-20171225071031-5c06ee8586a1"},
+		{Path: "github.com/russross/blackfriday", Version: "v1.5.2", Replace: &Module{Path: "../github.com/russross/blackfriday"}},
+	}
+	require.Equal(t, want, gomod.Modules(sums))
+}
+
+func TestParseGoModQuoted(t *testing.T) {
+	gomod, err := ParseGoMod(strings.NewReader(`module "example.com/quoted" // the main module
+
+require (
+	example.com/a v1.0.0 // indirect
+	"example.com/b" v1.1.0//indirect
+)
+
+replace example.com/a => "./forks/a // not a comment"
+replace example.com/b v1.1.0 => ` + "`../my forks/b`" + ` // local
+`))
+	require.NoError(t, err)
+	require.Equal(t, "example.com/quoted", gomod.Module)
+	require.Equal(t, []Module{
+		{Path: "example.com/a", Version: "v1.0.0", Indirect: true},
+		{Path: "example.com/b", Version: "v1.1.0", Indirect: true},
+	}, gomod.Requires)
+	require.Equal(t, []GoModReplace{
+		{Old: Module{Path: "example.com/a"}, New: Module{Path: "./forks/a // not a comment"}},
+		{Old: Module{Path: "example.com/b", Version: "v1.1.0"}, New: Module{Path: "../my forks/b"}},
+	}, gomod.Replaces)
+
+	_, err = ParseGoMod(strings.NewReader("module example.com/m\n\nreplace example.com/a => \"./a // unterminated\n"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "go.mod:3: unterminated quoted string")
+}
+
+func TestCompareVersions(t *testing.T) {
+	testCases := []struct {
+		a, b string
+		want int
+	}{
+		{a: "v1.2.3", b: "v1.2.3", want: 0},
+		{a: "v1.10.0", b: "v1.9.0", want: 1},
+		{a: "v1.0.0-rc.1", b: "v1.0.0", want: -1},
+		{a: "v1.0.0-rc.2", b: "v1.0.0-rc.10", want: -1},
+		{a: "v2.0.0+incompatible", b: "v1.9.9", want: 1},
+		{a: "v0.0.0-20170101000000-0123456789ab", b: "v0.0.0-20170606183049-ed3d087f40e2", want: -1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.a+"_"+tc.b, func(t *testing.T) {
+			require.Equal(t, tc.want, compareVersions(tc.a, tc.b))
+		})
+	}
+}
+
+func TestHashModuleZip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "modzip")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	zipPath := filepath.Join(dir, "v1.0.0.zip")
+	f, err := os.Create(zipPath)
+	require.NoError(t, err)
+
+	// files are hashed in name order, regardless of their order in the archive
+	zw := zip.NewWriter(f)
+	for _, file := range []struct{ name, content string }{
+		{name: "example.com/foo@v1.0.0/foo.go", content: "package foo\n"},
+		{name: "example.com/foo@v1.0.0/LICENSE", content: "MIT\n"},
+	} {
+		w, err := zw.Create(file.name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(file.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	require.NoError(t, f.Close())
+
+	hash, err := HashModuleZip(zipPath)
+	require.NoError(t, err)
+	require.Equal(t, "h1:UyWkOVu9UyA2TomuuW6zIN0tKwS8TWYDkJaumcazrVI=", hash)
+}
+
+func TestModulePathBase(t *testing.T) {
+	testCases := map[string]string{
+		"github.com/foo/bar":            "github.com/foo/bar",
+		"github.com/foo/bar/v3":         "github.com/foo/bar",
+		"github.com/foo/bar/v1":         "github.com/foo/bar/v1",
+		"github.com/foo/bar/v02":        "github.com/foo/bar/v02",
+		"github.com/foo/bar/vendor":     "github.com/foo/bar/vendor",
+		"github.com/foo/bar/v2/sub":     "github.com/foo/bar/v2/sub",
+		"gopkg.in/yaml.v2":              "gopkg.in/yaml",
+		"gopkg.in/src-d/go-git.v4":      "gopkg.in/src-d/go-git",
+		"gopkg.in/check.v1":             "gopkg.in/check",
+		"k8s.io/klog/v2":                "k8s.io/klog",
+		"gopkg.in/natefinch/lumberjack": "gopkg.in/natefinch/lumberjack",
+	}
+
+	for modPath, want := range testCases {
+		require.Equal(t, want, ModulePathBase(modPath), modPath)
+	}
+}
+
+func TestModuleIsFork(t *testing.T) {
+	testCases := []struct {
+		name    string
+		replace *Module
+		want    bool
+	}{
+		{name: "NotReplaced"},
+		{name: "Fork", replace: &Module{Path: "github.com/mycorp/forked-lib", Version: "v1.0.1"}, want: true},
+		{name: "OtherVersion", replace: &Module{Path: "github.com/foo/lib", Version: "v1.0.1"}},
+		{name: "OtherMajorVersion", replace: &Module{Path: "github.com/foo/lib/v2", Version: "v2.0.0"}},
+		{name: "LocalDirectory", replace: &Module{Path: "../lib"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mod := Module{Path: "github.com/foo/lib", Version: "v1.0.0", Replace: tc.replace}
+			require.Equal(t, tc.want, mod.IsFork())
+		})
+	}
+}
+
+func TestParsePseudoVersion(t *testing.T) {
+	testCases := []struct {
+		version string
+		want    PseudoVersion
+		wantOK  bool
+	}{
+		{
+			version: "v0.0.0-20230102150405-abcdef123456",
+			want:    PseudoVersion{Commit: "abcdef123456", Time: time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)},
+			wantOK:  true,
+		},
+		{
+			version: "v1.2.4-0.20230102150405-abcdef123456",
+			want:    PseudoVersion{Commit: "abcdef123456", Time: time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC), Base: "v1.2.3"},
+			wantOK:  true,
+		},
+		{
+			version: "v1.2.3-rc.1.0.20230102150405-abcdef123456",
+			want:    PseudoVersion{Commit: "abcdef123456", Time: time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC), Base: "v1.2.3-rc.1"},
+			wantOK:  true,
+		},
+		{
+			version: "v2.0.1-0.20230102150405-abcdef123456+incompatible",
+			want:    PseudoVersion{Commit: "abcdef123456", Time: time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC), Base: "v2.0.0"},
+			wantOK:  true,
+		},
+		{version: "v1.2.3"},
+		{version: "v1.2.3-rc.1"},
+		{version: "v0.0.0-20231302150405-abcdef123456"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.version, func(t *testing.T) {
+			got, ok := ParsePseudoVersion(tc.version)
+			require.Equal(t, tc.wantOK, ok)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
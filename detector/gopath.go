@@ -0,0 +1,59 @@
+package detector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ParseGOPATHDependencies reads a JSON object mapping the import paths of the dependencies that legacy builds resolve
+// from the GOPATH, rather than the module cache, to their source directories. Relative directories are resolved
+// against baseDir and empty ones are looked up in the src directory of each GOPATH entry in turn.
+func ParseGOPATHDependencies(r io.Reader, baseDir string, gopath []string) (map[string]string, error) {
+	var dirs map[string]string
+	if err := json.NewDecoder(r).Decode(&dirs); err != nil {
+		return nil, fmt.Errorf("failed to parse GOPATH dependencies: %w", err)
+	}
+
+	for importPath, dir := range dirs {
+		switch {
+		case dir == "":
+			if dirs[importPath] = lookupGOPATH(importPath, gopath); dirs[importPath] == "" {
+				return nil, fmt.Errorf("%s is not in the GOPATH", importPath)
+			}
+		case !filepath.IsAbs(dir):
+			dirs[importPath] = filepath.Join(baseDir, filepath.FromSlash(dir))
+		}
+	}
+
+	return dirs, nil
+}
+
+func lookupGOPATH(importPath string, gopath []string) string {
+	for _, root := range gopath {
+		dir := filepath.Join(root, "src", filepath.FromSlash(importPath))
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			return dir
+		}
+	}
+	return ""
+}
+
+// addGOPATHDependencies adds the GOPATH dependencies to the direct dependencies, unless the input lists them as
+// modules already. They have no version.
+func addGOPATHDependencies(deps *Dependencies, dirs map[string]string) {
+	listed := make(map[string]struct{})
+	for _, depList := range [][]LicenceInfo{deps.Direct, deps.Indirect, deps.Tools} {
+		for _, dep := range depList {
+			listed[dep.Path] = struct{}{}
+		}
+	}
+
+	for importPath, dir := range dirs {
+		if _, ok := listed[importPath]; !ok {
+			deps.Direct = append(deps.Direct, LicenceInfo{Module: Module{Path: importPath, Dir: dir}})
+		}
+	}
+}
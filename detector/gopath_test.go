@@ -0,0 +1,44 @@
+package detector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGOPATHDependencies(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	require.NoError(t, err)
+	defer os.RemoveAll(gopath)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(gopath, "src", "example.com", "legacy"), 0755))
+	roots := []string{filepath.Join(gopath, "missing"), gopath}
+
+	dirs, err := ParseGOPATHDependencies(strings.NewReader(`{"example.com/legacy": "", "example.com/vendored": "third_party/vendored"}`), "/base", roots)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"example.com/legacy":   filepath.Join(gopath, "src", "example.com", "legacy"),
+		"example.com/vendored": filepath.Join("/base", "third_party", "vendored"),
+	}, dirs)
+
+	_, err = ParseGOPATHDependencies(strings.NewReader(`{"example.com/unknown": ""}`), "/base", roots)
+	require.Error(t, err)
+}
+
+func TestAddGOPATHDependencies(t *testing.T) {
+	deps := &Dependencies{
+		Direct: []LicenceInfo{{Module: Module{Path: "example.com/module", Version: "v1.0.0"}}},
+	}
+
+	addGOPATHDependencies(deps, map[string]string{"example.com/module": "/gopath/src/example.com/module", "example.com/legacy": "/gopath/src/example.com/legacy"})
+	sortDependencies(deps)
+
+	require.Equal(t, []LicenceInfo{
+		{Module: Module{Path: "example.com/legacy", Dir: "/gopath/src/example.com/legacy"}},
+		{Module: Module{Path: "example.com/module", Version: "v1.0.0"}},
+	}, deps.Direct)
+}
@@ -0,0 +1,87 @@
+package detector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreRule excludes the files and directories of a module matching a pattern from the detection, to suppress
+// false positives such as documentation files whose name looks like a licence.
+type IgnoreRule struct {
+	Module  string // module path
+	Pattern string // pattern, as accepted by path.Match, matched against the slash-separated path relative to the module root
+}
+
+// ParseIgnoreFile reads ignore rules, one per line in the "module: pattern" format. Empty lines and lines starting
+// with # are ignored.
+func ParseIgnoreFile(r io.Reader) ([]IgnoreRule, error) {
+	var rules []IgnoreRule
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		idx := strings.Index(line, ":")
+		if idx < 0 {
+			return nil, fmt.Errorf("line %d: expected module: pattern", lineNum)
+		}
+
+		rule := IgnoreRule{Module: strings.TrimSpace(line[:idx]), Pattern: strings.TrimSpace(line[idx+1:])}
+		if rule.Module == "" || rule.Pattern == "" {
+			return nil, fmt.Errorf("line %d: expected module: pattern", lineNum)
+		}
+		if _, err := path.Match(rule.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("line %d: invalid pattern %q: %w", lineNum, rule.Pattern, err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, scanner.Err()
+}
+
+// ignorePatterns returns the patterns of the rules applying to the module.
+func ignorePatterns(rules []IgnoreRule, modPath string) []string {
+	var patterns []string
+	for _, rule := range rules {
+		if rule.Module == modPath {
+			patterns = append(patterns, rule.Pattern)
+		}
+	}
+	return patterns
+}
+
+// ignored reports whether the file or directory at p, under root, matches an ignore pattern or is in a directory
+// that does.
+func (w *walker) ignored(root, p string) bool {
+	if len(w.ignore) == 0 {
+		return false
+	}
+
+	rel, err := filepath.Rel(root, p)
+	if err != nil || rel == "." {
+		return false
+	}
+
+	rel = filepath.ToSlash(rel)
+	for {
+		for _, pattern := range w.ignore {
+			if ok, _ := path.Match(pattern, rel); ok {
+				w.tracef("%s: ignored by the pattern %s", w.rel(root, p), pattern)
+				return true
+			}
+		}
+
+		idx := strings.LastIndex(rel, "/")
+		if idx < 0 {
+			return false
+		}
+		rel = rel[:idx]
+	}
+}
@@ -0,0 +1,222 @@
+package detector
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+)
+
+// The module information embedded in Go binaries by the linker is framed by these sentinels, whatever the version
+// of Go.
+var (
+	buildInfoStart = []byte("\x30\x77\xaf\x0c\x92\x74\x08\x02\x41\xe1\xc1\x07\xe6\xd6\x18\xe6")
+	buildInfoEnd   = []byte("\xf9\x32\x43\x31\x86\x18\x20\x72\x00\x82\x42\x10\x41\x16\xd8\xf2")
+)
+
+// ParseImage lists the modules shipped in a tarred filesystem, such as an image layer, an extracted image rootfs
+// or the output of docker save, whose layers are read in turn. The modules are taken from the build information of
+// the Go binaries and from the vendor/modules.txt files of vendor trees. The archive may be compressed with gzip.
+//
+// The modules are returned sorted by path, along with the hashes of their sources recorded in the binaries. Files
+// deleted by later layers are not taken into account.
+func ParseImage(r io.Reader) ([]Module, GoSum, error) {
+	c := &imageContents{mods: make(map[string]Module), sums: make(GoSum)}
+	if err := c.readArchive(r); err != nil {
+		return nil, nil, err
+	}
+
+	mods := make([]Module, 0, len(c.mods))
+	for _, mod := range c.mods {
+		mods = append(mods, mod)
+	}
+	sort.Slice(mods, func(i, j int) bool {
+		if mods[i].Path != mods[j].Path {
+			return mods[i].Path < mods[j].Path
+		}
+		return compareVersions(mods[i].Version, mods[j].Version) < 0
+	})
+
+	return mods, c.sums, nil
+}
+
+type imageContents struct {
+	mods map[string]Module // by path@version
+	sums GoSum
+}
+
+func (c *imageContents) readArchive(r io.Reader) error {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		br = bufio.NewReader(gz)
+	}
+
+	tr := tar.NewReader(br)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		if err := c.readFile(hdr.Name, tr); err != nil {
+			return fmt.Errorf("failed to read %s: %w", hdr.Name, err)
+		}
+	}
+}
+
+func (c *imageContents) readFile(name string, r io.Reader) error {
+	name = path.Clean(strings.TrimPrefix(name, "./"))
+
+	if strings.HasSuffix(name, "vendor/modules.txt") {
+		mods, err := parseModulesTxt(r)
+		if err != nil {
+			return err
+		}
+		c.add(mods...)
+		return nil
+	}
+
+	br := bufio.NewReader(r)
+	if isArchive(br) {
+		// a layer of docker save output
+		return c.readArchive(br)
+	}
+
+	if magic, err := br.Peek(4); err != nil || string(magic) != "\x7fELF" {
+		return nil
+	}
+
+	data, err := ioutil.ReadAll(br)
+	if err != nil {
+		return err
+	}
+	c.add(parseBuildInfo(data, c.sums)...)
+	return nil
+}
+
+func (c *imageContents) add(mods ...Module) {
+	for _, mod := range mods {
+		c.mods[mod.Path+"@"+mod.Version] = mod
+	}
+}
+
+// isArchive tells whether the file is a tar archive, compressed or not.
+func isArchive(br *bufio.Reader) bool {
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return true
+	}
+	hdr, err := br.Peek(262)
+	return err == nil && string(hdr[257:262]) == "ustar"
+}
+
+// parseBuildInfo returns the dependencies recorded in the build information of a Go binary, and records the hashes
+// of their sources in sums.
+func parseBuildInfo(data []byte, sums GoSum) []Module {
+	start := bytes.Index(data, buildInfoStart)
+	if start < 0 {
+		return nil
+	}
+	data = data[start+len(buildInfoStart):]
+	end := bytes.Index(data, buildInfoEnd)
+	if end < 0 {
+		return nil
+	}
+
+	var mods []Module
+	for _, line := range strings.Split(string(data[:end]), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			continue
+		}
+
+		mod := &Module{Path: fields[1], Version: fields[2]}
+		if mod.Version == "(devel)" {
+			// local replacement
+			mod.Version = ""
+		}
+
+		switch fields[0] {
+		case "dep":
+			mods = append(mods, *mod)
+		case "=>":
+			if len(mods) == 0 {
+				continue
+			}
+			mods[len(mods)-1].Replace = mod
+			mod = mods[len(mods)-1].Replace
+		default:
+			continue
+		}
+
+		if len(fields) > 3 && fields[3] != "" {
+			if sums[mod.Path] == nil {
+				sums[mod.Path] = make(map[string]string)
+			}
+			sums[mod.Path][mod.Version] = fields[3]
+		}
+	}
+
+	return mods
+}
+
+// parseModulesTxt returns the modules listed in the vendor/modules.txt file of a vendor tree.
+func parseModulesTxt(r io.Reader) ([]Module, error) {
+	var mods []Module
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "# ") {
+			continue
+		}
+
+		var mod Module
+		fields := strings.Fields(line[2:])
+		old, repl := fields, []string(nil)
+		for i, f := range fields {
+			if f == "=>" {
+				old, repl = fields[:i], fields[i+1:]
+				break
+			}
+		}
+
+		switch len(old) {
+		case 1:
+			mod.Path = old[0]
+		case 2:
+			mod.Path, mod.Version = old[0], old[1]
+		default:
+			return nil, fmt.Errorf("line %d: invalid module line %q", lineNum, line)
+		}
+
+		switch len(repl) {
+		case 0:
+		case 1:
+			mod.Replace = &Module{Path: repl[0]}
+		case 2:
+			mod.Replace = &Module{Path: repl[0], Version: repl[1]}
+		default:
+			return nil, fmt.Errorf("line %d: invalid replacement %q", lineNum, line)
+		}
+
+		mods = append(mods, mod)
+	}
+
+	return mods, scanner.Err()
+}
@@ -0,0 +1,69 @@
+package detector
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImage(t *testing.T) {
+	buildInfo := "path\texample.com/app/cmd/app\n" +
+		"mod\texample.com/app\t(devel)\t\n" +
+		"dep\tgithub.com/a/b\tv1.2.0\th1:ab=\n" +
+		"dep\tgithub.com/c/d\tv1.0.0\th1:cd=\n" +
+		"=>\tgithub.com/e/d\tv1.1.0\th1:ed=\n" +
+		"dep\tgithub.com/h/i\tv0.0.1\th1:hi=\n" +
+		"=>\t/src/i\t(devel)\t\n"
+	binary := "\x7fELF\x02\x01\x01" + string(buildInfoStart) + buildInfo + string(buildInfoEnd) + "\x00\x00"
+
+	modulesTxt := "# github.com/a/b v1.2.0\n## explicit\ngithub.com/a/b\n# github.com/f/g v0.1.0 => ../g\ngithub.com/f/g\n"
+
+	layer := mkTar(t, map[string]string{
+		"usr/local/bin/app":          binary,
+		"usr/local/bin/script.sh":    "#!/bin/sh\n",
+		"src/app/vendor/modules.txt": modulesTxt,
+	})
+	var gzLayer bytes.Buffer
+	gz := gzip.NewWriter(&gzLayer)
+	_, err := gz.Write(layer)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	// docker save output holding the compressed layer
+	image := mkTar(t, map[string]string{
+		"manifest.json":  `[{"Layers": ["0123/layer.tar"]}]`,
+		"0123/layer.tar": gzLayer.String(),
+	})
+
+	mods, sums, err := ParseImage(bytes.NewReader(image))
+	require.NoError(t, err)
+	require.Equal(t, []Module{
+		{Path: "github.com/a/b", Version: "v1.2.0"},
+		{Path: "github.com/c/d", Version: "v1.0.0", Replace: &Module{Path: "github.com/e/d", Version: "v1.1.0"}},
+		{Path: "github.com/f/g", Version: "v0.1.0", Replace: &Module{Path: "../g"}},
+		{Path: "github.com/h/i", Version: "v0.0.1", Replace: &Module{Path: "/src/i"}},
+	}, mods)
+	require.Equal(t, "h1:ab=", sums.Hash("github.com/a/b", "v1.2.0"))
+	require.Equal(t, "h1:ed=", sums.Hash("github.com/e/d", "v1.1.0"))
+}
+
+func TestParseModulesTxt(t *testing.T) {
+	_, err := parseModulesTxt(strings.NewReader("# github.com/a/b v1.0.0 extra\n"))
+	require.Error(t, err)
+}
+
+func mkTar(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, contents := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(contents)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
@@ -0,0 +1,101 @@
+package detector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// IndirectPolicy selects the indirect dependencies included in the results.
+type IndirectPolicy string
+
+const (
+	IndirectNone     IndirectPolicy = "none"     // leave out every indirect dependency
+	IndirectAll      IndirectPolicy = "all"      // include every indirect dependency
+	IndirectCopyleft IndirectPolicy = "copyleft" // include the indirect dependencies under a copyleft or unknown licence
+	IndirectLinked   IndirectPolicy = "linked"   // include the indirect dependencies providing packages to the binary
+)
+
+func ParseIndirectPolicy(value string) (IndirectPolicy, error) {
+	switch p := IndirectPolicy(value); p {
+	case IndirectNone, IndirectAll, IndirectCopyleft, IndirectLinked:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid indirect dependency policy %q: must be one of none, all, copyleft, linked", value)
+	}
+}
+
+// copyleftLicences are the licences requiring derived works or modified files to be distributed under the same terms.
+var copyleftLicences = map[string]struct{}{
+	"AGPL-3.0":     {},
+	"CC-BY-SA-4.0": {},
+	"CDDL-1.0":     {},
+	"EPL-1.0":      {},
+	"EPL-2.0":      {},
+	"EUPL-1.2":     {},
+	"GPL-2.0":      {},
+	"GPL-3.0":      {},
+	"LGPL-2.0":     {},
+	"LGPL-2.1":     {},
+	"LGPL-3.0":     {},
+	"MPL-2.0":      {},
+	"OSL-3.0":      {},
+}
+
+// IsCopyleft reports whether the SPDX identifier denotes a copyleft licence. The -only, -or-later and + variants of
+// the GNU licences are recognised.
+func IsCopyleft(id string) bool {
+	_, ok := copyleftLicences[baseLicenceID(id)]
+	return ok
+}
+
+// ParseLinkedModules returns the paths of the modules providing the packages listed in the output of
+// go list -deps -json, which are the modules linked into the binaries built from the listed packages.
+func ParseLinkedModules(r io.Reader) (map[string]struct{}, error) {
+	modules := make(map[string]struct{})
+	decoder := json.NewDecoder(r)
+	for {
+		var pkg struct {
+			ImportPath string
+			Module     *Module
+		}
+		if err := decoder.Decode(&pkg); err != nil {
+			if err == io.EOF {
+				return modules, nil
+			}
+			return nil, fmt.Errorf("failed to parse package list: %w", err)
+		}
+
+		// standard library packages have no module
+		if pkg.Module != nil {
+			modules[pkg.Module.Path] = struct{}{}
+		}
+	}
+}
+
+func (opts *Options) indirectPolicy() IndirectPolicy {
+	switch {
+	case opts.Indirect != "":
+		return opts.Indirect
+	case opts.IncludeIndirect:
+		return IndirectAll
+	default:
+		return IndirectNone
+	}
+}
+
+// excludeIndirect returns why the policy excludes an indirect dependency whose licence has been detected, or an empty
+// string if the dependency is included.
+func (opts *Options) excludeIndirect(dep LicenceInfo) string {
+	if !dep.Indirect || opts.indirectPolicy() != IndirectCopyleft || len(dep.Licences) == 0 {
+		return ""
+	}
+
+	for _, l := range dep.Licences {
+		if IsCopyleft(l) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s is not a copyleft licence", strings.Join(dep.Licences, " AND "))
+}
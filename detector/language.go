@@ -0,0 +1,116 @@
+package detector
+
+import (
+	"strings"
+	"unicode"
+)
+
+// LanguageEnglish is the language of the licence texts classified by the licence signatures.
+const LanguageEnglish = "en"
+
+// languageStopwords are frequent words of the languages written in the Latin script, used to tell them apart.
+var languageStopwords = map[string]map[string]struct{}{
+	"en": words("the and of to in or any this is for without by"),
+	"de": words("der die und das nicht oder mit von zu den ist für"),
+	"es": words("el los las y que para sin por con una del se"),
+	"fr": words("le les et des du ou pour sans est une au aux"),
+	"it": words("il di e che per non del della senza gli una"),
+	"pt": words("os e que para sem com não do da uma pelo"),
+}
+
+// translatedSignatures are the signatures of known translations of licences, by language. The phrases of the
+// languages written without spaces are matched with the whitespace of the text removed.
+var translatedSignatures = map[string][]licenceSignature{
+	"de": {{id: "MIT", required: []string{"hiermit wird unentgeltlich jeder person, die eine kopie der software"}}},
+	"es": {{id: "MIT", required: []string{"por la presente se concede permiso, libre de cargos, a cualquier persona que obtenga una copia"}}},
+	"fr": {{id: "MIT", required: []string{"l'autorisation est accordée, gracieusement, à toute personne acquérant une copie"}}},
+	"ja": {{id: "MIT", required: []string{"本ソフトウェアおよび関連文書のファイル"}}},
+	"zh": {
+		{id: "Apache-2.0", required: []string{"apache许可证", "版本2.0"}},
+		{id: "MIT", required: []string{"特此免费授予任何获得本软件副本"}},
+	},
+}
+
+func words(s string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, w := range strings.Fields(s) {
+		set[w] = struct{}{}
+	}
+	return set
+}
+
+// DetectLanguage guesses the language of a licence text, as an ISO 639-1 code. The languages written in their own
+// scripts are recognised from the script and the languages written in the Latin script from their frequent words.
+// An empty string is returned if the text has no letters.
+func DetectLanguage(text string) string {
+	var letters, han, kana, hangul, cyrillic, ukrainian int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+			if strings.ContainsRune("іїєґІЇЄҐ", r) {
+				ukrainian++
+			}
+		}
+	}
+
+	switch {
+	case letters == 0:
+		return ""
+	case kana > 0 && (kana+han)*4 > letters:
+		return "ja"
+	case han*4 > letters:
+		return "zh"
+	case hangul*4 > letters:
+		return "ko"
+	case cyrillic*2 > letters && ukrainian > 0:
+		return "uk"
+	case cyrillic*2 > letters:
+		return "ru"
+	}
+
+	counts := make(map[string]int)
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		for lang, stopwords := range languageStopwords {
+			if _, ok := stopwords[w]; ok {
+				counts[lang]++
+			}
+		}
+	}
+
+	// English wins ties
+	best := LanguageEnglish
+	for _, lang := range []string{"de", "es", "fr", "it", "pt"} {
+		if counts[lang] > counts[best] {
+			best = lang
+		}
+	}
+	return best
+}
+
+// classifyTranslatedText returns the SPDX identifier of the licence whose known translation to the language is
+// the text, if any.
+func classifyTranslatedText(text, lang string) string {
+	normalised := normaliseLicenceText(text)
+	switch lang {
+	case "ja", "ko", "zh":
+		normalised = strings.Join(strings.Fields(normalised), "")
+	}
+
+	for _, sig := range translatedSignatures[lang] {
+		if sig.matches(normalised) {
+			return sig.id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,48 @@
+package detector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	testCases := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "english", text: "Permission is hereby granted, free of charge, to any person obtaining a copy of this software", want: "en"},
+		{name: "german", text: "Hiermit wird unentgeltlich jeder Person, die eine Kopie der Software und der zugehörigen Dokumentationen erhält, die Erlaubnis erteilt", want: "de"},
+		{name: "french", text: "L'autorisation est accordée, gracieusement, à toute personne acquérant une copie de ce logiciel et des fichiers de documentation associés", want: "fr"},
+		{name: "chinese", text: "特此免费授予任何获得本软件副本和相关文档文件（下称“软件”）的人不受限制地处置该软件的权利", want: "zh"},
+		{name: "japanese", text: "以下に定める条件に従い、本ソフトウェアおよび関連文書のファイルの複製を取得するすべての人に対し", want: "ja"},
+		{name: "russian", text: "Данная лицензия разрешает лицам, получившим копию данного программного обеспечения", want: "ru"},
+		{name: "no letters", text: "2019 - 2020", want: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, DetectLanguage(tc.text))
+		})
+	}
+}
+
+func TestDetectTranslatedLicence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "translated")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	text := "MIT 许可证\n\n特此免费授予任何获得本软件副本和相关文档文件（下称“软件”）的人不受限制地处置该软件的权利，\n包括不受限制地使用、复制、修改、合并、发布、分发、转授许可和/或出售该软件副本。\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "LICENSE"), []byte(text), 0644))
+
+	dep := LicenceInfo{Module: Module{Path: "example.com/translated", Version: "v1.0.0", Dir: dir}}
+	require.NoError(t, detectLicence(&dep, &Options{Symlinks: SymlinkFollow}))
+	require.Equal(t, []string{"MIT"}, dep.Licences)
+	require.Equal(t, "zh", dep.Language)
+	require.Len(t, dep.Warnings, 1)
+	require.Contains(t, dep.Warnings[0], "review it manually")
+}
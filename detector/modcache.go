@@ -0,0 +1,14 @@
+package detector
+
+import "path/filepath"
+
+// ModuleCacheDir returns the directory of the given module version in the module cache rooted at cache.
+func ModuleCacheDir(cache, modPath, version string) string {
+	return filepath.Join(cache, filepath.FromSlash(EscapeModulePath(modPath))+"@"+EscapeModulePath(version))
+}
+
+// ModuleZipHashFile returns the file holding the hash of the zip of the given module version in the module cache
+// rooted at cache.
+func ModuleZipHashFile(cache, modPath, version string) string {
+	return filepath.Join(cache, "cache", "download", filepath.FromSlash(EscapeModulePath(modPath)), "@v", EscapeModulePath(version)+".ziphash")
+}
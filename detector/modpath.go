@@ -0,0 +1,38 @@
+package detector
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ModulePathBase returns the module path without its major version suffix, so that github.com/foo/bar/v3 and
+// gopkg.in/yaml.v2 are identified as the same projects as github.com/foo/bar and gopkg.in/yaml.
+func ModulePathBase(modPath string) string {
+	if strings.HasPrefix(modPath, "gopkg.in/") {
+		if i := strings.LastIndex(modPath, ".v"); i > 0 && isMajorVersion(modPath[i+2:], 0) {
+			return modPath[:i]
+		}
+		return modPath
+	}
+
+	if i := strings.LastIndex(modPath, "/v"); i > 0 && isMajorVersion(modPath[i+2:], 2) {
+		return modPath[:i]
+	}
+	return modPath
+}
+
+// isMajorVersion reports whether s is a major version number of at least min without leading zeros.
+func isMajorVersion(s string, min int) bool {
+	if s == "" || (s[0] == '0' && s != "0") {
+		return false
+	}
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= min && strconv.Itoa(n) == s
+}
+
+// IsFork reports whether the module is replaced by a different module, which is taken to be a fork of it. The
+// licence is detected from the fork while the module path identifies the original project. Replacements by another
+// version of the same module or by a local directory are not forks.
+func (m Module) IsFork() bool {
+	return m.Replace != nil && m.Replace.Version != "" && ModulePathBase(m.Replace.Path) != ModulePathBase(m.Path)
+}
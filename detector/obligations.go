@@ -0,0 +1,60 @@
+package detector
+
+import "strings"
+
+// Obligations are the main conditions a licence places on the redistribution of the licensed work.
+type Obligations struct {
+	Attribution  bool `json:"attribution"`  // the copyright and licence notices must be kept
+	ShareAlike   bool `json:"shareAlike"`   // derived works or modified files must be distributed under the same licence
+	StateChanges bool `json:"stateChanges"` // modified files must carry a notice stating the changes
+	PatentGrant  bool `json:"patentGrant"`  // the licence grants the rights to the patents of the contributors
+}
+
+// knownObligations are the obligations of the licences recognised by the detector, share-alike aside as it follows
+// from IsCopyleft.
+var knownObligations = map[string]Obligations{
+	"0BSD":         {},
+	"AGPL-3.0":     {Attribution: true, StateChanges: true, PatentGrant: true},
+	"Apache-2.0":   {Attribution: true, StateChanges: true, PatentGrant: true},
+	"BSD-2-Clause": {Attribution: true},
+	"BSD-3-Clause": {Attribution: true},
+	"BSL-1.0":      {Attribution: true},
+	"CC-BY-4.0":    {Attribution: true, StateChanges: true},
+	"CC-BY-SA-4.0": {Attribution: true, StateChanges: true},
+	"CC0-1.0":      {},
+	"CDDL-1.0":     {Attribution: true, PatentGrant: true},
+	"EPL-1.0":      {Attribution: true, PatentGrant: true},
+	"EPL-2.0":      {Attribution: true, PatentGrant: true},
+	"EUPL-1.2":     {Attribution: true, StateChanges: true, PatentGrant: true},
+	"GPL-2.0":      {Attribution: true, StateChanges: true},
+	"GPL-3.0":      {Attribution: true, StateChanges: true, PatentGrant: true},
+	"ISC":          {Attribution: true},
+	"LGPL-2.0":     {Attribution: true, StateChanges: true},
+	"LGPL-2.1":     {Attribution: true, StateChanges: true},
+	"LGPL-3.0":     {Attribution: true, StateChanges: true, PatentGrant: true},
+	"MIT":          {Attribution: true},
+	"MPL-2.0":      {Attribution: true, PatentGrant: true},
+	"OSL-3.0":      {Attribution: true, StateChanges: true, PatentGrant: true},
+	"Unlicense":    {},
+	"Zlib":         {Attribution: true, StateChanges: true},
+}
+
+// LicenceObligations returns the obligations of the licence with the given SPDX identifier, looked up in overrides
+// first and then in the built-in knowledge base. The boolean is false if the licence is unknown to both.
+func LicenceObligations(id string, overrides map[string]Obligations) (Obligations, bool) {
+	if o, ok := overrides[id]; ok {
+		return o, true
+	}
+
+	o, ok := knownObligations[baseLicenceID(id)]
+	o.ShareAlike = ok && IsCopyleft(id)
+	return o, ok
+}
+
+// baseLicenceID strips the -only, -or-later and + suffixes of the GNU licence identifiers.
+func baseLicenceID(id string) string {
+	for _, suffix := range []string{"-only", "-or-later", "+"} {
+		id = strings.TrimSuffix(id, suffix)
+	}
+	return id
+}
@@ -0,0 +1,35 @@
+package detector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLicenceObligations(t *testing.T) {
+	overrides := map[string]Obligations{
+		"MIT":         {},
+		"Proprietary": {Attribution: true},
+	}
+
+	testCases := []struct {
+		id        string
+		want      Obligations
+		wantKnown bool
+	}{
+		{id: "Apache-2.0", want: Obligations{Attribution: true, StateChanges: true, PatentGrant: true}, wantKnown: true},
+		{id: "GPL-3.0-or-later", want: Obligations{Attribution: true, ShareAlike: true, StateChanges: true, PatentGrant: true}, wantKnown: true},
+		{id: "MPL-2.0", want: Obligations{Attribution: true, ShareAlike: true, PatentGrant: true}, wantKnown: true},
+		{id: "MIT", want: Obligations{}, wantKnown: true},
+		{id: "Proprietary", want: Obligations{Attribution: true}, wantKnown: true},
+		{id: "BUSL-1.1", want: Obligations{}, wantKnown: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.id, func(t *testing.T) {
+			got, known := LicenceObligations(tc.id, overrides)
+			require.Equal(t, tc.wantKnown, known)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
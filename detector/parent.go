@@ -0,0 +1,79 @@
+package detector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// repositoryMarkers are the entries found at the root of a repository.
+var repositoryMarkers = []string{".git", ".hg", ".svn"}
+
+// findEnclosingLicence returns the licence files of a module that has none of its own, found in the directory given
+// by Options.LicenceDirs or, for a local replacement, at the closest enclosing directory of the module within its
+// repository. Modules downloaded to the module cache are complete, so they are not looked up outside of their
+// directory unless configured.
+func findEnclosingLicence(dep *LicenceInfo, srcDir string, opts *Options, w *walker) ([]string, error) {
+	if dir, ok := opts.LicenceDirs[dep.Path]; ok {
+		files, err := findRootLicenceFiles(dir, w)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find licence of %s in %s: %w", dep.Path, dir, err)
+		}
+		if len(files) > 0 {
+			w.tracef("licence found in the configured directory %s", dir)
+		}
+		return files, nil
+	}
+
+	if dep.Replace == nil || dep.Replace.Version != "" {
+		return nil, nil
+	}
+
+	dir, err := filepath.Abs(srcDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// outside of a repository, there is no telling which directories belong to the project
+	root := repositoryRoot(dir)
+	if root == "" {
+		return nil, nil
+	}
+
+	for dir != root {
+		dir = filepath.Dir(dir)
+		files, err := findRootLicenceFiles(dir, w)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) > 0 {
+			w.addWarning(Warning{Kind: WarningParentLicence, Path: dir, Message: fmt.Sprintf("no licence found in %s: using the licence of the enclosing directory %s", srcDir, dir)})
+			return files, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// repositoryRoot returns the root of the repository holding dir, or an empty string if it is not in a repository.
+func repositoryRoot(dir string) string {
+	for {
+		if isRepositoryRoot(dir) {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func isRepositoryRoot(dir string) bool {
+	for _, marker := range repositoryMarkers {
+		if _, err := os.Lstat(filepath.Join(dir, marker)); err == nil {
+			return true
+		}
+	}
+	return false
+}
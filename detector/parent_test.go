@@ -0,0 +1,67 @@
+package detector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectParentLicence(t *testing.T) {
+	mit := []byte(`Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal`)
+
+	repo, err := ioutil.TempDir("", "repo")
+	require.NoError(t, err)
+	defer os.RemoveAll(repo)
+
+	sdk := filepath.Join(repo, "sdk", "go")
+	require.NoError(t, os.MkdirAll(sdk, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(sdk, "go.mod"), []byte("module example.com/sdk\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(repo, "LICENSE"), mit, 0644))
+
+	testCases := []struct {
+		name        string
+		repoRoot    bool
+		replace     string
+		licenceDirs map[string]string
+		wantSource  string
+	}{
+		{name: "local replacement", repoRoot: true, replace: `{"Path": "../sdk/go", "Dir": "` + filepath.ToSlash(sdk) + `"}`, wantSource: SourceParent},
+		{name: "outside of a repository", replace: `{"Path": "../sdk/go", "Dir": "` + filepath.ToSlash(sdk) + `"}`},
+		{name: "module cache", repoRoot: true, replace: `{"Path": "example.com/fork", "Version": "v1.0.0", "Dir": "` + filepath.ToSlash(sdk) + `"}`},
+		{
+			name:        "configured directory",
+			replace:     `{"Path": "example.com/fork", "Version": "v1.0.0", "Dir": "` + filepath.ToSlash(sdk) + `"}`,
+			licenceDirs: map[string]string{"example.com/sdk": repo},
+			wantSource:  SourceParent,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			os.RemoveAll(filepath.Join(repo, ".git"))
+			if tc.repoRoot {
+				require.NoError(t, os.Mkdir(filepath.Join(repo, ".git"), 0755))
+			}
+
+			input := `{"Path": "example.com/sdk", "Version": "v1.0.0", "Replace": ` + tc.replace + `}`
+			deps, err := DetectWithOptions(strings.NewReader(input), &Options{LicenceDirs: tc.licenceDirs})
+			require.NoError(t, err)
+			require.Len(t, deps.Direct, 1)
+
+			dep := deps.Direct[0]
+			if tc.wantSource == "" {
+				require.Equal(t, ErrLicenceNotFound, dep.Error)
+				return
+			}
+			require.NoError(t, dep.Error)
+			require.Equal(t, tc.wantSource, dep.Source)
+			require.Equal(t, filepath.Join(repo, "LICENSE"), dep.LicenceFile)
+			require.Equal(t, []string{"MIT"}, dep.Licences)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package detector
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// MissingModule is a dependency whose sources are not available, along with the reason.
+type MissingModule struct {
+	Module
+	Reason string
+}
+
+// MissingModulesError is returned before detection when the sources of some dependencies are not available, which
+// would otherwise leave them out of the results.
+type MissingModulesError struct {
+	Modules []MissingModule
+}
+
+func (e *MissingModulesError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "the sources of %d modules are not available:", len(e.Modules))
+	for _, m := range e.Modules {
+		fmt.Fprintf(&sb, "\n  %s", m.Path)
+		if m.Version != "" {
+			fmt.Fprintf(&sb, "@%s", m.Version)
+		}
+		fmt.Fprintf(&sb, ": %s", m.Reason)
+	}
+	sb.WriteString("\nRun `go mod download` in the main module to populate the module cache, then list the dependencies again")
+	return sb.String()
+}
+
+// checkModuleDirs returns the dependencies whose directory does not exist or cannot be read.
+func checkModuleDirs(deps *Dependencies) []MissingModule {
+	var missing []MissingModule
+	for _, depList := range [][]LicenceInfo{deps.Direct, deps.Indirect, deps.Tools} {
+		for _, dep := range depList {
+			if reason := checkModuleDir(sourceDir(dep.Module)); reason != "" {
+				missing = append(missing, MissingModule{Module: dep.Module, Reason: reason})
+			}
+		}
+	}
+	return missing
+}
+
+func checkModuleDir(dir string) string {
+	if dir == "" {
+		return "no directory in the dependency list"
+	}
+
+	f, err := os.Open(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Sprintf("directory %s does not exist", dir)
+		}
+		return fmt.Sprintf("directory %s cannot be read: %v", dir, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Readdirnames(1); err != nil && err != io.EOF {
+		return fmt.Sprintf("directory %s cannot be read: %v", dir, err)
+	}
+	return ""
+}
+
+// removeMissing moves the missing modules from the dependencies to the skipped modules.
+func removeMissing(deps *Dependencies, missing []MissingModule) {
+	paths := make(map[string]struct{}, len(missing))
+	for _, m := range missing {
+		paths[m.Path] = struct{}{}
+		deps.Skipped = append(deps.Skipped, SkippedModule{Module: m.Module, Reason: SkipMissing, Detail: m.Reason})
+	}
+
+	keep := func(depList []LicenceInfo) []LicenceInfo {
+		var kept []LicenceInfo
+		for _, dep := range depList {
+			if _, ok := paths[dep.Path]; !ok {
+				kept = append(kept, dep)
+			}
+		}
+		return kept
+	}
+	deps.Direct, deps.Indirect, deps.Tools = keep(deps.Direct), keep(deps.Indirect), keep(deps.Tools)
+}
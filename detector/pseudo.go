@@ -0,0 +1,62 @@
+package detector
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var pseudoVersionRegex = regexp.MustCompile(`^v\d+\.(\d+\.\d+-(.+\.)?0\.|0\.0-)\d{14}-[0-9a-f]{12}(\+incompatible)?$`)
+
+const pseudoVersionTimeLayout = "20060102150405"
+
+// PseudoVersion is the commit identified by a pseudo-version such as v0.0.0-20230101000000-abcdef123456.
+type PseudoVersion struct {
+	Commit string    // abbreviated commit hash
+	Time   time.Time // commit time, in UTC
+	Base   string    // latest tag before the commit, if any
+}
+
+// ParsePseudoVersion parses a pseudo-version. The boolean is false if the version is not a pseudo-version. The base
+// tag is derived from the version itself: v1.2.4-0.20230101000000-abcdef123456 follows v1.2.3 and
+// v1.2.3-pre.0.20230101000000-abcdef123456 follows v1.2.3-pre.
+func ParsePseudoVersion(version string) (PseudoVersion, bool) {
+	if !pseudoVersionRegex.MatchString(version) {
+		return PseudoVersion{}, false
+	}
+
+	version = strings.TrimSuffix(version, "+incompatible")
+	idx := strings.LastIndex(version, "-")
+	rest := version[:idx]
+	timestamp := rest[len(rest)-14:]
+
+	t, err := time.Parse(pseudoVersionTimeLayout, timestamp)
+	if err != nil {
+		return PseudoVersion{}, false
+	}
+
+	pv := PseudoVersion{Commit: version[idx+1:], Time: t}
+
+	// vX.0.0-timestamp-commit has no base
+	if rest[len(rest)-15] == '.' {
+		base := rest[:len(rest)-15]
+		if strings.HasSuffix(base, "-0") {
+			pv.Base = previousPatch(strings.TrimSuffix(base, "-0"))
+		} else {
+			pv.Base = strings.TrimSuffix(base, ".0")
+		}
+	}
+
+	return pv, true
+}
+
+// previousPatch returns the version preceding vX.Y.Z, with Z greater than 0.
+func previousPatch(version string) string {
+	idx := strings.LastIndex(version, ".")
+	patch, err := strconv.Atoi(version[idx+1:])
+	if err != nil || patch == 0 {
+		return ""
+	}
+	return version[:idx+1] + strconv.Itoa(patch-1)
+}
@@ -0,0 +1,244 @@
+package detector
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// REUSE specification: https://reuse.software/spec/
+const (
+	reuseLicencesDir = "LICENSES"
+	reuseDep5File    = ".reuse/dep5"
+	spdxHeaderLimit  = 4096
+)
+
+var spdxHeaderRegex = regexp.MustCompile(`SPDX-License-Identifier:\s*(.+)`)
+
+type reuseInfo struct {
+	licenceFiles []string
+	identifiers  []string
+	expression   string
+}
+
+// detectReuse collects the licences declared by a REUSE-compliant module. It returns nil if the module does not
+// follow the REUSE specification.
+func detectReuse(root string, w *walker) (*reuseInfo, error) {
+	licenceFiles, err := readReuseLicencesDir(filepath.Join(root, reuseLicencesDir), w)
+	if err != nil {
+		return nil, err
+	}
+
+	dep5Path := filepath.Join(root, filepath.FromSlash(reuseDep5File))
+	_, err = os.Stat(dep5Path)
+	hasDep5 := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if len(licenceFiles) == 0 && !hasDep5 {
+		return nil, nil
+	}
+
+	licences := newSPDXLicences()
+	for _, f := range licenceFiles {
+		name := filepath.Base(f)
+		licences.addID(strings.TrimSuffix(name, filepath.Ext(name)))
+	}
+
+	if hasDep5 {
+		if err := collectDep5Licences(dep5Path, licences); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := collectSPDXHeaders(root, w, licences); err != nil {
+		return nil, err
+	}
+
+	return &reuseInfo{licenceFiles: licenceFiles, identifiers: licences.identifiers(), expression: licences.expression()}, nil
+}
+
+func readReuseLicencesDir(dir string, w *walker) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		if os.IsPermission(err) {
+			w.warn(dir, err)
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var files []string
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		fi, err := w.stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if fi != nil && fi.Mode().IsRegular() {
+			files = append(files, path)
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+func collectDep5Licences(path string, licences *spdxLicences) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "License:") {
+			licences.addExpression(strings.TrimPrefix(line, "License:"))
+		}
+	}
+
+	return scanner.Err()
+}
+
+func collectSPDXHeaders(root string, w *walker, licences *spdxLicences) error {
+	buf := make([]byte, spdxHeaderLimit)
+	return w.walk(root, func(path, name string, mode os.FileMode) error {
+		if mode.IsDir() {
+			if path != root && (name == reuseLicencesDir || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !mode.IsRegular() {
+			return nil
+		}
+
+		header, err := readHeader(path, buf)
+		if err != nil {
+			if os.IsPermission(err) {
+				w.warn(path, err)
+				return nil
+			}
+			return err
+		}
+
+		for _, m := range spdxHeaderRegex.FindAllSubmatch(header, -1) {
+			licences.addExpression(string(m[1]))
+		}
+		return nil
+	})
+}
+
+func readHeader(path string, buf []byte) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+
+	return buf[:n], nil
+}
+
+// spdxLicences collects the licences declared by a module. The SPDX licence expressions are kept along with the
+// identifiers they reference, so that a choice between licences (OR) is not mistaken for a combination (AND).
+type spdxLicences struct {
+	ids         map[string]struct{}
+	expressions map[string]struct{}
+}
+
+func newSPDXLicences() *spdxLicences {
+	return &spdxLicences{ids: make(map[string]struct{}), expressions: make(map[string]struct{})}
+}
+
+func (l *spdxLicences) addID(id string) {
+	l.ids[id] = struct{}{}
+}
+
+// addExpression records the SPDX licence expression, stripped of the comment terminators of the header it was read
+// from, and each licence identifier it references.
+func (l *spdxLicences) addExpression(expr string) {
+	expr = strings.Join(strings.Fields(strings.NewReplacer("*/", " ", "-->", " ").Replace(expr)), " ")
+	if expr == "" {
+		return
+	}
+	l.expressions[expr] = struct{}{}
+
+	for _, id := range spdxExpressionIDs(expr) {
+		l.addID(id)
+	}
+}
+
+func (l *spdxLicences) identifiers() []string {
+	ids := make([]string, 0, len(l.ids))
+	for id := range l.ids {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// expression returns the licence expression of the module, or an empty string if no expression was declared. The
+// expressions declared for different files all apply, so they are combined with AND along with the identifiers
+// that no expression references.
+func (l *spdxLicences) expression() string {
+	if len(l.expressions) == 0 {
+		return ""
+	}
+
+	referenced := make(map[string]struct{})
+	terms := make([]string, 0, len(l.expressions))
+	for expr := range l.expressions {
+		terms = append(terms, expr)
+		for _, id := range spdxExpressionIDs(expr) {
+			referenced[id] = struct{}{}
+		}
+	}
+	for id := range l.ids {
+		if _, ok := referenced[id]; !ok {
+			terms = append(terms, id)
+		}
+	}
+
+	if len(terms) == 1 {
+		return terms[0]
+	}
+
+	sort.Strings(terms)
+	for i, term := range terms {
+		if strings.Contains(term, " ") {
+			terms[i] = "(" + term + ")"
+		}
+	}
+	return strings.Join(terms, " AND ")
+}
+
+// spdxExpressionIDs returns the licence identifiers referenced by the SPDX licence expression.
+func spdxExpressionIDs(expr string) []string {
+	var ids []string
+	for _, tok := range strings.Fields(strings.NewReplacer("(", " ", ")", " ").Replace(expr)) {
+		switch strings.ToUpper(tok) {
+		case "AND", "OR", "WITH":
+			continue
+		}
+		ids = append(ids, tok)
+	}
+	return ids
+}
@@ -0,0 +1,129 @@
+package detector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// ScanCodeResults holds the subset of the ScanCode toolkit JSON output (scancode --json) used by the detector.
+type ScanCodeResults struct {
+	Files []ScanCodeFile `json:"files"`
+}
+
+type ScanCodeFile struct {
+	Path                   string            `json:"path"`
+	Type                   string            `json:"type"`
+	IsLicenseText          bool              `json:"is_license_text"`
+	DetectedLicenseSPDX    string            `json:"detected_license_expression_spdx"` // ScanCode >= 32
+	Licenses               []ScanCodeLicence `json:"licenses"`                         // ScanCode < 32
+	LicenseExpressionsSPDX []string          `json:"spdx_license_expressions"`
+}
+
+type ScanCodeLicence struct {
+	SPDXLicenseKey string `json:"spdx_license_key"`
+}
+
+func ParseScanCode(data io.Reader) (*ScanCodeResults, error) {
+	var results ScanCodeResults
+	if err := json.NewDecoder(data).Decode(&results); err != nil {
+		return nil, fmt.Errorf("failed to parse ScanCode results: %w", err)
+	}
+
+	return &results, nil
+}
+
+// applyTo enriches the dependency with the detections reported by ScanCode. A licence text identified by ScanCode
+// takes precedence over the file picked by the detector.
+func (r *ScanCodeResults) applyTo(dep *LicenceInfo) {
+	srcDir := sourceDir(dep.Module)
+	licences := newSPDXLicences()
+	for _, id := range dep.Licences {
+		licences.addID(id)
+	}
+	if dep.LicenceExpression != "" {
+		licences.addExpression(dep.LicenceExpression)
+	}
+
+	var licenceText string
+	for _, f := range r.Files {
+		if f.Type != "file" {
+			continue
+		}
+
+		rel, ok := scanCodeRelPath(dep.Module, f.Path)
+		if !ok {
+			continue
+		}
+
+		licences.addExpression(f.DetectedLicenseSPDX)
+		for _, expr := range f.LicenseExpressionsSPDX {
+			licences.addExpression(expr)
+		}
+		for _, l := range f.Licenses {
+			if l.SPDXLicenseKey != "" {
+				licences.addID(l.SPDXLicenseKey)
+			}
+		}
+
+		if f.IsLicenseText && (licenceText == "" || strings.Count(rel, "/") < strings.Count(licenceText, "/")) {
+			licenceText = rel
+		}
+	}
+
+	if licenceText != "" && len(dep.LicenceFiles) == 0 {
+		dep.LicenceFile = filepath.Join(srcDir, filepath.FromSlash(licenceText))
+		dep.Source = SourceScanCode
+		dep.Error = nil
+	}
+
+	if ids := licences.identifiers(); len(ids) > 0 {
+		dep.Licences = ids
+	}
+	dep.LicenceExpression = licences.expression()
+}
+
+// scanCodeRelPath returns the path of a ScanCode result relative to the module source directory. Modules from the
+// module cache are matched by their escaped path@version directory so that the scan can be rooted anywhere. Other
+// modules (local replacements) require the scan to have been run with --full-root.
+func scanCodeRelPath(mod Module, resultPath string) (string, bool) {
+	if mod.Replace != nil {
+		mod = *mod.Replace
+	}
+
+	resultPath = "/" + strings.TrimPrefix(path.Clean(filepath.ToSlash(resultPath)), "/")
+
+	var key string
+	if mod.Version != "" {
+		key = "/" + EscapeModulePath(mod.Path) + "@" + mod.Version + "/"
+	} else {
+		key = "/" + strings.Trim(filepath.ToSlash(mod.Dir), "/") + "/"
+	}
+
+	idx := strings.Index(resultPath, key)
+	if idx < 0 {
+		return "", false
+	}
+
+	return resultPath[idx+len(key):], true
+}
+
+// EscapeModulePath applies the module cache case-encoding: each upper-case letter is replaced by an exclamation
+// mark followed by the lower-case letter.
+func EscapeModulePath(modPath string) string {
+	var sb strings.Builder
+	for _, r := range modPath {
+		if unicode.IsUpper(r) {
+			sb.WriteByte('!')
+			sb.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,74 @@
+package detector
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// subcomponentRoots are the names of the directories under which modules keep copies of other projects.
+var subcomponentRoots = map[string]struct{}{
+	"third_party": {},
+	"third-party": {},
+	"vendor":      {},
+}
+
+// Subcomponent is a copy of another project inside a module, with a licence of its own.
+type Subcomponent struct {
+	Dir         string   // directory of the sub-component, relative to the module root and slash-separated
+	LicenceFile string   // licence file of the sub-component
+	Licences    []string // SPDX identifiers of the licences of the sub-component
+}
+
+// subcomponentDir returns the directory of the sub-component holding the licence file, relative to root, or an empty
+// string if the file is not under a sub-component root such as third_party/ or internal/vendor/.
+func subcomponentDir(root, file string) string {
+	rel, err := filepath.Rel(root, file)
+	if err != nil {
+		return ""
+	}
+
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for _, part := range parts[:len(parts)-1] {
+		if _, ok := subcomponentRoots[part]; ok {
+			return strings.Join(parts[:len(parts)-1], "/")
+		}
+	}
+	return ""
+}
+
+// detectSubcomponents records the sub-components of the module from the licence files found under sub-component
+// roots and returns the remaining candidates, which are the licence files of the module itself. Only the first
+// licence file of a sub-component is used and nested sub-components are ignored, the candidates being sorted from the
+// shallowest to the deepest.
+func detectSubcomponents(dep *LicenceInfo, root string, candidates []string) ([]string, error) {
+	var own []string
+	for _, file := range candidates {
+		dir := subcomponentDir(root, file)
+		if dir == "" {
+			own = append(own, file)
+			continue
+		}
+
+		if isInSubcomponent(dep.Subcomponents, dir) {
+			continue
+		}
+
+		c, err := classifyLicenceFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to classify licence of %s in %s: %w", dir, dep.Path, err)
+		}
+		dep.Subcomponents = append(dep.Subcomponents, Subcomponent{Dir: dir, LicenceFile: file, Licences: c.licences})
+	}
+
+	return own, nil
+}
+
+func isInSubcomponent(subcomponents []Subcomponent, dir string) bool {
+	for _, s := range subcomponents {
+		if dir == s.Dir || strings.HasPrefix(dir, s.Dir+"/") {
+			return true
+		}
+	}
+	return false
+}
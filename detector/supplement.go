@@ -0,0 +1,52 @@
+package detector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// Supplement declares dependencies that are not Go modules and are therefore missing from the go list output, such
+// as C libraries linked through cgo. Their licences are detected from their source trees like those of modules.
+type Supplement struct {
+	Libraries []SupplementLibrary `json:"libraries"`
+}
+
+type SupplementLibrary struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Dir      string `json:"dir"` // source tree of the library, relative to the supplemental manifest
+	Indirect bool   `json:"indirect"`
+}
+
+// ParseSupplement reads a supplemental manifest. Relative library directories are resolved against baseDir.
+func ParseSupplement(r io.Reader, baseDir string) (*Supplement, error) {
+	var s Supplement
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to parse supplemental manifest: %w", err)
+	}
+
+	for i, lib := range s.Libraries {
+		if lib.Name == "" || lib.Dir == "" {
+			return nil, fmt.Errorf("library #%d of the supplemental manifest must have a name and a dir", i+1)
+		}
+
+		if !filepath.IsAbs(lib.Dir) {
+			s.Libraries[i].Dir = filepath.Join(baseDir, filepath.FromSlash(lib.Dir))
+		}
+	}
+
+	return &s, nil
+}
+
+func (s *Supplement) addTo(deps *Dependencies, includeIndirect bool) {
+	for _, lib := range s.Libraries {
+		dep := LicenceInfo{Module: Module{Path: lib.Name, Version: lib.Version, Dir: lib.Dir, Indirect: lib.Indirect}}
+		if !lib.Indirect {
+			deps.Direct = append(deps.Direct, dep)
+		} else if includeIndirect {
+			deps.Indirect = append(deps.Indirect, dep)
+		}
+	}
+}
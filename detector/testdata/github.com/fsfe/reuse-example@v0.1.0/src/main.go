@@ -0,0 +1,7 @@
+// SPDX-FileCopyrightText: 2019 Jane Doe <jane@example.com>
+//
+// SPDX-License-Identifier: MIT OR Apache-2.0
+
+package main
+
+func main() {}
@@ -0,0 +1,18 @@
+module example.com/app
+
+go 1.13
+
+require (
+	github.com/davecgh/go-spew v1.1.0
+	github.com/dgryski/go-minhash v0.0.0-20170608043002-7fe510aff544 // indirect
+	"github.com/russross/blackfriday" v1.5.2
+)
+
+require github.com/ekzhu/minhash-lsh v0.0.0-20171225071031-5c06ee8586a1
+
+replace github.com/russross/blackfriday => ../github.com/russross/blackfriday
+
+replace (
+	github.com/davecgh/go-spew => github.com/davecgh/go-spew v1.1.1
+	github.com/davecgh/go-spew v1.1.0 => github.com/example/go-spew v1.1.0
+)
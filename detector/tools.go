@@ -0,0 +1,47 @@
+package detector
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseToolsGo returns the package paths imported by a tools.go file, which records the build tools of a module as
+// blank imports behind a build constraint.
+func ParseToolsGo(r io.Reader) ([]string, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), "tools.go", r, parser.ImportsOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tools.go: %w", err)
+	}
+
+	tools := make([]string, 0, len(f.Imports))
+	for _, imp := range f.Imports {
+		pkgPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid import path %s: %w", imp.Path.Value, err)
+		}
+		tools = append(tools, pkgPath)
+	}
+	return tools, nil
+}
+
+// toolModules returns the paths of the modules providing the tool packages, which are the modules with the longest
+// path prefixing each package path.
+func toolModules(tools []string, mods []Module) map[string]struct{} {
+	paths := make(map[string]struct{})
+	for _, pkgPath := range tools {
+		provider := ""
+		for _, mod := range mods {
+			if (pkgPath == mod.Path || strings.HasPrefix(pkgPath, mod.Path+"/")) && len(mod.Path) > len(provider) {
+				provider = mod.Path
+			}
+		}
+		if provider != "" {
+			paths[provider] = struct{}{}
+		}
+	}
+	return paths
+}
@@ -0,0 +1,51 @@
+package detector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseToolsGo(t *testing.T) {
+	toolsGo := `//go:build tools
+// +build tools
+
+package tools
+
+import (
+	_ "github.com/golang/mock/mockgen"
+	_ "golang.org/x/tools/cmd/stringer"
+)
+`
+
+	tools, err := ParseToolsGo(strings.NewReader(toolsGo))
+	require.NoError(t, err)
+	require.Equal(t, []string{"github.com/golang/mock/mockgen", "golang.org/x/tools/cmd/stringer"}, tools)
+}
+
+func TestParseGoModTools(t *testing.T) {
+	gomod, err := ParseGoMod(strings.NewReader("module example.com/main\n\ntool golang.org/x/tools/cmd/stringer\n\ntool (\n\tgithub.com/golang/mock/mockgen\n)\n"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"golang.org/x/tools/cmd/stringer", "github.com/golang/mock/mockgen"}, gomod.Tools)
+}
+
+func TestParseDependenciesTools(t *testing.T) {
+	input := `{"Path": "example.com/main", "Main": true}
+{"Path": "golang.org/x/tools", "Version": "v0.1.0", "Indirect": true}
+{"Path": "golang.org/x/tools/gopls", "Version": "v0.7.0", "Indirect": true}
+{"Path": "github.com/foo/lib", "Version": "v1.0.0"}`
+
+	deps, err := parseDependencies(strings.NewReader(input), &Options{Tools: []string{"golang.org/x/tools/cmd/stringer", "golang.org/x/tools/gopls"}})
+	require.NoError(t, err)
+
+	require.Len(t, deps.Direct, 1)
+	require.Equal(t, "github.com/foo/lib", deps.Direct[0].Path)
+	require.Empty(t, deps.Indirect)
+
+	var tools []string
+	for _, dep := range deps.Tools {
+		tools = append(tools, dep.Path)
+	}
+	require.Equal(t, []string{"golang.org/x/tools", "golang.org/x/tools/gopls"}, tools)
+}
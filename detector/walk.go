@@ -0,0 +1,264 @@
+package detector
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/karrick/godirwalk"
+)
+
+type SymlinkPolicy string
+
+const (
+	SymlinkFollow SymlinkPolicy = "follow" // follow symlinks to files and directories
+	SymlinkSkip   SymlinkPolicy = "skip"   // ignore symlinks
+	SymlinkError  SymlinkPolicy = "error"  // fail the detection when a symlink is encountered
+)
+
+// binarySniffLen is the number of leading bytes inspected for NUL bytes, as done by git to detect binary files.
+const binarySniffLen = 8000
+
+var ErrSymlink = errors.New("symlink found in module tree")
+
+// ErrModuleTimeout is returned when the detection of a module takes longer than Options.ModuleTimeout.
+var ErrModuleTimeout = errors.New("licence detection timed out")
+
+func ParseSymlinkPolicy(value string) (SymlinkPolicy, error) {
+	switch p := SymlinkPolicy(value); p {
+	case SymlinkFollow, SymlinkSkip, SymlinkError:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid symlink policy %q: must be one of follow, skip, error", value)
+	}
+}
+
+// walkFunc is called for every entry of the walked tree. When symlinks are followed, mode describes the target of
+// the symlink.
+type walkFunc func(path, name string, mode os.FileMode) error
+
+// walker walks module trees according to the detection options. Unreadable files and directories are skipped and
+// recorded as warnings instead of failing the detection.
+type walker struct {
+	symlinks SymlinkPolicy
+	maxDepth int
+	maxSize  int64
+	sorted   bool
+	ignore   []string
+	timeout  time.Duration
+	deadline time.Time
+	warnings []string
+	trace    func(format string, args ...interface{})
+
+	recordEvidence bool
+	evidence       []FileEvidence
+	allCandidates  bool
+
+	mod       Module
+	onWarning func(mod Module, w Warning)
+}
+
+func newWalker(opts *Options, mod Module) *walker {
+	w := &walker{
+		symlinks: opts.Symlinks,
+		maxDepth: opts.MaxDepth,
+		maxSize:  opts.MaxLicenceSize,
+		sorted:   opts.SortedWalk,
+		ignore:   ignorePatterns(opts.Ignore, mod.Path),
+		trace:    opts.trace,
+
+		recordEvidence: opts.RecordEvidence,
+		// sub-components and the preference patterns may select files below the module root
+		allCandidates: opts.AllCandidates || opts.Subcomponents || len(opts.LicencePreference) > 0,
+
+		mod:       mod,
+		onWarning: opts.OnWarning,
+	}
+	if opts.ModuleTimeout > 0 {
+		w.timeout, w.deadline = opts.ModuleTimeout, time.Now().Add(opts.ModuleTimeout)
+	}
+	return w
+}
+
+// checkDeadline returns ErrModuleTimeout once the module has been walked for longer than the timeout.
+func (w *walker) checkDeadline() error {
+	if !w.deadline.IsZero() && time.Now().After(w.deadline) {
+		return fmt.Errorf("%w after %s", ErrModuleTimeout, w.timeout)
+	}
+	return nil
+}
+
+func (w *walker) warn(path string, err error) {
+	w.addWarning(Warning{Kind: WarningSkippedFile, Path: path, Message: fmt.Sprintf("skipped %s: %v", path, err)})
+}
+
+// addWarning records a warning of the module and delivers it to Options.OnWarning.
+func (w *walker) addWarning(warning Warning) {
+	w.warnings = append(w.warnings, warning.String())
+	w.tracef("%s", warning)
+	w.notify(warning)
+}
+
+// notify delivers a warning to Options.OnWarning without recording it in the results, for findings already visible
+// in them.
+func (w *walker) notify(warning Warning) {
+	if w.onWarning != nil {
+		w.onWarning(w.mod, warning)
+	}
+}
+
+// walk walks the tree rooted at root, applying the symlink policy and skipping the ignored entries. When following
+// symlinks, a directory reached through a symlink is only walked once so that cycles cannot hang the walk.
+func (w *walker) walk(root string, callback walkFunc) error {
+	visited := make(map[string]struct{})
+	if realRoot, err := realPath(root); err == nil {
+		visited[realRoot] = struct{}{}
+	}
+
+	return godirwalk.Walk(root, &godirwalk.Options{
+		Callback: func(osPathName string, dirent *godirwalk.Dirent) error {
+			if err := w.checkDeadline(); err != nil {
+				return err
+			}
+
+			if w.ignored(root, osPathName) {
+				if dirent.IsDir() {
+					return filepath.SkipDir
+				}
+				if isLicenceFileName(dirent.Name()) {
+					w.examine(osPathName, "ignored by the ignore rules")
+				}
+				return nil
+			}
+
+			if !dirent.IsSymlink() {
+				return callback(osPathName, dirent.Name(), dirent.ModeType())
+			}
+
+			switch w.symlinks {
+			case SymlinkSkip:
+				if isLicenceFileName(dirent.Name()) {
+					w.examine(osPathName, "symlink skipped")
+				}
+				return nil
+			case SymlinkError:
+				return fmt.Errorf("%w: %s", ErrSymlink, osPathName)
+			}
+
+			target, err := realPath(osPathName)
+			if err != nil {
+				// dangling symlink
+				return nil
+			}
+
+			fi, err := os.Stat(target)
+			if err != nil {
+				return nil
+			}
+
+			if fi.IsDir() {
+				if _, ok := visited[target]; ok {
+					return filepath.SkipDir
+				}
+				visited[target] = struct{}{}
+			}
+
+			return callback(osPathName, dirent.Name(), fi.Mode()&os.ModeType)
+		},
+		ErrorCallback: func(osPathName string, err error) godirwalk.ErrorAction {
+			if os.IsPermission(err) {
+				w.warn(osPathName, err)
+				return godirwalk.SkipNode
+			}
+			return godirwalk.Halt
+		},
+		FollowSymbolicLinks: w.symlinks == SymlinkFollow,
+		Unsorted:            !w.sorted,
+	})
+}
+
+// stat returns the file info of a directory entry according to the symlink policy. It returns nil if the entry
+// should be ignored.
+func (w *walker) stat(path string) (os.FileInfo, error) {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if fi.Mode()&os.ModeSymlink == 0 {
+		return fi, nil
+	}
+
+	switch w.symlinks {
+	case SymlinkSkip:
+		return nil, nil
+	case SymlinkError:
+		return nil, fmt.Errorf("%w: %s", ErrSymlink, path)
+	}
+
+	fi, err = os.Stat(path)
+	if err != nil {
+		// dangling symlink
+		return nil, nil
+	}
+
+	return fi, nil
+}
+
+// isLicenceCandidate rejects files whose name looks like a licence but which are too large or contain binary data.
+func (w *walker) isLicenceCandidate(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsPermission(err) {
+			w.warn(path, err)
+			w.examine(path, fmt.Sprintf("unreadable: %v", err))
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+
+	if w.maxSize > 0 {
+		fi, err := f.Stat()
+		if err != nil {
+			return false, err
+		}
+
+		if fi.Size() > w.maxSize {
+			w.warn(path, fmt.Errorf("licence candidate is larger than %d bytes", w.maxSize))
+			w.examine(path, fmt.Sprintf("larger than %d bytes", w.maxSize))
+			return false, nil
+		}
+	}
+
+	buf := make([]byte, binarySniffLen)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+
+	switch DetectEncoding(buf[:n]) {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		return true, nil
+	}
+
+	if bytes.IndexByte(buf[:n], 0) >= 0 {
+		w.warn(path, errors.New("licence candidate contains binary data"))
+		w.examine(path, "contains binary data")
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func realPath(path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(resolved)
+}
@@ -0,0 +1,24 @@
+package detector
+
+// WarningKind classifies the non-fatal findings of the detection.
+type WarningKind string
+
+const (
+	WarningSkippedFile   WarningKind = "skipped-file"   // a file could not be read or is unsuitable as a licence
+	WarningTimeout       WarningKind = "timeout"        // the licence was chosen among the files found before the timeout
+	WarningLowConfidence WarningKind = "low-confidence" // the licence file matches no known licence
+	WarningEncoding      WarningKind = "encoding"       // the licence file was transcoded to UTF-8
+	WarningTranslation   WarningKind = "translation"    // the licence text is not in English and needs a manual review
+	WarningParentLicence WarningKind = "parent-licence" // the licence was found in an enclosing directory of the module
+)
+
+// Warning is a non-fatal finding of the detection of a module, delivered to Options.OnWarning.
+type Warning struct {
+	Kind    WarningKind
+	Path    string // file or directory concerned, if any
+	Message string // human-readable description, including the path
+}
+
+func (w Warning) String() string {
+	return w.Message
+}
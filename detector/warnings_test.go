@@ -0,0 +1,33 @@
+package detector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectWarnings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "warnings")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "LICENSE"), []byte("All rights reserved by J\xfcrgen\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "COPYING"), []byte("binary\x00licence"), 0644))
+
+	input := `{"Path": "example.com/warnings", "Version": "v1.0.0", "Dir": "` + filepath.ToSlash(dir) + `"}`
+
+	var got []WarningKind
+	deps, err := DetectWithOptions(strings.NewReader(input), &Options{
+		OnWarning: func(mod Module, w Warning) {
+			require.Equal(t, "example.com/warnings", mod.Path)
+			got = append(got, w.Kind)
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []WarningKind{WarningSkippedFile, WarningEncoding, WarningLowConfidence}, got)
+	require.Len(t, deps.Direct[0].Warnings, 1)
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+const defaultGOPROXY = "https://proxy.golang.org,direct"
+
+var errModuleNotFound = errors.New("module version not found")
+
+// proxyEntry is an element of a GOPROXY list. The next entry is only tried if the module is not found, unless the
+// entry was followed by a pipe, in which case any error falls back to the next entry.
+type proxyEntry struct {
+	url             string
+	fallbackOnError bool
+}
+
+func parseGOPROXY(value string) []proxyEntry {
+	var entries []proxyEntry
+	for value != "" {
+		idx := strings.IndexAny(value, ",|")
+		entry := proxyEntry{url: value}
+		value = ""
+		if idx >= 0 {
+			entry.url, entry.fallbackOnError, value = entry.url[:idx], entry.url[idx] == '|', entry.url[idx+1:]
+		}
+
+		if entry.url = strings.TrimSpace(entry.url); entry.url != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// moduleDownloader fetches modules from the module proxies listed in GOPROXY, verifies them and extracts them to
+// dir. Fetching directly from version control systems is not supported.
+type moduleDownloader struct {
+	proxies []proxyEntry
+	auth    *authenticator
+	sumdb   *checksumDB
+	client  *http.Client
+	dir     string
+}
+
+func newModuleDownloader() (*moduleDownloader, error) {
+	goproxy := os.Getenv("GOPROXY")
+	if goproxy == "" {
+		goproxy = defaultGOPROXY
+	}
+
+	auth, err := newAuthenticator(os.Getenv("GOAUTH"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up authentication: %w", err)
+	}
+
+	dir, err := downloadDir()
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 5 * time.Minute}
+	return &moduleDownloader{
+		proxies: parseGOPROXY(goproxy),
+		auth:    auth,
+		sumdb:   newChecksumDB(client),
+		client:  client,
+		dir:     dir,
+	}, nil
+}
+
+// downloadDir returns the directory the modules downloaded from GOPROXY are extracted to.
+func downloadDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "licence-detector", "mod"), nil
+}
+
+// verifyCached verifies a module version extracted to the module cache rooted at cache using the zip hash recorded
+// when it was downloaded.
+func (d *moduleDownloader) verifyCached(cache, modPath, version string, sums detector.GoSum) (string, error) {
+	hash, err := ioutil.ReadFile(detector.ModuleZipHashFile(cache, modPath, version))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return unverified, nil
+		}
+		return "", err
+	}
+
+	return d.sumdb.verify(modPath, version, strings.TrimSpace(string(hash)), sums)
+}
+
+// download returns the directory holding the sources of the module version and their verification status,
+// downloading them if necessary. Downloaded zips are verified before being extracted.
+func (d *moduleDownloader) download(modPath, version string, sums detector.GoSum) (string, string, error) {
+	dir := detector.ModuleCacheDir(d.dir, modPath, version)
+	if _, err := os.Stat(dir); err == nil {
+		status, err := d.verifyCached(d.dir, modPath, version, sums)
+		return dir, status, err
+	}
+
+	var lastErr error
+	for _, proxy := range d.proxies {
+		switch proxy.url {
+		case "off":
+			return "", "", permanentError{fmt.Errorf("module lookup disabled by GOPROXY=off")}
+		case "direct":
+			return "", "", permanentError{fmt.Errorf("fetching %s@%s from version control is not supported: add a module proxy to GOPROXY", modPath, version)}
+		}
+
+		files, err := d.fetchModule(proxy.url, modPath, version)
+		if err == nil {
+			defer removeFiles(files)
+			status, err := d.extract(files, modPath, version, sums)
+			return dir, status, err
+		}
+
+		lastErr = err
+		if !errors.Is(err, errModuleNotFound) && !proxy.fallbackOnError {
+			return "", "", err
+		}
+	}
+
+	if lastErr == nil {
+		lastErr = permanentError{errors.New("GOPROXY is empty")}
+	}
+	return "", "", lastErr
+}
+
+// moduleFileExts are the extensions of the files of a module version fetched from a module proxy, in the order the go
+// command fetches them: the version metadata, the go.mod file and the sources.
+var moduleFileExts = []string{"info", "mod", "zip"}
+
+// fetchModule fetches the files of the module version from a module proxy to temporary files, by extension.
+func (d *moduleDownloader) fetchModule(proxyURL, modPath, version string) (map[string]string, error) {
+	files := make(map[string]string, len(moduleFileExts))
+	for _, ext := range moduleFileExts {
+		path, err := d.fetchFile(proxyURL, modPath, version, ext)
+		if err != nil {
+			removeFiles(files)
+			return nil, err
+		}
+		files[ext] = path
+	}
+	return files, nil
+}
+
+func removeFiles(files map[string]string) {
+	for _, path := range files {
+		os.Remove(path)
+	}
+}
+
+// extract verifies a downloaded module zip and extracts it to the cache. The zip hash, the version metadata and the
+// go.mod file are stored next to each other as in the Go module cache, so that the download directory can also be
+// used as a GOMODCACHE.
+func (d *moduleDownloader) extract(files map[string]string, modPath, version string, sums detector.GoSum) (string, error) {
+	info, err := ioutil.ReadFile(files["info"])
+	if err != nil {
+		return "", err
+	}
+	var infoVersion struct{ Version string }
+	if err := json.Unmarshal(info, &infoVersion); err != nil || infoVersion.Version != version {
+		return "", permanentError{fmt.Errorf("invalid metadata for %s@%s: %s", modPath, version, info)}
+	}
+
+	hash, err := detector.HashModuleZip(files["zip"])
+	if err != nil {
+		return "", err
+	}
+
+	status, err := d.sumdb.verify(modPath, version, hash, sums)
+	if err != nil {
+		return "", err
+	}
+
+	hashFile := detector.ModuleZipHashFile(d.dir, modPath, version)
+	if err := os.MkdirAll(filepath.Dir(hashFile), 0755); err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(hashFile, []byte(hash+"\n"), 0644); err != nil {
+		return "", err
+	}
+
+	base := strings.TrimSuffix(hashFile, ".ziphash")
+	if err := ioutil.WriteFile(base+".info", info, 0644); err != nil {
+		return "", err
+	}
+	mod, err := ioutil.ReadFile(files["mod"])
+	if err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(base+".mod", mod, 0644); err != nil {
+		return "", err
+	}
+
+	return status, extractModuleZip(files["zip"], modPath+"@"+version, detector.ModuleCacheDir(d.dir, modPath, version))
+}
+
+// fetchFile fetches the file of the module version with the given extension from a module proxy to a temporary file.
+func (d *moduleDownloader) fetchFile(proxyURL, modPath, version, ext string) (string, error) {
+	url := fmt.Sprintf("%s/%s/@v/%s.%s", strings.TrimSuffix(proxyURL, "/"), detector.EscapeModulePath(modPath), detector.EscapeModulePath(version), ext)
+
+	var tmpPath string
+	err := remote.do(func() error {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return permanentError{err}
+		}
+		d.auth.authenticate(req)
+
+		resp, err := d.client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+			return permanentError{fmt.Errorf("%w: %s: %s", errModuleNotFound, url, resp.Status)}
+		}
+		if err := checkResponseStatus(resp); err != nil {
+			return fmt.Errorf("%s: %w", url, err)
+		}
+
+		f, err := ioutil.TempFile("", "module-*."+ext)
+		if err != nil {
+			return permanentError{err}
+		}
+		defer f.Close()
+
+		if _, err := io.Copy(f, resp.Body); err != nil {
+			os.Remove(f.Name())
+			return err
+		}
+
+		tmpPath = f.Name()
+		return nil
+	})
+
+	return tmpPath, err
+}
+
+// extractModuleZip extracts a module zip, whose files are all prefixed with path@version/, to dir. The files are
+// first extracted to a temporary directory so that an interrupted extraction does not leave a partial module.
+func extractModuleZip(zipPath, prefix, dir string) error {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+
+	tmpDir, err := ioutil.TempDir(filepath.Dir(dir), filepath.Base(dir)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		name := strings.TrimPrefix(f.Name, prefix+"/")
+		if cleaned := path.Clean(name); name == f.Name || cleaned != name || cleaned == ".." || strings.HasPrefix(cleaned, "../") || path.IsAbs(cleaned) {
+			return fmt.Errorf("unexpected file %s in module zip", f.Name)
+		}
+
+		if err := extractZipFile(f, filepath.Join(tmpDir, filepath.FromSlash(name))); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(tmpDir, dir)
+}
+
+func extractZipFile(f *zip.File, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
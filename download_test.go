@@ -0,0 +1,309 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+// mkModuleZip returns a zip holding the files, named as they appear in the zip.
+func mkModuleZip(t *testing.T, files ...string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte("contents of " + name))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// mkModuleProxy returns a module proxy serving version v1.0.0 of example.com/m, whose sources are in zipData.
+func mkModuleProxy(zipData []byte) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/example.com/m/@v/v1.0.0.info", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Version":"v1.0.0","Time":"2020-01-01T00:00:00Z"}`))
+	})
+	mux.HandleFunc("/example.com/m/@v/v1.0.0.mod", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("module example.com/m\n"))
+	})
+	mux.HandleFunc("/example.com/m/@v/v1.0.0.zip", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(zipData)
+	})
+	return mux
+}
+
+func TestParseGOPROXY(t *testing.T) {
+	testCases := []struct {
+		value string
+		want  []proxyEntry
+	}{
+		{value: "", want: nil},
+		{value: " off ", want: []proxyEntry{{url: "off"}}},
+		{value: defaultGOPROXY, want: []proxyEntry{{url: "https://proxy.golang.org"}, {url: "direct"}}},
+		{
+			value: "https://a.example.com|https://b.example.com,https://c.example.com|direct",
+			want: []proxyEntry{
+				{url: "https://a.example.com", fallbackOnError: true},
+				{url: "https://b.example.com"},
+				{url: "https://c.example.com", fallbackOnError: true},
+				{url: "direct"},
+			},
+		},
+		{value: "https://a.example.com,,https://b.example.com,", want: []proxyEntry{{url: "https://a.example.com"}, {url: "https://b.example.com"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			require.Equal(t, tc.want, parseGOPROXY(tc.value))
+		})
+	}
+}
+
+func TestDownloadFallback(t *testing.T) {
+	zipData := mkModuleZip(t, "example.com/m@v1.0.0/LICENSE")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/missing/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+	mux.HandleFunc("/gone/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	})
+	mux.HandleFunc("/forbidden/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	})
+	mux.Handle("/ok/", http.StripPrefix("/ok", mkModuleProxy(zipData)))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	testCases := []struct {
+		name    string
+		goproxy string
+		wantErr string
+	}{
+		{name: "Off", goproxy: "off", wantErr: "module lookup disabled by GOPROXY=off"},
+		{name: "Direct", goproxy: "direct", wantErr: "fetching example.com/m@v1.0.0 from version control is not supported"},
+		{name: "Empty", goproxy: "", wantErr: "GOPROXY is empty"},
+		{name: "NotFoundFallsBack", goproxy: srv.URL + "/missing," + srv.URL + "/gone," + srv.URL + "/ok"},
+		{name: "NotFoundFallsBackToOff", goproxy: srv.URL + "/missing,off", wantErr: "module lookup disabled by GOPROXY=off"},
+		{name: "ErrorStopsAtComma", goproxy: srv.URL + "/forbidden," + srv.URL + "/ok", wantErr: "403 Forbidden"},
+		{name: "ErrorFallsBackAtPipe", goproxy: srv.URL + "/forbidden|" + srv.URL + "/ok"},
+		{name: "ErrorFallsBackAtPipeToDirect", goproxy: srv.URL + "/forbidden|direct", wantErr: "from version control is not supported"},
+		{name: "FirstProxyWins", goproxy: srv.URL + "/ok,off"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "download")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			d := &moduleDownloader{proxies: parseGOPROXY(tc.goproxy), auth: &authenticator{}, sumdb: &checksumDB{}, client: srv.Client(), dir: dir}
+			got, status, err := d.download("example.com/m", "v1.0.0", nil)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, detector.ModuleCacheDir(dir, "example.com/m", "v1.0.0"), got)
+			require.Equal(t, unverified, status)
+
+			licence, err := ioutil.ReadFile(filepath.Join(got, "LICENSE"))
+			require.NoError(t, err)
+			require.Equal(t, "contents of example.com/m@v1.0.0/LICENSE", string(licence))
+		})
+	}
+}
+
+func TestDownloadFiles(t *testing.T) {
+	defer func(f *fetcher) { remote = f }(remote)
+
+	zipData := mkModuleZip(t, "example.com/m@v1.0.0/LICENSE")
+	proxy := mkModuleProxy(zipData)
+
+	testCases := []struct {
+		name      string
+		failures  int // number of requests answered with a server error before the proxy recovers
+		status    int
+		retries   int
+		budget    int
+		wantPaths []string
+		wantErr   error
+	}{
+		{
+			name:      "Success",
+			wantPaths: []string{"/example.com/m/@v/v1.0.0.info", "/example.com/m/@v/v1.0.0.mod", "/example.com/m/@v/v1.0.0.zip"},
+		},
+		{
+			name:      "RetryServerError",
+			failures:  2,
+			status:    http.StatusServiceUnavailable,
+			retries:   2,
+			wantPaths: []string{"/example.com/m/@v/v1.0.0.info", "/example.com/m/@v/v1.0.0.info", "/example.com/m/@v/v1.0.0.info", "/example.com/m/@v/v1.0.0.mod", "/example.com/m/@v/v1.0.0.zip"},
+		},
+		{
+			name:      "RetryRateLimit",
+			failures:  1,
+			status:    http.StatusTooManyRequests,
+			retries:   1,
+			wantPaths: []string{"/example.com/m/@v/v1.0.0.info", "/example.com/m/@v/v1.0.0.info", "/example.com/m/@v/v1.0.0.mod", "/example.com/m/@v/v1.0.0.zip"},
+		},
+		{
+			name:      "RetriesExhausted",
+			failures:  3,
+			status:    http.StatusInternalServerError,
+			retries:   2,
+			wantPaths: []string{"/example.com/m/@v/v1.0.0.info", "/example.com/m/@v/v1.0.0.info", "/example.com/m/@v/v1.0.0.info"},
+		},
+		{
+			name:      "BudgetExhausted",
+			budget:    2,
+			wantPaths: []string{"/example.com/m/@v/v1.0.0.info", "/example.com/m/@v/v1.0.0.mod"},
+			wantErr:   errFetchBudgetExhausted,
+		},
+		{
+			name:      "BudgetExhaustedByRetries",
+			failures:  2,
+			status:    http.StatusBadGateway,
+			retries:   5,
+			budget:    2,
+			wantPaths: []string{"/example.com/m/@v/v1.0.0.info", "/example.com/m/@v/v1.0.0.info"},
+			wantErr:   errFetchBudgetExhausted,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			remote = &fetcher{retries: tc.retries, backoff: time.Millisecond, budget: tc.budget}
+
+			var paths []string
+			failures := tc.failures
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.URL.Path)
+				if failures > 0 {
+					failures--
+					http.Error(w, "unavailable", tc.status)
+					return
+				}
+				proxy.ServeHTTP(w, r)
+			}))
+			defer srv.Close()
+
+			dir, err := ioutil.TempDir("", "download")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			d := &moduleDownloader{proxies: parseGOPROXY(srv.URL), auth: &authenticator{}, sumdb: &checksumDB{}, client: srv.Client(), dir: dir}
+			got, _, err := d.download("example.com/m", "v1.0.0", nil)
+			require.Equal(t, tc.wantPaths, paths)
+			if tc.wantErr != nil {
+				require.True(t, errors.Is(err, tc.wantErr), fmt.Sprintf("unexpected error: %v", err))
+				return
+			}
+			if tc.failures > tc.retries {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), fmt.Sprint(tc.status))
+				return
+			}
+			require.NoError(t, err)
+
+			// the metadata and the go.mod file are stored next to the zip hash, as in the Go module cache
+			base := strings.TrimSuffix(detector.ModuleZipHashFile(dir, "example.com/m", "v1.0.0"), ".ziphash")
+			info, err := ioutil.ReadFile(base + ".info")
+			require.NoError(t, err)
+			require.Equal(t, `{"Version":"v1.0.0","Time":"2020-01-01T00:00:00Z"}`, string(info))
+			mod, err := ioutil.ReadFile(base + ".mod")
+			require.NoError(t, err)
+			require.Equal(t, "module example.com/m\n", string(mod))
+			hash, err := ioutil.ReadFile(base + ".ziphash")
+			require.NoError(t, err)
+			require.True(t, strings.HasPrefix(string(hash), "h1:"))
+
+			licence, err := ioutil.ReadFile(filepath.Join(got, "LICENSE"))
+			require.NoError(t, err)
+			require.Equal(t, "contents of example.com/m@v1.0.0/LICENSE", string(licence))
+		})
+	}
+}
+
+func TestDownloadInvalidInfo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Version":"v1.0.1"}`))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "download")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	d := &moduleDownloader{proxies: parseGOPROXY(srv.URL), auth: &authenticator{}, sumdb: &checksumDB{}, client: srv.Client(), dir: dir}
+	_, _, err = d.download("example.com/m", "v1.0.0", nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid metadata for example.com/m@v1.0.0")
+}
+
+func TestExtractModuleZip(t *testing.T) {
+	testCases := []struct {
+		name    string
+		files   []string
+		wantErr bool
+	}{
+		{name: "Valid", files: []string{"example.com/m@v1.0.0/LICENSE", "example.com/m@v1.0.0/sub/dir/COPYING"}},
+		{name: "ParentDirectory", files: []string{"example.com/m@v1.0.0/LICENSE", "example.com/m@v1.0.0/../escaped"}, wantErr: true},
+		{name: "ParentDirectoryWithinPath", files: []string{"example.com/m@v1.0.0/sub/../../escaped"}, wantErr: true},
+		{name: "DotSegment", files: []string{"example.com/m@v1.0.0/./LICENSE"}, wantErr: true},
+		{name: "Absolute", files: []string{"example.com/m@v1.0.0//etc/escaped"}, wantErr: true},
+		{name: "OtherModule", files: []string{"example.com/other@v1.0.0/LICENSE"}, wantErr: true},
+		{name: "OtherVersion", files: []string{"example.com/m@v1.0.1/LICENSE"}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "extract")
+			require.NoError(t, err)
+			defer os.RemoveAll(root)
+
+			zipPath := filepath.Join(root, "m.zip")
+			require.NoError(t, ioutil.WriteFile(zipPath, mkModuleZip(t, tc.files...), 0644))
+			dir := filepath.Join(root, "cache", "example.com", "m@v1.0.0")
+
+			err = extractModuleZip(zipPath, "example.com/m@v1.0.0", dir)
+			if tc.wantErr {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "unexpected file")
+
+				// nothing is left behind, whether in the module directory or next to it
+				entries, err := ioutil.ReadDir(filepath.Dir(dir))
+				require.NoError(t, err)
+				require.Empty(t, entries)
+				_, err = os.Stat(filepath.Join(root, "cache", "escaped"))
+				require.True(t, os.IsNotExist(err))
+				return
+			}
+			require.NoError(t, err)
+
+			for _, name := range tc.files {
+				rel, err := filepath.Rel("example.com/m@v1.0.0", name)
+				require.NoError(t, err)
+				data, err := ioutil.ReadFile(filepath.Join(dir, rel))
+				require.NoError(t, err)
+				require.Equal(t, "contents of "+name, string(data))
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+/* Template functions */
+
+// Excerpt returns the first n characters of the text, cut at a word boundary and followed by an ellipsis, or the
+// whole text if it is not longer. Its arguments are in this order so that it can end a pipeline:
+// {{ licenceContents . | excerpt 200 }}.
+func Excerpt(n int, text string) string {
+	text = strings.TrimSpace(text)
+	runes := []rune(text)
+	if n < 0 || len(runes) <= n {
+		return text
+	}
+
+	cut := n
+	for cut > 0 && !unicode.IsSpace(runes[cut]) {
+		cut--
+	}
+	// a single word longer than n is cut within it
+	if cut == 0 {
+		cut = n
+	}
+
+	return strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace) + "…"
+}
+
+// FirstParagraph returns the first paragraph of the text, paragraphs being separated by blank lines.
+func FirstParagraph(text string) string {
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+
+	var paragraph []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			if len(paragraph) > 0 {
+				break
+			}
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+
+	return strings.Join(paragraph, "\n")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+func init() {
+	subcommands["explain"] = explain
+}
+
+// explain prints how the licence of the module given as argument was detected: the candidate files and their
+// scores, the classification evidence and why the licence file was chosen. Only that module is processed.
+func explain() {
+	if flag.NArg() != 1 {
+		log.Fatal("Usage: explain [-in FILE] MODULE")
+	}
+
+	remote.retries = *fetchRetriesFlag
+	remote.budget = *fetchBudgetFlag
+
+	depInput, err := mkReader(*inFlag)
+	if err != nil {
+		log.Fatalf("Failed to create reader for %s: %v", *inFlag, err)
+	}
+	defer depInput.Close()
+
+	opts, err := detectOptions()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	input, err := convertInput(depInput, *inputFormatFlag)
+	if err != nil {
+		log.Fatalf("Failed to read dependencies from %s: %v", *inFlag, err)
+	}
+
+	e, err := detector.Explain(input, flag.Arg(0), opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := writeExplanation(os.Stdout, e); err != nil {
+		log.Fatalf("Failed to write explanation: %v", err)
+	}
+}
+
+func writeExplanation(w io.Writer, e *detector.Explanation) error {
+	dep := e.Dependency
+	mod := effectiveModule(dep)
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Module  : %s %s\n", dep.Path, dep.Version)
+	if dep.Replace != nil {
+		fmt.Fprintf(&buf, "Replaced: %s %s\n", mod.Path, mod.Version)
+	}
+	fmt.Fprintf(&buf, "Sources : %s\n", mod.Dir)
+
+	buf.WriteString("\nTrace:\n")
+	for _, step := range e.Trace {
+		fmt.Fprintf(&buf, "  %s\n", step)
+	}
+
+	buf.WriteString("\nResult  : ")
+	switch {
+	case dep.Error != nil:
+		fmt.Fprintf(&buf, "%v\n", dep.Error)
+	case len(dep.Licences) == 0:
+		fmt.Fprintf(&buf, "%s from %s (%s)\n", unknownLicence, dep.LicenceFile, dep.Source)
+	default:
+		fmt.Fprintf(&buf, "%s from %s (%s)\n", strings.Join(dep.Licences, " AND "), dep.LicenceFile, dep.Source)
+	}
+
+	if len(dep.Warnings) > 0 {
+		buf.WriteString("\nWarnings:\n")
+		for _, warning := range dep.Warnings {
+			fmt.Fprintf(&buf, "  %s\n", warning)
+		}
+	}
+
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
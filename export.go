@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+const noAssertion = "NOASSERTION"
+
+type exportFunc func(io.Writer, *detector.Dependencies) error
+
+var exporters = map[string]exportFunc{
+	"csv":        exportCSV,
+	"fossa":      exportFossa,
+	"intoto":     exportInToto,
+	"json":       exportJSON,
+	"matrix":     exportMatrix,
+	"pr-comment": exportPRComment,
+	"protobuf":   exportProtobuf,
+	"yaml":       exportYAML,
+}
+
+func exportFormats() []string {
+	formats := make([]string, 0, len(exporters))
+	for f := range exporters {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+func exportDependencies(dependencies *detector.Dependencies, format, outputPath string) error {
+	export, ok := exporters[format]
+	if !ok {
+		return fmt.Errorf("unknown format %q: must be one of notice, %s", format, strings.Join(exportFormats(), ", "))
+	}
+
+	w, cleanup, err := mkWriter(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file %s: %w", outputPath, err)
+	}
+	defer cleanup()
+
+	return export(w, dependencies)
+}
+
+// exportCSV writes a generic dependency inventory in the CSV layout accepted by the Black Duck BOM import.
+func exportCSV(w io.Writer, dependencies *detector.Dependencies) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"Component Name", "Component Version", "License", "Origin", "Dependency Type", "License File"}); err != nil {
+		return err
+	}
+
+	for _, dep := range allDependencies(dependencies) {
+		mod := effectiveModule(dep)
+		depType := "Direct"
+		if dep.Indirect {
+			depType = "Transitive"
+		}
+
+		if err := cw.Write([]string{mod.Path, mod.Version, licenceExpression(dep), "golang", depType, displayPath(dep.LicenceFile)}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+type fossaDeps struct {
+	CustomDependencies []fossaDependency `json:"custom-dependencies"`
+}
+
+type fossaDependency struct {
+	Name     string             `json:"name"`
+	Version  string             `json:"version"`
+	License  string             `json:"license"`
+	Metadata *fossaDepsMetadata `json:"metadata,omitempty"`
+}
+
+type fossaDepsMetadata struct {
+	Description string `json:"description,omitempty"`
+	Homepage    string `json:"homepage,omitempty"`
+}
+
+// exportFossa writes a fossa-deps.json file declaring every dependency as a FOSSA custom dependency.
+func exportFossa(w io.Writer, dependencies *detector.Dependencies) error {
+	deps := fossaDeps{CustomDependencies: []fossaDependency{}}
+	for _, dep := range allDependencies(dependencies) {
+		mod := effectiveModule(dep)
+		deps.CustomDependencies = append(deps.CustomDependencies, fossaDependency{
+			Name:    mod.Path,
+			Version: mod.Version,
+			License: licenceExpression(dep),
+			Metadata: &fossaDepsMetadata{
+				Homepage: "https://pkg.go.dev/" + mod.Path,
+			},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(deps)
+}
+
+func allDependencies(dependencies *detector.Dependencies) []detector.LicenceInfo {
+	all := make([]detector.LicenceInfo, 0, len(dependencies.Direct)+len(dependencies.Indirect)+len(dependencies.Tools))
+	all = append(all, dependencies.Direct...)
+	all = append(all, dependencies.Indirect...)
+	return append(all, dependencies.Tools...)
+}
+
+// effectiveModule returns the module that is actually built, taking replace directives into account.
+func effectiveModule(dep detector.LicenceInfo) detector.Module {
+	if dep.Replace != nil {
+		return *dep.Replace
+	}
+	return dep.Module
+}
+
+// licenceExpression returns the SPDX licence expression declared by the module, or the conjunction of its licences
+// if it declared none.
+func licenceExpression(dep detector.LicenceInfo) string {
+	if dep.LicenceExpression != "" {
+		return dep.LicenceExpression
+	}
+	if len(dep.Licences) == 0 {
+		return noAssertion
+	}
+	return strings.Join(dep.Licences, " AND ")
+}
+
+func displayPath(path string) string {
+	return strings.Replace(path, goModCache, "$GOMODCACHE", -1)
+}
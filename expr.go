@@ -0,0 +1,508 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The policy rules are written in a subset of CEL (https://github.com/google/cel-spec) evaluated against the fields of
+// a dependency:
+//
+//	literals     "string", 'string', true, false, 42, ["a", "b"]
+//	operators    !, &&, ||, ==, !=, <, <=, >, >=, in
+//	functions    size(x), s.startsWith(prefix), s.endsWith(suffix), s.contains(sub), s.matches(regexp)
+//
+// Expressions are type-checked when the policy is loaded so that mistakes are reported before any detection.
+
+type exprType int
+
+const (
+	typeBool exprType = iota
+	typeInt
+	typeString
+	typeList
+)
+
+func (t exprType) String() string {
+	return [...]string{"bool", "int", "string", "list"}[t]
+}
+
+// exprEnv holds the values of the variables an expression is evaluated with.
+type exprEnv map[string]interface{}
+
+// expr is a type-checked expression.
+type expr struct {
+	source string
+	root   exprNode
+}
+
+type exprNode interface {
+	typ() exprType
+	eval(env exprEnv) interface{}
+}
+
+// compileExpr parses and type-checks a boolean expression over the variables of the given types.
+func compileExpr(source string, vars map[string]exprType) (*expr, error) {
+	tokens, err := tokenizeExpr(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens, vars: vars}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.peek().text, p.peek().pos)
+	}
+	if root.typ() != typeBool {
+		return nil, fmt.Errorf("expression is of type %s, not bool", root.typ())
+	}
+
+	return &expr{source: source, root: root}, nil
+}
+
+func (e *expr) match(env exprEnv) bool {
+	return e.root.eval(env).(bool)
+}
+
+/* Lexer */
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenInt
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string // unquoted value of string literals
+	pos  int
+}
+
+var exprOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "!", "<", ">", "(", ")", "[", "]", ",", "."}
+
+func tokenizeExpr(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(s) && s[end] != s[i] {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			quoted := s[i : end+1]
+			if c == '\'' {
+				quoted = `"` + strings.Replace(strings.Replace(s[i+1:end], `"`, `\"`, -1), `\'`, `'`, -1) + `"`
+			}
+			text, err := strconv.Unquote(quoted)
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at offset %d: %w", i, err)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, pos: i})
+			i = end + 1
+		case unicode.IsDigit(c):
+			end := i
+			for end < len(s) && unicode.IsDigit(rune(s[end])) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenInt, text: s[i:end], pos: i})
+			i = end
+		case unicode.IsLetter(c) || c == '_':
+			end := i
+			for end < len(s) && (unicode.IsLetter(rune(s[end])) || unicode.IsDigit(rune(s[end])) || s[end] == '_') {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: s[i:end], pos: i})
+			i = end
+		default:
+			op := ""
+			for _, o := range exprOperators {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, text: "end of expression", pos: len(s)}), nil
+}
+
+/* Parser */
+
+type exprParser struct {
+	tokens []token
+	pos    int
+	vars   map[string]exprType
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokenOp && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) expect(op string) error {
+	if !p.accept(op) {
+		return fmt.Errorf("expected %q at offset %d, got %q", op, p.peek().pos, p.peek().text)
+	}
+	return nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = mkLogical("||", left, right); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseRelation()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseRelation()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = mkLogical("&&", left, right); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseRelation() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	op := t.text
+	switch {
+	case t.kind == tokenOp && (op == "==" || op == "!=" || op == "<" || op == "<=" || op == ">" || op == ">="):
+	case t.kind == tokenIdent && op == "in":
+	default:
+		return left, nil
+	}
+	p.next()
+
+	right, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return mkComparison(op, left, right)
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if operand.typ() != typeBool {
+			return nil, fmt.Errorf("operator ! requires a bool, got %s", operand.typ())
+		}
+		return notNode{operand}, nil
+	}
+	return p.parseMember()
+}
+
+func (p *exprParser) parseMember() (exprNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept(".") {
+		name := p.next()
+		if name.kind != tokenIdent {
+			return nil, fmt.Errorf("expected a function name at offset %d", name.pos)
+		}
+		args, err := p.parseArgs()
+		if err != nil {
+			return nil, err
+		}
+		if node, err = mkCall(name.text, append([]exprNode{node}, args...)); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+func (p *exprParser) parseArgs() ([]exprNode, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var args []exprNode
+	for !p.accept(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return literalNode{t.text, typeString}, nil
+	case tokenInt:
+		n, err := strconv.Atoi(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer at offset %d: %w", t.pos, err)
+		}
+		return literalNode{n, typeInt}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true", "false":
+			return literalNode{t.text == "true", typeBool}, nil
+		case "size":
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			return mkCall("size", args)
+		}
+		typ, ok := p.vars[t.text]
+		if !ok {
+			return nil, fmt.Errorf("undeclared reference to %q at offset %d", t.text, t.pos)
+		}
+		return varNode{t.text, typ}, nil
+	case tokenOp:
+		switch t.text {
+		case "(":
+			node, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return node, p.expect(")")
+		case "[":
+			var items []string
+			for !p.accept("]") {
+				if len(items) > 0 {
+					if err := p.expect(","); err != nil {
+						return nil, err
+					}
+				}
+				item := p.next()
+				if item.kind != tokenString {
+					return nil, fmt.Errorf("list items must be string literals at offset %d", item.pos)
+				}
+				items = append(items, item.text)
+			}
+			return literalNode{items, typeList}, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+}
+
+/* Nodes */
+
+type literalNode struct {
+	value interface{}
+	t     exprType
+}
+
+func (n literalNode) typ() exprType                { return n.t }
+func (n literalNode) eval(env exprEnv) interface{} { return n.value }
+
+type varNode struct {
+	name string
+	t    exprType
+}
+
+func (n varNode) typ() exprType                { return n.t }
+func (n varNode) eval(env exprEnv) interface{} { return env[n.name] }
+
+type notNode struct {
+	operand exprNode
+}
+
+func (n notNode) typ() exprType                { return typeBool }
+func (n notNode) eval(env exprEnv) interface{} { return !n.operand.eval(env).(bool) }
+
+type funcNode struct {
+	t    exprType
+	args []exprNode
+	fn   func(args []interface{}) interface{}
+}
+
+func (n funcNode) typ() exprType { return n.t }
+
+func (n funcNode) eval(env exprEnv) interface{} {
+	args := make([]interface{}, len(n.args))
+	for i, a := range n.args {
+		args[i] = a.eval(env)
+	}
+	return n.fn(args)
+}
+
+// logicalNode evaluates its right operand only if needed.
+type logicalNode struct {
+	and         bool
+	left, right exprNode
+}
+
+func (n logicalNode) typ() exprType { return typeBool }
+
+func (n logicalNode) eval(env exprEnv) interface{} {
+	if n.left.eval(env).(bool) != n.and {
+		return !n.and
+	}
+	return n.right.eval(env).(bool)
+}
+
+func mkLogical(op string, left, right exprNode) (exprNode, error) {
+	if left.typ() != typeBool || right.typ() != typeBool {
+		return nil, fmt.Errorf("operator %s requires bools, got %s and %s", op, left.typ(), right.typ())
+	}
+	return logicalNode{and: op == "&&", left: left, right: right}, nil
+}
+
+func mkComparison(op string, left, right exprNode) (exprNode, error) {
+	args := []exprNode{left, right}
+	switch op {
+	case "in":
+		if right.typ() != typeList || left.typ() != typeString {
+			return nil, fmt.Errorf("operator in requires a string and a list, got %s and %s", left.typ(), right.typ())
+		}
+		return funcNode{t: typeBool, args: args, fn: func(a []interface{}) interface{} {
+			for _, item := range a[1].([]string) {
+				if item == a[0].(string) {
+					return true
+				}
+			}
+			return false
+		}}, nil
+	case "==", "!=":
+		if left.typ() != right.typ() || left.typ() == typeList {
+			return nil, fmt.Errorf("operator %s requires operands of the same scalar type, got %s and %s", op, left.typ(), right.typ())
+		}
+		return funcNode{t: typeBool, args: args, fn: func(a []interface{}) interface{} {
+			return (a[0] == a[1]) == (op == "==")
+		}}, nil
+	default:
+		if left.typ() != typeInt || right.typ() != typeInt {
+			return nil, fmt.Errorf("operator %s requires ints, got %s and %s", op, left.typ(), right.typ())
+		}
+		return funcNode{t: typeBool, args: args, fn: func(a []interface{}) interface{} {
+			l, r := a[0].(int), a[1].(int)
+			switch op {
+			case "<":
+				return l < r
+			case "<=":
+				return l <= r
+			case ">":
+				return l > r
+			default:
+				return l >= r
+			}
+		}}, nil
+	}
+}
+
+// stringFuncs are the functions called on strings, as in path.startsWith("golang.org/").
+var stringFuncs = map[string]func(s, arg string) bool{
+	"startsWith": strings.HasPrefix,
+	"endsWith":   strings.HasSuffix,
+	"contains":   strings.Contains,
+}
+
+func mkCall(name string, args []exprNode) (exprNode, error) {
+	if name == "size" {
+		if len(args) != 1 || (args[0].typ() != typeString && args[0].typ() != typeList) {
+			return nil, fmt.Errorf("size requires a string or a list")
+		}
+		return funcNode{t: typeInt, args: args, fn: func(a []interface{}) interface{} {
+			if l, ok := a[0].([]string); ok {
+				return len(l)
+			}
+			return len(a[0].(string))
+		}}, nil
+	}
+
+	if len(args) != 2 || args[0].typ() != typeString || args[1].typ() != typeString {
+		return nil, fmt.Errorf("%s requires a string receiver and a string argument", name)
+	}
+
+	if name == "matches" {
+		// the pattern is compiled once, so it must be a literal
+		lit, ok := args[1].(literalNode)
+		if !ok {
+			return nil, fmt.Errorf("matches requires a literal pattern")
+		}
+		re, err := regexp.Compile(lit.value.(string))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		return funcNode{t: typeBool, args: args[:1], fn: func(a []interface{}) interface{} {
+			return re.MatchString(a[0].(string))
+		}}, nil
+	}
+
+	fn, ok := stringFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+	return funcNode{t: typeBool, args: args, fn: func(a []interface{}) interface{} {
+		return fn(a[0].(string), a[1].(string))
+	}}, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpr(t *testing.T) {
+	vars := map[string]exprType{"path": typeString, "licences": typeList, "direct": typeBool}
+	env := exprEnv{"path": "golang.org/x/net", "licences": []string{"BSD-3-Clause"}, "direct": false}
+
+	testCases := []struct {
+		expr string
+		want bool
+	}{
+		{expr: `path == "golang.org/x/net"`, want: true},
+		{expr: `path != 'golang.org/x/net'`, want: false},
+		{expr: `path.startsWith("golang.org/") && !direct`, want: true},
+		{expr: `direct || path.endsWith("/net")`, want: true},
+		{expr: `path.contains("x/") && path.matches("^golang\\.org/x/[a-z]+$")`, want: true},
+		{expr: `"BSD-3-Clause" in licences`, want: true},
+		{expr: `path in ["a", "b"]`, want: false},
+		{expr: `size(licences) == 1 && size(path) >= 16`, want: true},
+		{expr: `!(direct || size(licences) < 1)`, want: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			e, err := compileExpr(tc.expr, vars)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, e.match(env))
+		})
+	}
+}
+
+func TestExprPrecedence(t *testing.T) {
+	vars := map[string]exprType{"a": typeBool, "b": typeBool, "c": typeBool}
+
+	testCases := []struct {
+		expr string
+		want func(a, b, c bool) bool
+	}{
+		{expr: `a || b && c`, want: func(a, b, c bool) bool { return a || (b && c) }},
+		{expr: `a && b || c`, want: func(a, b, c bool) bool { return (a && b) || c }},
+		{expr: `!a && b`, want: func(a, b, c bool) bool { return !a && b }},
+		{expr: `!(a && b)`, want: func(a, b, c bool) bool { return !(a && b) }},
+		{expr: `a == b || c`, want: func(a, b, c bool) bool { return a == b || c }},
+		{expr: `a || b == c`, want: func(a, b, c bool) bool { return a || b == c }},
+		{expr: `!!a`, want: func(a, b, c bool) bool { return a }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			e, err := compileExpr(tc.expr, vars)
+			require.NoError(t, err)
+			for i := 0; i < 8; i++ {
+				a, b, c := i&4 != 0, i&2 != 0, i&1 != 0
+				require.Equal(t, tc.want(a, b, c), e.match(exprEnv{"a": a, "b": b, "c": c}), fmt.Sprintf("a=%t b=%t c=%t", a, b, c))
+			}
+		})
+	}
+}
+
+func TestExprErrors(t *testing.T) {
+	vars := map[string]exprType{"path": typeString, "licences": typeList, "direct": typeBool}
+
+	testCases := []struct {
+		name    string
+		expr    string
+		wantErr string
+	}{
+		// syntax errors
+		{name: "MissingOperand", expr: `path ==`, wantErr: `unexpected "end of expression" at offset 7`},
+		{name: "UnclosedParenthesis", expr: `(direct`, wantErr: `expected ")" at offset 7`},
+		{name: "UnterminatedString", expr: `path == "golang.org`, wantErr: "unterminated string at offset 8"},
+		{name: "UnexpectedCharacter", expr: `path # "a"`, wantErr: `unexpected character '#' at offset 5`},
+		{name: "TrailingTokens", expr: `direct direct`, wantErr: `unexpected "direct" at offset 7`},
+		{name: "ChainedComparison", expr: `size(path) < 1 < 2`, wantErr: `unexpected "<" at offset 15`},
+		{name: "MissingFunctionName", expr: `path.("a")`, wantErr: "expected a function name at offset 5"},
+		{name: "MissingComma", expr: `path in ["a" "b"]`, wantErr: `expected "," at offset 13`},
+		{name: "NonLiteralListItem", expr: `path in ["a", path]`, wantErr: "list items must be string literals at offset 14"},
+		{name: "Empty", expr: ``, wantErr: `unexpected "end of expression" at offset 0`},
+
+		// unknown identifiers
+		{name: "UndeclaredVariable", expr: `licence == "MIT"`, wantErr: `undeclared reference to "licence" at offset 0`},
+		{name: "UnknownFunction", expr: `path.hasPrefix("a")`, wantErr: `unknown function "hasPrefix"`},
+
+		// type mismatches
+		{name: "EqualityMismatch", expr: `path == 1`, wantErr: "operator == requires operands of the same scalar type, got string and int"},
+		{name: "ListEquality", expr: `licences != ["MIT"]`, wantErr: "operator != requires operands of the same scalar type, got list and list"},
+		{name: "LogicalOperand", expr: `direct && path`, wantErr: "operator && requires bools, got bool and string"},
+		{name: "NegatedString", expr: `!path`, wantErr: "operator ! requires a bool, got string"},
+		{name: "OrderedStrings", expr: `path < "b"`, wantErr: "operator < requires ints, got string and string"},
+		{name: "InList", expr: `licences in ["MIT"]`, wantErr: "operator in requires a string and a list, got list and list"},
+		{name: "SizeOfBool", expr: `size(direct) == 0`, wantErr: "size requires a string or a list"},
+		{name: "StringFunctionArgument", expr: `path.startsWith(1)`, wantErr: "startsWith requires a string receiver and a string argument"},
+		{name: "PatternVariable", expr: `path.matches(path)`, wantErr: "matches requires a literal pattern"},
+		{name: "InvalidPattern", expr: `path.matches("(")`, wantErr: "invalid pattern"},
+
+		// non-boolean results
+		{name: "StringResult", expr: `path`, wantErr: "expression is of type string, not bool"},
+		{name: "IntResult", expr: `size(licences)`, wantErr: "expression is of type int, not bool"},
+		{name: "ListResult", expr: `["MIT"]`, wantErr: "expression is of type list, not bool"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := compileExpr(tc.expr, vars)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var errFetchBudgetExhausted = errors.New("remote request budget exhausted")
+
+// permanentError marks a failure that retrying cannot fix, such as a missing module version.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+// fetcher applies the retry policy and the global request budget to every remote request made by the tool, so that
+// large runs do not get rate limited by the module proxy or the artifact servers.
+type fetcher struct {
+	retries int
+	backoff time.Duration
+	budget  int // maximum number of requests, including retries (0 means unlimited)
+
+	mu   sync.Mutex
+	used int
+}
+
+var remote = &fetcher{backoff: time.Second}
+
+// do calls fn until it succeeds, fails permanently or the retries are exhausted, doubling the delay between
+// attempts.
+func (f *fetcher) do(fn func() error) error {
+	delay := f.backoff
+	for attempt := 0; ; attempt++ {
+		if err := f.take(); err != nil {
+			return err
+		}
+
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		var perm permanentError
+		if errors.As(err, &perm) || attempt >= f.retries {
+			return err
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func (f *fetcher) take() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.budget > 0 && f.used >= f.budget {
+		return errFetchBudgetExhausted
+	}
+	f.used++
+	return nil
+}
+
+// checkResponseStatus returns an error for unsuccessful responses. Only rate limiting and server errors are worth
+// retrying.
+func checkResponseStatus(resp *http.Response) error {
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	default:
+		return permanentError{fmt.Errorf("unexpected response status: %s", resp.Status)}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetcherDo(t *testing.T) {
+	errTransient := errors.New("transient")
+	errFatal := errors.New("fatal")
+
+	testCases := []struct {
+		name      string
+		retries   int
+		budget    int
+		errs      []error // errors returned by the successive attempts, the attempts past the end succeeding
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "Success", retries: 2, wantCalls: 1},
+		{name: "RetryThenSuccess", retries: 2, errs: []error{errTransient, errTransient}, wantCalls: 3},
+		{name: "RetriesExhausted", retries: 2, errs: []error{errTransient, errTransient, errTransient}, wantCalls: 3, wantErr: errTransient},
+		{name: "NoRetries", errs: []error{errTransient}, wantCalls: 1, wantErr: errTransient},
+		{name: "Permanent", retries: 2, errs: []error{permanentError{errFatal}}, wantCalls: 1, wantErr: errFatal},
+		{name: "PermanentAfterRetry", retries: 2, errs: []error{errTransient, permanentError{errFatal}}, wantCalls: 2, wantErr: errFatal},
+		{name: "BudgetExhausted", retries: 5, budget: 2, errs: []error{errTransient, errTransient, errTransient}, wantCalls: 2, wantErr: errFetchBudgetExhausted},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := &fetcher{retries: tc.retries, backoff: time.Millisecond, budget: tc.budget}
+
+			calls := 0
+			err := f.do(func() error {
+				calls++
+				if calls <= len(tc.errs) {
+					return tc.errs[calls-1]
+				}
+				return nil
+			})
+			require.Equal(t, tc.wantCalls, calls)
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.True(t, errors.Is(err, tc.wantErr))
+		})
+	}
+}
+
+func TestFetcherBudgetShared(t *testing.T) {
+	f := &fetcher{budget: 3}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, f.do(func() error { return nil }))
+	}
+
+	called := false
+	err := f.do(func() error {
+		called = true
+		return nil
+	})
+	require.Equal(t, errFetchBudgetExhausted, err)
+	require.False(t, called)
+}
+
+func TestCheckResponseStatus(t *testing.T) {
+	testCases := []struct {
+		status        int
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{status: http.StatusOK},
+		{status: http.StatusTooManyRequests, wantErr: true},
+		{status: http.StatusInternalServerError, wantErr: true},
+		{status: http.StatusServiceUnavailable, wantErr: true},
+		{status: http.StatusForbidden, wantErr: true, wantPermanent: true},
+		{status: http.StatusNotFound, wantErr: true, wantPermanent: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			err := checkResponseStatus(&http.Response{StatusCode: tc.status, Status: http.StatusText(tc.status)})
+			if !tc.wantErr {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			var perm permanentError
+			require.Equal(t, tc.wantPermanent, errors.As(err, &perm))
+		})
+	}
+}
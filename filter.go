@@ -0,0 +1,63 @@
+package main
+
+import (
+	"path"
+	"strings"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// The filter functions take the dependency list as their last argument so that they can be used in pipelines:
+//
+//	{{ range .Direct | filterByLicence "GPL-*" }}
+
+/* Template functions */
+
+// FilterByLicence returns the dependencies declaring a licence that matches the given pattern, as accepted by
+// path.Match.
+func FilterByLicence(pattern string, deps []detector.LicenceInfo) ([]detector.LicenceInfo, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	return filterDependencies(deps, func(dep detector.LicenceInfo) bool {
+		for _, l := range dep.Licences {
+			if ok, _ := path.Match(pattern, l); ok {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// FilterByPrefix returns the dependencies whose module path is the given prefix or lies under it. The prefix matches
+// whole path elements: github.com/elastic matches github.com/elastic/go-ucfg but not github.com/elasticsearch/x.
+func FilterByPrefix(prefix string, deps []detector.LicenceInfo) []detector.LicenceInfo {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return filterDependencies(deps, func(dep detector.LicenceInfo) bool {
+		return prefix == "" || dep.Path == prefix || strings.HasPrefix(dep.Path, prefix+"/")
+	})
+}
+
+// Exclude returns the dependencies that are not in the excluded list, typically the result of another filter.
+func Exclude(excluded, deps []detector.LicenceInfo) []detector.LicenceInfo {
+	paths := make(map[string]struct{}, len(excluded))
+	for _, dep := range excluded {
+		paths[dep.Path] = struct{}{}
+	}
+
+	return filterDependencies(deps, func(dep detector.LicenceInfo) bool {
+		_, ok := paths[dep.Path]
+		return !ok
+	})
+}
+
+func filterDependencies(deps []detector.LicenceInfo, keep func(detector.LicenceInfo) bool) []detector.LicenceInfo {
+	var filtered []detector.LicenceInfo
+	for _, dep := range deps {
+		if keep(dep) {
+			filtered = append(filtered, dep)
+		}
+	}
+	return filtered
+}
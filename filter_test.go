@@ -0,0 +1,137 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func mkFilterDeps() []detector.LicenceInfo {
+	return []detector.LicenceInfo{
+		mkSortDep("github.com/elastic/go-ucfg", "v0.8.0", "Apache-2.0"),
+		mkSortDep("github.com/elasticsearch/x", "v1.0.0", "GPL-2.0-only"),
+		mkSortDep("github.com/elastic", "v1.0.0", "MIT"),
+		mkSortDep("gopkg.in/yaml.v2", "v2.3.0", "Apache-2.0", "MIT"),
+		mkSortDep("github.com/foo/gpl", "v1.0.0", "LGPL-3.0-or-later", "GPL-3.0-only"),
+		mkSortDep("k8s.io/api", "v0.18.0"),
+	}
+}
+
+func filteredPaths(deps []detector.LicenceInfo) []string {
+	var paths []string
+	for _, dep := range deps {
+		paths = append(paths, dep.Path)
+	}
+	return paths
+}
+
+func TestFilterByLicence(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		want    []string
+		wantErr bool
+	}{
+		{pattern: "MIT", want: []string{"github.com/elastic", "gopkg.in/yaml.v2"}},
+		{pattern: "GPL-*", want: []string{"github.com/elasticsearch/x", "github.com/foo/gpl"}},
+		{pattern: "*GPL-*", want: []string{"github.com/elasticsearch/x", "github.com/foo/gpl"}},
+		{pattern: "Apache-?.0", want: []string{"github.com/elastic/go-ucfg", "gopkg.in/yaml.v2"}},
+		{pattern: "*", want: []string{"github.com/elastic/go-ucfg", "github.com/elasticsearch/x", "github.com/elastic", "gopkg.in/yaml.v2", "github.com/foo/gpl"}},
+		// the pattern must match the whole identifier
+		{pattern: "Apache", want: nil},
+		{pattern: "mit", want: nil},
+		{pattern: "", want: nil},
+		{pattern: "[", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern, func(t *testing.T) {
+			have, err := FilterByLicence(tc.pattern, mkFilterDeps())
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, filteredPaths(have))
+		})
+	}
+}
+
+func TestFilterByLicenceInvalidPatternWithoutDependencies(t *testing.T) {
+	_, err := FilterByLicence("[", nil)
+	require.Error(t, err)
+}
+
+func TestFilterByPrefix(t *testing.T) {
+	testCases := []struct {
+		name   string
+		prefix string
+		want   []string
+	}{
+		{
+			name:   "Org",
+			prefix: "github.com/elastic",
+			want:   []string{"github.com/elastic/go-ucfg", "github.com/elastic"},
+		},
+		{
+			name:   "TrailingSlash",
+			prefix: "github.com/elastic/",
+			want:   []string{"github.com/elastic/go-ucfg", "github.com/elastic"},
+		},
+		{
+			name:   "PartialSegment",
+			prefix: "github.com/elas",
+			want:   nil,
+		},
+		{
+			name:   "PartialModulePath",
+			prefix: "gopkg.in/yaml",
+			want:   nil,
+		},
+		{
+			name:   "Host",
+			prefix: "github.com",
+			want:   []string{"github.com/elastic/go-ucfg", "github.com/elasticsearch/x", "github.com/elastic", "github.com/foo/gpl"},
+		},
+		{
+			name:   "ModulePath",
+			prefix: "k8s.io/api",
+			want:   []string{"k8s.io/api"},
+		},
+		{
+			name:   "Empty",
+			prefix: "",
+			want:   []string{"github.com/elastic/go-ucfg", "github.com/elasticsearch/x", "github.com/elastic", "gopkg.in/yaml.v2", "github.com/foo/gpl", "k8s.io/api"},
+		},
+		{
+			name:   "NoMatch",
+			prefix: "golang.org/x",
+			want:   nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, filteredPaths(FilterByPrefix(tc.prefix, mkFilterDeps())))
+		})
+	}
+}
+
+func TestExclude(t *testing.T) {
+	deps := mkFilterDeps()
+
+	copyleft, err := FilterByLicence("*GPL-*", deps)
+	require.NoError(t, err)
+	require.Equal(t, []string{"github.com/elastic/go-ucfg", "github.com/elastic", "gopkg.in/yaml.v2", "k8s.io/api"},
+		filteredPaths(Exclude(copyleft, deps)))
+
+	// exclusion is by module path, whatever the version
+	excluded := []detector.LicenceInfo{mkSortDep("k8s.io/api", "v0.19.0")}
+	require.Equal(t, []string{"github.com/elastic/go-ucfg", "github.com/elasticsearch/x", "github.com/elastic", "gopkg.in/yaml.v2", "github.com/foo/gpl"},
+		filteredPaths(Exclude(excluded, deps)))
+
+	require.Equal(t, filteredPaths(deps), filteredPaths(Exclude(nil, deps)))
+	require.Nil(t, Exclude(deps, deps))
+	require.Nil(t, Exclude(copyleft, nil))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"strconv"
+)
+
+func init() {
+	subcommands["generate"] = generate
+}
+
+// generate writes a Go source file embedding the notice, or the gzip-compressed JSON report with -format json, so that
+// applications can serve their licence information at runtime. It is meant to be run by go generate, whose GOPACKAGE
+// environment variable names the package of the file:
+//
+//	//go:generate sh -c "go list -m -json all | licence-detector generate -out notice_gen.go"
+func generate() {
+	if *formatFlag != "notice" && *formatFlag != "json" {
+		log.Fatalf("Invalid -format %q: generate supports notice and json", *formatFlag)
+	}
+
+	dependencies, err := detect(mkReader)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var name, doc string
+	var data []byte
+	switch *formatFlag {
+	case "notice":
+		tmpl, err := loadTemplate(*templateFlag, *presetFlag)
+		if err != nil {
+			log.Fatalf("Failed to load template: %v", err)
+		}
+		sorted, err := sortedDependencies(dependencies, *sortFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if data, err = executeTemplate(tmpl, sorted); err != nil {
+			log.Fatal(err)
+		}
+		name, doc = "Notice", "Notice is the notice of the third party dependencies."
+	case "json":
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if err := exportJSON(zw, dependencies); err != nil {
+			log.Fatalf("Failed to export dependencies: %v", err)
+		}
+		if err := zw.Close(); err != nil {
+			log.Fatalf("Failed to compress report: %v", err)
+		}
+		data = buf.Bytes()
+		name, doc = "Report", "Report is the gzip-compressed JSON report of the third party dependencies."
+	}
+
+	pkg := os.Getenv("GOPACKAGE")
+	if pkg == "" {
+		pkg = "main"
+	}
+
+	src, err := generateSource(pkg, name, doc, data, *formatFlag == "notice")
+	if err != nil {
+		log.Fatalf("Failed to generate source: %v", err)
+	}
+
+	if err := writeOutput(*outFlag, src); err != nil {
+		log.Fatalf("Failed to write %s: %v", *outFlag, err)
+	}
+}
+
+// generateSource returns the formatted source of a Go file of the package declaring the data as a string constant if
+// text is set, or as a byte slice variable otherwise.
+func generateSource(pkg, name, doc string, data []byte, text bool) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by licence-detector generate; DO NOT EDIT.\n\npackage %s\n\n// %s\n", pkg, doc)
+	if text {
+		fmt.Fprintf(&buf, "const %s = %s\n", name, strconv.Quote(string(data)))
+	} else {
+		fmt.Fprintf(&buf, "var %s = []byte(%s)\n", name, strconv.Quote(string(data)))
+	}
+
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// codeHosts are hosts whose module paths are namespaced by the owning organisation or user.
+var codeHosts = map[string]struct{}{
+	"bitbucket.org": {},
+	"github.com":    {},
+	"gitlab.com":    {},
+	"golang.org":    {},
+}
+
+// DependencyGroup holds the dependencies of one organisation, project or licence.
+type DependencyGroup struct {
+	Name         string
+	Dependencies []detector.LicenceInfo
+}
+
+/* Template functions */
+
+// Org returns the organisation owning a module, derived from its path: github.com/elastic/go-ucfg belongs to
+// github.com/elastic and k8s.io/api to k8s.io.
+func Org(dep detector.LicenceInfo) string {
+	parts := strings.SplitN(dep.Path, "/", 3)
+	if _, ok := codeHosts[parts[0]]; ok && len(parts) > 2 {
+		return parts[0] + "/" + parts[1]
+	}
+	return parts[0]
+}
+
+// Project returns the module path of the dependency without its major version suffix, which identifies the upstream
+// project across major versions.
+func Project(dep detector.LicenceInfo) string {
+	return detector.ModulePathBase(dep.Path)
+}
+
+// RepoURL returns the URL of the repository of the dependency, derived from its path for the code hosts and
+// otherwise pointing to the module path, which serves the go-import metadata.
+func RepoURL(dep detector.LicenceInfo) string {
+	parts := strings.SplitN(Project(dep), "/", 4)
+	if _, ok := codeHosts[parts[0]]; ok {
+		if len(parts) > 3 {
+			parts = parts[:3]
+		}
+		return "https://" + strings.Join(parts, "/")
+	}
+	// the major version is part of the import path on other hosts, such as gopkg.in/yaml.v2
+	return "https://" + dep.Path
+}
+
+// Upstream returns the module path of the original project of a dependency replaced by a fork, or an empty string if
+// the dependency is not a fork.
+func Upstream(dep detector.LicenceInfo) string {
+	if !dep.IsFork() {
+		return ""
+	}
+	return dep.Path
+}
+
+// GroupByOrg groups the dependencies by organisation. Groups are sorted by name and keep the order of the
+// dependencies within them.
+func GroupByOrg(deps []detector.LicenceInfo) []DependencyGroup {
+	return groupDependencies(deps, Org)
+}
+
+// GroupByProject groups the dependencies by upstream project, so that the major versions of a module are grouped
+// together. Groups are sorted by name and keep the order of the dependencies within them.
+func GroupByProject(deps []detector.LicenceInfo) []DependencyGroup {
+	return groupDependencies(deps, Project)
+}
+
+// GroupByFamily groups the dependencies by licence family, such as BSD for both BSD-2-Clause and BSD-3-Clause. Groups
+// are sorted by name and keep the order of the dependencies within them.
+func GroupByFamily(deps []detector.LicenceInfo) []DependencyGroup {
+	return groupDependencies(deps, LicenceFamily)
+}
+
+// LicenceFamily returns the families of the licences of the dependency, joined with AND, or "Unknown" if no licence
+// was detected.
+func LicenceFamily(dep detector.LicenceInfo) string {
+	if len(dep.Licences) == 0 {
+		return "Unknown"
+	}
+	return strings.Join(licenceFamilies(dep.Licences), " AND ")
+}
+
+// licenceFamilies returns the distinct families of the licences, in the order of the licences.
+func licenceFamilies(licences []string) []string {
+	var families []string
+	for _, l := range licences {
+		families = appendUnique(families, detector.LicenceFamily(l))
+	}
+	return families
+}
+
+// GroupByLicence groups the dependencies by licence expression, dependencies without a detected licence being grouped
+// under "Unknown". Groups are sorted by name and keep the order of the dependencies within them.
+func GroupByLicence(deps []detector.LicenceInfo) []DependencyGroup {
+	return groupDependencies(deps, func(dep detector.LicenceInfo) string {
+		if len(dep.Licences) == 0 {
+			return "Unknown"
+		}
+		return strings.Join(dep.Licences, " AND ")
+	})
+}
+
+func groupDependencies(deps []detector.LicenceInfo, keyFn func(detector.LicenceInfo) string) []DependencyGroup {
+	var groups []DependencyGroup
+	index := make(map[string]int)
+	for _, dep := range deps {
+		key := keyFn(dep)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, DependencyGroup{Name: key})
+		}
+		groups[i].Dependencies = append(groups[i].Dependencies, dep)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+
+	return groups
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func mkDep(path string) detector.LicenceInfo {
+	return detector.LicenceInfo{Module: detector.Module{Path: path, Version: "v1.0.0"}}
+}
+
+func TestOrgAndRepoURL(t *testing.T) {
+	testCases := []struct {
+		path    string
+		org     string
+		repoURL string
+	}{
+		{path: "github.com/elastic/go-ucfg", org: "github.com/elastic", repoURL: "https://github.com/elastic/go-ucfg"},
+		{path: "github.com/elastic/go-ucfg/v2", org: "github.com/elastic", repoURL: "https://github.com/elastic/go-ucfg"},
+		{path: "github.com/elastic/beats/libbeat", org: "github.com/elastic", repoURL: "https://github.com/elastic/beats"},
+		{path: "github.com/elastic", org: "github.com", repoURL: "https://github.com/elastic"},
+		{path: "gitlab.com/group/project", org: "gitlab.com/group", repoURL: "https://gitlab.com/group/project"},
+		{path: "bitbucket.org/team/repo/v3", org: "bitbucket.org/team", repoURL: "https://bitbucket.org/team/repo"},
+		{path: "golang.org/x/net", org: "golang.org/x", repoURL: "https://golang.org/x/net"},
+		{path: "golang.org/x/tools/gopls", org: "golang.org/x", repoURL: "https://golang.org/x/tools"},
+		{path: "gopkg.in/yaml.v2", org: "gopkg.in", repoURL: "https://gopkg.in/yaml.v2"},
+		{path: "gopkg.in/src-d/go-git.v4", org: "gopkg.in", repoURL: "https://gopkg.in/src-d/go-git.v4"},
+		{path: "go.uber.org/zap", org: "go.uber.org", repoURL: "https://go.uber.org/zap"},
+		{path: "k8s.io/api", org: "k8s.io", repoURL: "https://k8s.io/api"},
+		{path: "k8s.io/klog/v2", org: "k8s.io", repoURL: "https://k8s.io/klog/v2"},
+		{path: "honnef.co/go/tools", org: "honnef.co", repoURL: "https://honnef.co/go/tools"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			dep := mkDep(tc.path)
+			require.Equal(t, tc.org, Org(dep))
+			require.Equal(t, tc.repoURL, RepoURL(dep))
+		})
+	}
+}
+
+func TestGroupByOrg(t *testing.T) {
+	deps := []detector.LicenceInfo{
+		mkDep("k8s.io/api"),
+		mkDep("github.com/elastic/go-ucfg"),
+		mkDep("gopkg.in/yaml.v2"),
+		mkDep("github.com/foo/bar"),
+		mkDep("k8s.io/klog/v2"),
+		mkDep("github.com/elastic/go-sysinfo"),
+		mkDep("golang.org/x/net"),
+		mkDep("golang.org/x/text"),
+	}
+
+	groups := GroupByOrg(deps)
+
+	type group struct {
+		name  string
+		paths []string
+	}
+	var have []group
+	for _, g := range groups {
+		var paths []string
+		for _, dep := range g.Dependencies {
+			paths = append(paths, dep.Path)
+		}
+		have = append(have, group{name: g.Name, paths: paths})
+	}
+
+	// groups are sorted by name and keep the order of their dependencies
+	require.Equal(t, []group{
+		{name: "github.com/elastic", paths: []string{"github.com/elastic/go-ucfg", "github.com/elastic/go-sysinfo"}},
+		{name: "github.com/foo", paths: []string{"github.com/foo/bar"}},
+		{name: "golang.org/x", paths: []string{"golang.org/x/net", "golang.org/x/text"}},
+		{name: "gopkg.in", paths: []string{"gopkg.in/yaml.v2"}},
+		{name: "k8s.io", paths: []string{"k8s.io/api", "k8s.io/klog/v2"}},
+	}, have)
+}
+
+func TestGroupByOrgEmpty(t *testing.T) {
+	require.Empty(t, GroupByOrg(nil))
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+)
+
+// headerText and footerText are the contents of -header-file and -footer-file, which the presets render before and
+// after the notice.
+var headerText, footerText string
+
+// loadTextFile reads a header or footer, ending it with a newline so that it stays on lines of its own.
+func loadTextFile(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	text := string(data)
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	return text, nil
+}
+
+/* Template functions */
+
+// Header returns the contents of -header-file, or an empty string.
+func Header() string {
+	return headerText
+}
+
+// Footer returns the contents of -footer-file, or an empty string.
+func Footer() string {
+	return footerText
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// historySnapshot is the licence inventory of a release, recorded in the history directory by history record.
+type historySnapshot struct {
+	Release  string         `json:"release"`
+	Recorded string         `json:"recorded"`       // generation time of the report, as RFC 3339
+	Lock     string         `json:"lock,omitempty"` // contents of the lockfile at the time of recording
+	Licences map[string]int `json:"licences"`       // number of dependencies by licence expression
+}
+
+func init() {
+	subcommands["history"] = history
+}
+
+// history records the licence inventory of a release in -history-dir, or prints the number of dependencies under
+// each licence for every recorded release.
+func history() {
+	switch {
+	case flag.NArg() == 3 && flag.Arg(0) == "record":
+		snapshot, err := mkHistorySnapshot(flag.Arg(1), flag.Arg(2))
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := recordHistory(*historyDirFlag, snapshot); err != nil {
+			log.Fatalf("Failed to record history in %s: %v", *historyDirFlag, err)
+		}
+	case flag.NArg() == 1 && flag.Arg(0) == "show":
+		snapshots, err := loadHistory(*historyDirFlag)
+		if err != nil {
+			log.Fatalf("Failed to load history from %s: %v", *historyDirFlag, err)
+		}
+
+		w, cleanup, err := mkWriter(*outFlag)
+		if err != nil {
+			log.Fatalf("Failed to create output file %s: %v", *outFlag, err)
+		}
+		defer cleanup()
+
+		if err := writeHistory(w, snapshots); err != nil {
+			log.Fatalf("Failed to write history: %v", err)
+		}
+	default:
+		log.Fatal("Usage: history [-history-dir DIR] record RELEASE REPORT | history [-history-dir DIR] [-out FILE] show")
+	}
+}
+
+// mkHistorySnapshot counts the dependencies of the report written by -format json under each licence.
+func mkHistorySnapshot(release, reportPath string) (historySnapshot, error) {
+	if release == "" || strings.ContainsAny(release, `/\`) || release == "." || release == ".." {
+		return historySnapshot{}, fmt.Errorf("invalid release name %q", release)
+	}
+
+	r, err := loadReport(reportPath)
+	if err != nil {
+		return historySnapshot{}, fmt.Errorf("failed to load report %s: %w", reportPath, err)
+	}
+
+	s := historySnapshot{Release: release, Recorded: time.Now().UTC().Format(time.RFC3339), Licences: make(map[string]int)}
+	if r.Metadata != nil && r.Metadata.GeneratedAt != "" {
+		s.Recorded = r.Metadata.GeneratedAt
+	}
+
+	for _, deps := range [][]reportDependency{r.Direct, r.Indirect, r.Tools} {
+		for _, dep := range deps {
+			licence := unknownLicence
+			if len(dep.Licences) > 0 {
+				licence = strings.Join(dep.Licences, " AND ")
+			}
+			s.Licences[licence]++
+		}
+	}
+
+	if lock, err := ioutil.ReadFile(*lockfileFlag); err == nil {
+		s.Lock = string(lock)
+	} else if !os.IsNotExist(err) {
+		return s, fmt.Errorf("failed to read lockfile %s: %w", *lockfileFlag, err)
+	}
+
+	return s, nil
+}
+
+// recordHistory writes the snapshot to <dir>/<release>.json, replacing any previous snapshot of the release.
+func recordHistory(dir string, s historySnapshot) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, s.Release+".json"), append(data, '\n'), 0644)
+}
+
+// loadHistory reads the snapshots of the history directory, from the oldest to the most recent.
+func loadHistory(dir string) ([]historySnapshot, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]historySnapshot, 0, len(paths))
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var s historySnapshot
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Recorded < snapshots[j].Recorded
+	})
+	return snapshots, nil
+}
+
+// writeHistory writes a table of the number of dependencies under each licence per release, followed by the
+// change between the last two releases.
+func writeHistory(w io.Writer, snapshots []historySnapshot) error {
+	licenceSet := make(map[string]struct{})
+	for _, s := range snapshots {
+		for licence := range s.Licences {
+			licenceSet[licence] = struct{}{}
+		}
+	}
+	licences := make([]string, 0, len(licenceSet))
+	for licence := range licenceSet {
+		licences = append(licences, licence)
+	}
+	sort.Strings(licences)
+
+	header := []string{"Licence"}
+	for _, s := range snapshots {
+		header = append(header, s.Release)
+	}
+	header = append(header, "Change")
+
+	rows := [][]string{header}
+	for _, licence := range licences {
+		row := []string{licence}
+		for _, s := range snapshots {
+			row = append(row, strconv.Itoa(s.Licences[licence]))
+		}
+		rows = append(rows, append(row, historyChange(snapshots, licence)))
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range rows {
+		for i, cell := range row {
+			if n := len([]rune(cell)); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = PadRight(widths[i], cell)
+		}
+		if _, err := fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, "  "), " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func historyChange(snapshots []historySnapshot, licence string) string {
+	if len(snapshots) < 2 {
+		return ""
+	}
+
+	diff := snapshots[len(snapshots)-1].Licences[licence] - snapshots[len(snapshots)-2].Licences[licence]
+	switch {
+	case diff > 0:
+		return "+" + strconv.Itoa(diff)
+	case diff < 0:
+		return strconv.Itoa(diff)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	reports := map[string]string{
+		"v1.0.0": `{"metadata": {"generatedAt": "2020-01-01T00:00:00Z"}, "direct": [{"path": "a", "licences": ["MIT"]}, {"path": "b", "licences": ["Apache-2.0"]}]}`,
+		"v1.1.0": `{"metadata": {"generatedAt": "2020-02-01T00:00:00Z"}, "direct": [{"path": "a", "licences": ["MIT"]}, {"path": "c", "licences": ["MIT"]}, {"path": "d"}]}`,
+	}
+	historyDir := filepath.Join(dir, "history")
+	for release, contents := range reports {
+		reportPath := filepath.Join(dir, release+".json")
+		require.NoError(t, ioutil.WriteFile(reportPath, []byte(contents), 0644))
+
+		snapshot, err := mkHistorySnapshot(release, reportPath)
+		require.NoError(t, err)
+		require.NoError(t, recordHistory(historyDir, snapshot))
+	}
+
+	snapshots, err := loadHistory(historyDir)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeHistory(&buf, snapshots))
+	require.Equal(t, `Licence     v1.0.0  v1.1.0  Change
+Apache-2.0  1       0       -1
+MIT         1       2       +1
+unknown     0       1       +1
+`, buf.String())
+
+	_, err = mkHistorySnapshot("../v1", filepath.Join(dir, "v1.0.0.json"))
+	require.Error(t, err)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+)
+
+// hook is meant to be run from pre-commit frameworks. Detection only runs when go.mod or go.sum changed since the
+// lockfile was written. If the regenerated output or the lockfile differ from the existing ones, hook exits with a
+// non-zero code so that the user commits the updated files.
+func hook() {
+	if *outFlag == "-" {
+		log.Fatal("The hook command requires -out to be a file")
+	}
+
+	inputFn := goListReader
+	if *inFlag != "-" {
+		inputFn = mkReader
+	}
+
+	updated, err := runHook(*outFlag, *lockfileFlag, func() error {
+		_, err := run(inputFn)
+		return err
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(updated) > 0 {
+		fmt.Fprintf(os.Stderr, "%s out of date and updated. Please commit the changes.\n", strings.Join(updated, " and "))
+		osExit(1)
+	}
+}
+
+// runHook regenerates the output with generate unless it exists and go.mod and go.sum are unchanged since the lockfile
+// was written. It returns the files it updated. The lockfile is only written when its contents change, so that the
+// tree is left clean when nothing changed.
+func runHook(outPath, lockPath string, generate func() error) ([]string, error) {
+	lock, err := mkLock("go.mod", "go.sum")
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute digests of go.mod and go.sum: %w", err)
+	}
+
+	existing, err := ioutil.ReadFile(lockPath)
+	lockChanged := err != nil || !bytes.Equal(existing, lock)
+	if _, err := os.Stat(outPath); err == nil && !lockChanged {
+		return nil, nil
+	}
+
+	before, _ := sha256File(outPath)
+	if err := generate(); err != nil {
+		return nil, err
+	}
+
+	after, err := sha256File(outPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", outPath, err)
+	}
+
+	var updated []string
+	if before != after {
+		updated = append(updated, outPath)
+	}
+	if lockChanged {
+		if err := ioutil.WriteFile(lockPath, lock, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write lockfile %s: %w", lockPath, err)
+		}
+		updated = append(updated, lockPath)
+	}
+
+	return updated, nil
+}
+
+func mkLock(paths ...string) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, p := range paths {
+		digest, err := sha256File(p)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buf, "%s  %s\n", digest, p)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMkLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lock")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	goMod := filepath.Join(dir, "go.mod")
+	goSum := filepath.Join(dir, "go.sum")
+	require.NoError(t, ioutil.WriteFile(goMod, []byte("hello"), 0644))
+	require.NoError(t, ioutil.WriteFile(goSum, nil, 0644))
+
+	lock, err := mkLock(goMod, goSum)
+	require.NoError(t, err)
+	// the digests are computed with sha256sum, in its format
+	require.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824  "+goMod+"\n"+
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  "+goSum+"\n", string(lock))
+
+	_, err = mkLock(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
+
+func TestRunHook(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(wd)
+
+	const (
+		outPath  = "NOTICE.txt"
+		lockPath = ".licence-detector.lock"
+	)
+
+	testCases := []struct {
+		name        string
+		goMod       string // go.mod after the lockfile was written, if changed
+		removeOut   bool
+		notice      string // contents of the regenerated notice
+		wantRun     bool
+		wantUpdated []string
+	}{
+		{name: "Locked", notice: "notice v1\n"},
+		{name: "GoModChangedNoticeUnchanged", goMod: "module example.com/m\n\nrequire example.com/a v1.0.0\n", notice: "notice v1\n", wantRun: true, wantUpdated: []string{lockPath}},
+		{name: "GoModChangedNoticeChanged", goMod: "module example.com/m\n\nrequire example.com/a v1.1.0\n", notice: "notice v2\n", wantRun: true, wantUpdated: []string{outPath, lockPath}},
+		{name: "NoticeMissing", removeOut: true, notice: "notice v1\n", wantRun: true, wantUpdated: []string{outPath}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "hook")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+			require.NoError(t, os.Chdir(dir))
+
+			require.NoError(t, ioutil.WriteFile("go.mod", []byte("module example.com/m\n"), 0644))
+			require.NoError(t, ioutil.WriteFile("go.sum", nil, 0644))
+			require.NoError(t, ioutil.WriteFile(outPath, []byte("notice v1\n"), 0644))
+			lock, err := mkLock("go.mod", "go.sum")
+			require.NoError(t, err)
+			require.NoError(t, ioutil.WriteFile(lockPath, lock, 0644))
+			lockTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+			require.NoError(t, os.Chtimes(lockPath, lockTime, lockTime))
+
+			if tc.goMod != "" {
+				require.NoError(t, ioutil.WriteFile("go.mod", []byte(tc.goMod), 0644))
+			}
+			if tc.removeOut {
+				require.NoError(t, os.Remove(outPath))
+			}
+
+			ran := false
+			updated, err := runHook(outPath, lockPath, func() error {
+				ran = true
+				return ioutil.WriteFile(outPath, []byte(tc.notice), 0644)
+			})
+			require.NoError(t, err)
+			require.Equal(t, tc.wantRun, ran)
+			require.Equal(t, tc.wantUpdated, updated)
+
+			// the lockfile always matches go.mod and go.sum afterwards, and is only written when it changes
+			want, err := mkLock("go.mod", "go.sum")
+			require.NoError(t, err)
+			got, err := ioutil.ReadFile(lockPath)
+			require.NoError(t, err)
+			require.Equal(t, string(want), string(got))
+			fi, err := os.Stat(lockPath)
+			require.NoError(t, err)
+			require.Equal(t, tc.goMod != "", !fi.ModTime().Equal(lockTime))
+		})
+	}
+}
+
+func TestRunHookErrors(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(wd)
+
+	dir, err := ioutil.TempDir("", "hook")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.Chdir(dir))
+
+	_, err = runHook("NOTICE.txt", "lock", func() error { return nil })
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to compute digests of go.mod and go.sum")
+
+	require.NoError(t, ioutil.WriteFile("go.mod", []byte("module example.com/m\n"), 0644))
+	require.NoError(t, ioutil.WriteFile("go.sum", nil, 0644))
+	errGenerate := errors.New("detection failed")
+	_, err = runHook("NOTICE.txt", "lock", func() error { return errGenerate })
+	require.Equal(t, errGenerate, err)
+	// nothing is recorded when the generation fails
+	_, err = os.Stat("lock")
+	require.True(t, os.IsNotExist(err))
+}
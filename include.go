@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/* Template functions */
+
+// IncludeFile returns the contents of the file at path, relative to the project directory, which is the working
+// directory. Files outside the project directory, including through symlinks, cannot be included so that templates
+// from third parties cannot read arbitrary files.
+func IncludeFile(path string) (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := resolveProjectPath(wd, path)
+	if err != nil {
+		return "", fmt.Errorf("includeFile %s: %w", path, err)
+	}
+
+	data, err := ioutil.ReadFile(resolved)
+	if err != nil {
+		return "", fmt.Errorf("includeFile %s: %w", path, err)
+	}
+	return string(data), nil
+}
+
+// resolveProjectPath resolves the path relative to the project directory and checks that the file it points to,
+// after following symlinks, is within that directory.
+func resolveProjectPath(projectDir, path string) (string, error) {
+	if filepath.IsAbs(path) {
+		return "", fmt.Errorf("the path must be relative to the project directory")
+	}
+
+	root, err := filepath.EvalSymlinks(projectDir)
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := filepath.EvalSymlinks(filepath.Join(root, path))
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("the file is outside the project directory")
+	}
+	return resolved, nil
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// inputFormats convert dependency lists produced by other tools to the go list -m -json format.
+var inputFormats = map[string]func(io.Reader) (io.Reader, error){
+	"bazel": convertBazelQuery,
+	"gomod": convertGoMod,
+	"image": convertImage,
+}
+
+func inputFormatNames() []string {
+	names := []string{"go-list"}
+	for name := range inputFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+func convertInput(r io.Reader, format string) (io.Reader, error) {
+	if format == "go-list" {
+		return r, nil
+	}
+
+	convert, ok := inputFormats[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown input format %q: must be one of %s", format, strings.Join(inputFormatNames(), ", "))
+	}
+
+	return convert(r)
+}
+
+// convertBazelQuery resolves the go_repository rules to the external repositories of the Bazel output base if
+// one is given, or to the module cache otherwise.
+func convertBazelQuery(r io.Reader) (io.Reader, error) {
+	mods, err := detector.ParseBazelQuery(r, func(repo string, mod detector.Module) string {
+		if *bazelOutputBaseFlag != "" {
+			return filepath.Join(*bazelOutputBaseFlag, "external", repo)
+		}
+		return detector.ModuleCacheDir(goModCache, mod.Path, mod.Version)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeModules(mods)
+}
+
+// convertGoMod lists the dependencies from the go.mod file given as input and the go.sum file next to it. This is
+// meant for analysing source trees whose build environment is not available, so the module sources are taken from
+// the module cache or downloaded from GOPROXY when missing.
+func convertGoMod(r io.Reader) (io.Reader, error) {
+	gomod, err := detector.ParseGoMod(r)
+	if err != nil {
+		return nil, err
+	}
+
+	modDir := "."
+	if *inFlag != "-" {
+		modDir = filepath.Dir(*inFlag)
+	}
+
+	sums := make(detector.GoSum)
+	if f, err := os.Open(filepath.Join(modDir, "go.sum")); err == nil {
+		sums, err = detector.ParseGoSum(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	mods := gomod.Modules(sums)
+	if err := resolveModuleDirs(mods, modDir, sums); err != nil {
+		return nil, err
+	}
+
+	return encodeModules(mods)
+}
+
+// convertImage lists the modules shipped in the tarred filesystem given as input, such as an image layer or the
+// output of docker save. Their sources are taken from the module cache or downloaded from GOPROXY and verified
+// against the hashes recorded in the binaries.
+func convertImage(r io.Reader) (io.Reader, error) {
+	mods, sums, err := detector.ParseImage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// the local replacements refer to the build environment and cannot be resolved
+	if err := resolveModuleDirs(mods, "", sums); err != nil {
+		return nil, err
+	}
+
+	return encodeModules(mods)
+}
+
+// resolveModuleDirs sets the source directories of the modules, taken from the module cache or downloaded from
+// GOPROXY. Local replacements are resolved against modDir, unless it is empty.
+func resolveModuleDirs(mods []detector.Module, modDir string, sums detector.GoSum) error {
+	errs := make([]error, len(mods))
+	sem := make(chan struct{}, maxInt(*fetchConcurrencyFlag, 1))
+	var wg sync.WaitGroup
+	for i := range mods {
+		mod := &mods[i]
+		src := mod
+		if mod.Replace != nil {
+			src = mod.Replace
+		}
+
+		if src.Version == "" {
+			if modDir == "" {
+				continue
+			}
+			src.Dir = src.Path
+			if !filepath.IsAbs(src.Dir) {
+				src.Dir = filepath.Join(modDir, filepath.FromSlash(src.Path))
+			}
+			mod.Dir = src.Dir
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if src.Dir, src.Verification, errs[i] = moduleDir(src.Path, src.Version, sums); errs[i] != nil {
+				errs[i] = fmt.Errorf("failed to download %s@%s: %w", src.Path, src.Version, errs[i])
+			}
+			mod.Dir = src.Dir
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+var (
+	downloaderOnce sync.Once
+	downloader     *moduleDownloader
+	downloaderErr  error
+)
+
+// moduleDir returns the directory of the module version in the Go module cache, or downloads it from GOPROXY, along
+// with the status of the verification of its sources against go.sum or the checksum database.
+func moduleDir(modPath, version string, sums detector.GoSum) (string, string, error) {
+	downloaderOnce.Do(func() {
+		downloader, downloaderErr = newModuleDownloader()
+	})
+	if downloaderErr != nil {
+		return "", "", downloaderErr
+	}
+
+	dir := detector.ModuleCacheDir(goModCache, modPath, version)
+	if _, err := os.Stat(dir); err == nil {
+		status, err := downloader.verifyCached(goModCache, modPath, version, sums)
+		return dir, status, err
+	}
+
+	return downloader.download(modPath, version, sums)
+}
+
+func encodeModules(mods []detector.Module) (io.Reader, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, mod := range mods {
+		if err := encoder.Encode(mod); err != nil {
+			return nil, err
+		}
+	}
+
+	return &buf, nil
+}
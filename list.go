@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// The colours have the same length so that coloured and uncoloured cells stay aligned by the tabwriter.
+const (
+	colourRed     = "\x1b[31m"
+	colourYellow  = "\x1b[33m"
+	colourDefault = "\x1b[39m"
+	colourReset   = "\x1b[0m"
+)
+
+const unknownLicence = "unknown"
+
+// list prints a table of the dependencies and their licences without generating any files.
+func list() {
+	dependencies, err := detect(mkReader)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *porcelainFlag {
+		if err := writePorcelain(os.Stdout, dependencies); err != nil {
+			log.Fatalf("Failed to write porcelain output: %v", err)
+		}
+		return
+	}
+
+	colour, err := useColour(*colorFlag, os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := writeTable(os.Stdout, dependencies, colour); err != nil {
+		log.Fatalf("Failed to write table: %v", err)
+	}
+}
+
+func useColour(mode string, f *os.File) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		fi, err := f.Stat()
+		if err != nil {
+			return false, nil
+		}
+		return fi.Mode()&os.ModeCharDevice != 0 && os.Getenv("NO_COLOR") == "", nil
+	default:
+		return false, fmt.Errorf("invalid -color value %q: must be one of auto, always, never", mode)
+	}
+}
+
+// writeTable writes the dependencies as a table. With colour, the licences denied by the policy are highlighted in red
+// and the unknown licences in yellow.
+func writeTable(w io.Writer, dependencies *detector.Dependencies, colour bool) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	denied := make(map[string]struct{})
+	if currentPolicy != nil {
+		for _, id := range currentPolicy.Deny {
+			denied[id] = struct{}{}
+		}
+	}
+
+	cell := func(text, textColour string) string {
+		if !colour {
+			return text
+		}
+		return textColour + text + colourReset
+	}
+
+	fmt.Fprintf(tw, "MODULE\tVERSION\t%s\tSOURCE\n", cell("LICENCE", colourDefault))
+	for _, dep := range allDependencies(dependencies) {
+		mod := effectiveModule(dep)
+		licence, licenceColour := unknownLicence, colourYellow
+		if len(dep.Licences) > 0 {
+			licence, licenceColour = strings.Join(dep.Licences, " AND "), colourDefault
+		}
+		for _, id := range dep.Licences {
+			if _, ok := denied[id]; ok {
+				licenceColour = colourRed
+			}
+		}
+
+		source := dep.Source
+		if source == "" {
+			source = "-"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", mod.Path, mod.Version, cell(licence, licenceColour), source)
+	}
+
+	return tw.Flush()
+}
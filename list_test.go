@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTable(t *testing.T) {
+	defer func(p *policy) { currentPolicy = p }(currentPolicy)
+	currentPolicy = &policy{Deny: []string{"GPL-3.0"}}
+
+	dep := func(path, source string, licences ...string) detector.LicenceInfo {
+		return detector.LicenceInfo{Module: detector.Module{Path: path, Version: "v1.0.0"}, Licences: licences, Source: source}
+	}
+	dependencies := &detector.Dependencies{
+		Direct: []detector.LicenceInfo{
+			dep("example.com/mit", detector.SourceFile, "MIT"),
+			dep("example.com/dual", detector.SourceReuse, "GPL-3.0", "MIT"),
+		},
+		Indirect: []detector.LicenceInfo{
+			dep("example.com/unknown", ""),
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeTable(&buf, dependencies, false))
+	require.Equal(t, `MODULE               VERSION  LICENCE          SOURCE
+example.com/mit      v1.0.0   MIT              file
+example.com/dual     v1.0.0   GPL-3.0 AND MIT  reuse
+example.com/unknown  v1.0.0   unknown          -
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, writeTable(&buf, dependencies, true))
+	lines := strings.Split(buf.String(), "\n")
+	require.Contains(t, lines[0], colourDefault+"LICENCE"+colourReset)
+	require.Contains(t, lines[1], colourDefault+"MIT"+colourReset)
+	require.Contains(t, lines[2], colourRed+"GPL-3.0 AND MIT"+colourReset)
+	require.Contains(t, lines[3], colourYellow+"unknown"+colourReset)
+
+	// every licence cell is coloured, so that the colours do not change the alignment
+	plain := buf.String()
+	for _, c := range []string{colourRed, colourYellow, colourDefault, colourReset} {
+		plain = strings.Replace(plain, c, "", -1)
+	}
+	buf.Reset()
+	require.NoError(t, writeTable(&buf, dependencies, false))
+	require.Equal(t, buf.String(), plain)
+}
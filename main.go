@@ -2,12 +2,17 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"go/build"
 	"io"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -19,30 +24,621 @@ import (
 )
 
 var (
-	inFlag              = flag.String("in", "-", "Dependency list (output from go list -m -json all)")
-	includeIndirectFlag = flag.Bool("includeIndirect", false, "Include indirect dependencies")
-	outFlag             = flag.String("out", "-", "Path to output the notice information")
-	templateFlag        = flag.String("template", "NOTICE.txt.tmpl", "Path to the template file")
+	allCandidatesFlag        = flag.Bool("all-candidates", false, "Walk the whole module tree to list every candidate licence file, instead of stopping at the licence files of the module root (implied by -subcomponents and -licence-preference)")
+	bazelOutputBaseFlag      = flag.String("bazel-output-base", "", "Bazel output base holding the external repositories (bazel input format; defaults to the module cache)")
+	baselineFlag             = flag.String("baseline", "", "Path to a previous report (-format json) to compare the dependencies against")
+	cacheDirFlag             = flag.String("cache-dir", "", "Directory or URL (http(s)://, s3://bucket/prefix or gs://bucket/prefix) caching the detection results of module versions, keyed by the hash of their zip, which can be shared by concurrent runs")
+	cacheReadOnlyFlag        = flag.Bool("cache-read-only", false, "Only read the detection results from -cache-dir, without storing new ones")
+	checksumFlag             = flag.Bool("checksum", false, "Write the SHA-256 checksum of the output to <out>.sha256")
+	colorFlag                = flag.String("color", "auto", "Colour the list output (auto, always, never)")
+	displayNamesFlag         = flag.String("display-names", "", "Path to a JSON object mapping module paths to the project names used in rendered output")
+	errorsFlag               = flag.String("errors", "fail-fast", "How failures to process a module are handled: fail-fast aborts on the first one, collect processes every module and reports the failures together")
+	evidenceFlag             = flag.Bool("evidence", false, "Record the files examined for each module, with the reasons for rejecting them, in the report (json, yaml and protobuf formats)")
+	execDetectorFlag         = flag.String("exec-detector", "", "Command invoked for each module with the module JSON on stdin, returning detection JSON on stdout")
+	fetchBudgetFlag          = flag.Int("fetch-budget", 0, "Maximum number of remote requests, including retries, made during a run (0 means unlimited)")
+	fetchConcurrencyFlag     = flag.Int("fetch-concurrency", 4, "Maximum number of modules downloaded concurrently (gomod input format)")
+	fetchRetriesFlag         = flag.Int("fetch-retries", 3, "Number of times failed remote requests are retried with exponential backoff")
+	footerFileFlag           = flag.String("footer-file", "", "Path to a file whose contents are available to templates as footer and appended by the presets")
+	formatFlag               = flag.String("format", "notice", "Output format (notice, csv, fossa, intoto, json, matrix, ndjson, pr-comment, protobuf, yaml)")
+	gopathFlag               = flag.String("gopath", "", "Path to a JSON object mapping the import paths of dependencies resolved from the GOPATH rather than the module cache to their source directories (looked up in $GOPATH/src if empty)")
+	headerFileFlag           = flag.String("header-file", "", "Path to a file whose contents are available to templates as header and prepended by the presets")
+	historyDirFlag           = flag.String("history-dir", ".licence-history", "Directory holding the licence inventory of each release recorded by the history command")
+	ignoreFlag               = flag.String("ignore", "", "Path to a file of \"module: pattern\" lines excluding files and directories of modules from the detection")
+	inFlag                   = &inputsFlag.path
+	inceptionYearFlag        = flag.Int("inception-year", 0, "Year the project started, used as the start of the copyrightYears template function range")
+	includeIndirectFlag      = flag.Bool("includeIndirect", false, "Include indirect dependencies (same as an indirect policy of all in the -policy file)")
+	inputFormatFlag          = flag.String("input-format", "go-list", "Format of the dependency list (go-list, bazel, gomod, image: a tarred filesystem holding Go binaries or vendor trees)")
+	licenceDirsFlag          = flag.String("licence-dirs", "", "Path to a JSON object mapping module paths to the directories holding their licence, for modules that have none of their own")
+	licencePreferenceFlag    = flag.String("licence-preference", "", "Comma-separated patterns ranking the licence files of modules that have several (e.g. LICENSE,LICENSE.*,COPYING*)")
+	lockfileFlag             = flag.String("lockfile", ".licence-detector.lock", "Path to the lockfile recording the go.mod and go.sum digests (hook command)")
+	manifestFlag             = flag.String("manifest", "", "Path to write a JSON manifest of the run recording its inputs, flags, counts and the digests of the outputs")
+	maxDepthFlag             = flag.Int("max-depth", 0, "Maximum directory depth to search for licence files when none is found at the module root, or with -all-candidates (0 means unlimited)")
+	maxLicenceSizeFlag       = flag.Int64("max-licence-size", 1<<20, "Skip licence candidates larger than this many bytes (0 means unlimited)")
+	maxLineLengthFlag        = flag.Int("max-line-length", 0, "Wrap the lines of the rendered notice longer than this many characters, at spaces where possible (0 means unlimited)")
+	messagesFlag             = flag.String("messages", "", "Path to a JSON object mapping message keys (licenceFile, licenceNotFound, licenceOmitted, licenceTruncated) to the boilerplate strings rendered by the template functions")
+	moduleTimeoutFlag        = flag.Duration("module-timeout", 0, "Maximum time spent searching the tree of a module, after which the licence is chosen among the files found so far (0 means unlimited)")
+	notifyWebhookFlag        = flag.String("notify-webhook", "", "URL of a webhook to post a summary of the run to: new and removed dependencies, policy violations and unknown licences")
+	obligationsFlag          = flag.String("obligations", "", "Path to a JSON object mapping SPDX identifiers to the licence obligations overriding the built-in ones")
+	onlyFlag                 = flag.String("only", "", "Comma-separated module path patterns, as in GOPRIVATE, of the modules to detect again, the other modules reusing their results from the -baseline report")
+	osvFlag                  = flag.Bool("osv", false, "Annotate the dependencies with the IDs of their known vulnerabilities, queried from OSV.dev")
+	outFlag                  = flag.String("out", "-", "Path to output the notice information")
+	overflowFlag             = flag.String("overflow", overflowFail, "What to do when the notice exceeds -max-output-size (fail, truncate, split)")
+	ownersFlag               = flag.String("owners", "", "Path to a JSON object mapping module path patterns, as used by GOPRIVATE, to the owning teams")
+	packagesFlag             = flag.String("packages", "", "Path to the output of go list -deps -json for the packages of the binary (required by the linked indirect policy)")
+	platformsFlag            = flag.String("platforms", "", "Comma-separated GOOS/GOARCH pairs (e.g. linux/amd64,windows/amd64) whose dependencies are listed with go list -deps in the current directory, tagging each dependency with the platforms it applies to")
+	policyFlag               = flag.String("policy", "", "Path to a JSON policy file selecting the indirect dependencies to include and the denied licences")
+	porcelainFlag            = flag.Bool("porcelain", false, "Write one JSON object per dependency to stdout and suppress all other non-error output")
+	presetFlag               = flag.String("preset", "", "Built-in template to render instead of -template (apache, by-licence, html, markdown, notice)")
+	profileFlag              = flag.String("profile", "", "Path to write a report of the time taken to detect the licence of each module")
+	quietFlag                = flag.Bool("quiet", false, "Suppress all non-error output")
+	releaseNotesFlag         = flag.String("release-notes", "", "Path to write a release notes fragment listing the dependencies added, removed, updated or relicensed since the -baseline")
+	releaseNotesTemplateFlag = flag.String("release-notes-template", "", "Path to the template of the release notes fragment (built-in Markdown template if empty)")
+	reproducibleFlag         = flag.Bool("reproducible", false, "Produce byte-identical output for identical inputs, using SOURCE_DATE_EPOCH as the current time")
+	reviewQueueFlag          = flag.String("review-queue", "", "Directory to write the dependencies of unknown licence to, in one JSON file per owning team")
+	scanCodeFlag             = flag.String("scancode", "", "Path to ScanCode toolkit JSON results used to enrich detection")
+	signKeyFlag              = flag.String("sign-key", "", "Path to a PEM private key used to write a detached signature of the output to <out>.sig")
+	skipMissingFlag          = flag.Bool("skip-missing", false, "Leave out the dependencies whose sources are missing from the module cache instead of failing")
+	softFailFlag             = flag.Bool("soft-fail", false, "Report policy violations, relicensed dependencies and failures to detect licences without failing the run (implies -errors collect)")
+	sortFlag                 = flag.String("sort", "path", "Order of the dependencies passed to the template (path, licence, org, project)")
+	strictEncodingFlag       = flag.Bool("strict-encoding", false, "Fail on licence files that are not UTF-8 instead of transcoding them")
+	subcomponentsFlag        = flag.Bool("subcomponents", false, "Record the licences found in the third_party/ and vendor/ trees of modules as sub-components")
+	supplementFlag           = flag.String("supplement", "", "Path to a supplemental manifest declaring non-Go dependencies, such as cgo-linked libraries")
+	symlinksFlag             = flag.String("symlinks", "follow", "How to handle symlinks in module trees (follow, skip, error)")
+	templateFlag             = flag.String("template", "NOTICE.txt.tmpl", "Path to the template file")
+	toolsFlag                = flag.Bool("tools", false, "Also detect the licences of the modules providing the build tools declared by the tools.go file or the tool directives of the go.mod file in the current directory, listed as a separate build tools section")
+	versionFlag              = flag.Bool("version", false, "Print the version information and exit")
+	violationsReportFlag     = flag.String("violations-report", "", "Path to write a report of the policy violations and unknown licences, with suggested remediations")
+	violationsTemplateFlag   = flag.String("violations-template", "", "Path to the template of the violations report (built-in template if empty)")
+	watchFlag                = flag.Bool("watch", false, "Regenerate the output whenever go.mod, go.sum or the input file change")
+
+	attestationSubjectsFlag stringsFlag
+	inputsFlag              = inputFlag{path: "-"}
+	maxLicenceBytesFlag     byteSizeFlag
+	maxOutputSizeFlag       byteSizeFlag
+	splitSizeFlag           byteSizeFlag
 
 	goModCache = filepath.Join(build.Default.GOPATH, "pkg", "mod")
 )
 
+func init() {
+	flag.Var(&attestationSubjectsFlag, "attestation-subject", "Path to an artifact to use as the subject of the in-toto attestation (repeatable)")
+	flag.Var(&inputsFlag, "in", "Dependency list (output from go list -m -json all) as a path or an http(s) URL, or label=path for the dependency list of each build target (repeatable)")
+	flag.Var(&maxLicenceBytesFlag, "max-licence-bytes", "Maximum size of the licence texts rendered, such as 64KB, beyond which they are truncated with a marker and the digest of the file (0 means unlimited)")
+	flag.Var(&maxOutputSizeFlag, "max-output-size", "Maximum size of the notice, such as 512KB or 2MB, beyond which -overflow applies (0 means unlimited)")
+	flag.Var(&splitSizeFlag, "split-size", "Split the notice into numbered files of at most this size, such as 1MB, indexed by the -out file")
+}
+
+// exitRelicensed is the exit code used when a dependency changed licence since the baseline.
+const exitRelicensed = 3
+
+var subcommands = map[string]func(){
+	"hook":     hook,
+	"list":     list,
+	"selftest": selftest,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			if err := flag.CommandLine.Parse(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			validateFlags()
+			cmd()
+			return
+		}
+	}
+
 	flag.Parse()
-	depInput, err := mkReader(*inFlag)
+
+	if *versionFlag {
+		printVersion(os.Stdout)
+		return
+	}
+
+	validateFlags()
+
+	if *watchFlag {
+		watch()
+		return
+	}
+
+	if *porcelainFlag && *outFlag == "-" {
+		log.Fatal("-porcelain requires -out to be a file")
+	}
+
+	dependencies, err := run(mkReader)
 	if err != nil {
-		log.Fatalf("Failed to create reader for %s: %v", *inFlag, err)
+		log.Fatal(err)
+	}
+
+	if *porcelainFlag {
+		if err := writePorcelain(os.Stdout, dependencies); err != nil {
+			log.Fatalf("Failed to write porcelain output: %v", err)
+		}
+	}
+
+	enforcePolicy(dependencies)
+}
+
+// enforcePolicy reports the policy violations and the relicensed dependencies, then fails the run if there are any.
+func enforcePolicy(dependencies *detector.Dependencies) {
+	violations := checkPolicy(dependencies)
+
+	if *violationsReportFlag != "" {
+		report := mkViolationsReport(dependencies, violations)
+		if err := writeViolationsReport(*violationsReportFlag, *violationsTemplateFlag, report); err != nil {
+			log.Fatalf("Failed to write violations report to %s: %v", *violationsReportFlag, err)
+		}
+	}
+
+	if *notifyWebhookFlag != "" {
+		if err := notifyWebhook(*notifyWebhookFlag, dependencies, violations); err != nil {
+			log.Printf("Failed to notify webhook: %v", err)
+		}
+	}
+
+	// both problems are logged before exiting, policy violations taking precedence for the exit code
+	exitCode := 0
+	if dependencies.Changelog != nil && len(dependencies.Changelog.Relicensed) > 0 {
+		logRelicensed(dependencies.Changelog.Relicensed)
+		exitCode = exitRelicensed
+	}
+
+	if len(violations) > 0 {
+		logPolicyViolations(violations)
+		exitCode = exitPolicyViolation
+	}
+
+	if exitCode != 0 {
+		exit(exitCode)
+	}
+}
+
+// exit exits with the given code, unless -soft-fail is set in which case the failure is only logged.
+func exit(code int) {
+	if *softFailFlag {
+		log.Printf("Not failing the run with exit code %d because of -soft-fail", code)
+		return
+	}
+	osExit(code)
+}
+
+// osExit is replaced by the tests.
+var osExit = os.Exit
+
+func logRelicensed(entries []detector.ChangelogEntry) {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		fmt.Fprintf(&buf, "\n  %s %s => %s: %s => %s", e.Path, e.OldVersion, e.NewVersion,
+			strings.Join(e.OldLicences, " AND "), strings.Join(e.NewLicences, " AND "))
+	}
+	log.Printf("Dependencies changed licence since the baseline:%s", buf.String())
+}
+
+func validateFlags() {
+	if (*checksumFlag || *signKeyFlag != "") && *outFlag == "-" {
+		log.Fatal("Signing and checksums require -out to be a file")
+	}
+
+	if _, ok := sortKeys[*sortFlag]; !ok {
+		log.Fatalf("Invalid -sort %q: must be one of %s", *sortFlag, strings.Join(sortKeyNames(), ", "))
+	}
+
+	if _, ok := presets[*presetFlag]; *presetFlag != "" && !ok {
+		log.Fatalf("Invalid -preset %q: must be one of %s", *presetFlag, strings.Join(presetNames(), ", "))
+	}
+
+	switch *overflowFlag {
+	case overflowFail, overflowTruncate, overflowSplit:
+	default:
+		log.Fatalf("Invalid -overflow %q: must be one of %s", *overflowFlag, strings.Join(overflowStrategies, ", "))
+	}
+
+	if *overflowFlag == overflowSplit && maxOutputSizeFlag > 0 && *outFlag == "-" {
+		log.Fatal("-overflow split requires -out to be a file")
+	}
+
+	if splitSizeFlag > 0 {
+		if *outFlag == "-" {
+			log.Fatal("-split-size requires -out to be a file")
+		}
+		if maxOutputSizeFlag > 0 {
+			log.Fatal("-split-size and -max-output-size cannot be used together")
+		}
+	}
+
+	if *toolsFlag && (splitSizeFlag > 0 || (*overflowFlag == overflowSplit && maxOutputSizeFlag > 0)) {
+		log.Fatal("-tools cannot be used when splitting the notice")
+	}
+
+	if len(inputsFlag.targets) > 0 && *inputFormatFlag == "gomod" {
+		log.Fatal("Labelled dependency lists are not supported by the gomod input format")
+	}
+
+	if *platformsFlag != "" {
+		if _, err := parsePlatforms(*platformsFlag); err != nil {
+			log.Fatalf("Invalid -platforms: %v", err)
+		}
+		if *inFlag != "-" || len(inputsFlag.targets) > 0 {
+			log.Fatal("-platforms computes the dependencies itself and cannot be used with -in")
+		}
+	}
+
+	if *violationsTemplateFlag != "" && *violationsReportFlag == "" {
+		log.Fatal("-violations-template requires -violations-report")
+	}
+
+	if *formatFlag == "pr-comment" && *baselineFlag == "" {
+		log.Fatal("-format pr-comment requires -baseline to compare the dependencies against")
+	}
+
+	if *releaseNotesFlag != "" && *baselineFlag == "" {
+		log.Fatal("-release-notes requires -baseline to compare the dependencies against")
+	}
+
+	if *releaseNotesTemplateFlag != "" && *releaseNotesFlag == "" {
+		log.Fatal("-release-notes-template requires -release-notes")
+	}
+
+	if *cacheReadOnlyFlag && *cacheDirFlag == "" {
+		log.Fatal("-cache-read-only requires -cache-dir")
+	}
+
+	if *onlyFlag != "" && *baselineFlag == "" {
+		log.Fatal("-only requires -baseline to reuse the results of the other modules")
+	}
+
+	if *reproducibleFlag {
+		if _, err := sourceDateEpoch(); err != nil {
+			log.Fatalf("-reproducible requires SOURCE_DATE_EPOCH to be set: %v", err)
+		}
+	}
+}
+
+// run detects the licences of the dependencies read from the input and writes the requested output.
+func run(inputFn func(string) (io.ReadCloser, error)) (*detector.Dependencies, error) {
+	dependencies, err := detect(inputFn)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := []string{*outFlag}
+	switch *formatFlag {
+	case streamFormat:
+		// already written during the detection
+	case "notice":
+		if outputs, err = renderNotice(dependencies, *templateFlag, *outFlag); err != nil {
+			return nil, fmt.Errorf("failed to render notice: %w", err)
+		}
+	default:
+		if err := exportDependencies(dependencies, *formatFlag, *outFlag); err != nil {
+			return nil, fmt.Errorf("failed to export dependencies: %w", err)
+		}
+	}
+
+	written := outputs
+	for _, output := range outputs {
+		if *checksumFlag {
+			if err := writeChecksum(output); err != nil {
+				return nil, fmt.Errorf("failed to write checksum of %s: %w", output, err)
+			}
+			written = append(written, output+".sha256")
+		}
+
+		if *signKeyFlag != "" {
+			if err := signOutput(output, *signKeyFlag); err != nil {
+				return nil, fmt.Errorf("failed to sign %s: %w", output, err)
+			}
+			written = append(written, output+".sig")
+		}
+	}
+
+	if *releaseNotesFlag != "" {
+		if err := writeReleaseNotes(*releaseNotesFlag, *releaseNotesTemplateFlag, dependencies); err != nil {
+			return nil, fmt.Errorf("failed to write release notes to %s: %w", *releaseNotesFlag, err)
+		}
+		written = append(written, *releaseNotesFlag)
+	}
+
+	if *reviewQueueFlag != "" {
+		queues, err := writeReviewQueues(dependencies, *reviewQueueFlag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write review queues to %s: %w", *reviewQueueFlag, err)
+		}
+		written = append(written, queues...)
+	}
+
+	if *manifestFlag != "" {
+		if err := writeManifest(*manifestFlag, dependencies, written); err != nil {
+			return nil, fmt.Errorf("failed to write manifest to %s: %w", *manifestFlag, err)
+		}
+	}
+
+	return dependencies, nil
+}
+
+func detect(inputFn func(string) (io.ReadCloser, error)) (*detector.Dependencies, error) {
+	startRun()
+
+	remote.retries = *fetchRetriesFlag
+	remote.budget = *fetchBudgetFlag
+
+	depInput, err := detectInput(inputFn)
+	if err != nil {
+		return nil, err
 	}
 	defer depInput.Close()
 
-	dependencies, err := detector.Detect(depInput, *includeIndirectFlag)
+	opts, err := detectOptions()
+	if err != nil {
+		return nil, err
+	}
+	if *onlyFlag != "" {
+		if opts.Cached, err = loadCachedResults(*baselineFlag, parseOnlyPatterns(*onlyFlag)); err != nil {
+			return nil, fmt.Errorf("failed to load previous results from %s: %w", *baselineFlag, err)
+		}
+	}
+
+	if *displayNamesFlag != "" {
+		if displayNames, err = loadDisplayNames(*displayNamesFlag); err != nil {
+			return nil, fmt.Errorf("failed to load display names from %s: %w", *displayNamesFlag, err)
+		}
+	}
+
+	if *headerFileFlag != "" {
+		if headerText, err = loadTextFile(*headerFileFlag); err != nil {
+			return nil, fmt.Errorf("failed to load header from %s: %w", *headerFileFlag, err)
+		}
+	}
+
+	if *footerFileFlag != "" {
+		if footerText, err = loadTextFile(*footerFileFlag); err != nil {
+			return nil, fmt.Errorf("failed to load footer from %s: %w", *footerFileFlag, err)
+		}
+	}
+
+	if *messagesFlag != "" {
+		if messages, err = loadMessages(*messagesFlag); err != nil {
+			return nil, fmt.Errorf("failed to load messages from %s: %w", *messagesFlag, err)
+		}
+	}
+
+	if *obligationsFlag != "" {
+		if obligationOverrides, err = loadObligations(*obligationsFlag); err != nil {
+			return nil, fmt.Errorf("failed to load obligations from %s: %w", *obligationsFlag, err)
+		}
+	}
+
+	if *ownersFlag != "" {
+		if owners, err = loadOwners(*ownersFlag); err != nil {
+			return nil, fmt.Errorf("failed to load owners from %s: %w", *ownersFlag, err)
+		}
+	}
+
+	var callbacks []func(detector.LicenceInfo, time.Duration)
+
+	var prof *profiler
+	if *profileFlag != "" {
+		prof = &profiler{}
+		callbacks = append(callbacks, prof.record)
+	}
+
+	var stream *streamWriter
+	if *formatFlag == streamFormat {
+		if stream, err = newStreamWriter(*outFlag); err != nil {
+			return nil, fmt.Errorf("failed to create output file %s: %w", *outFlag, err)
+		}
+		defer stream.close()
+		callbacks = append(callbacks, stream.record)
+	}
+
+	if *cacheDirFlag != "" {
+		cache, err := newDetectionCache(*cacheDirFlag, *cacheReadOnlyFlag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open detection cache %s: %w", *cacheDirFlag, err)
+		}
+		// the cached results do not record the files examined
+		if !*evidenceFlag {
+			opts.Cached = cache.cached(opts.Cached)
+		}
+		callbacks = append(callbacks, cache.record)
+	}
+
+	if len(callbacks) > 0 {
+		opts.OnModuleDetected = func(dep detector.LicenceInfo, elapsed time.Duration) {
+			for _, cb := range callbacks {
+				cb(dep, elapsed)
+			}
+		}
+	}
+
+	inputDigest := sha256.New()
+	inputFormat := *inputFormatFlag
+	if len(buildTargets()) > 0 {
+		inputFormat = "go-list"
+	}
+	input, err := convertInput(io.TeeReader(depInput, inputDigest), inputFormat)
 	if err != nil {
-		log.Fatalf("Failed to detect licences: %v", err)
+		return nil, fmt.Errorf("failed to read dependencies from %s: %w", inputsFlag.String(), err)
+	}
+
+	dependencies, err := detector.DetectWithOptions(input, opts)
+	var detectionErrs *detector.DetectionErrors
+	if *softFailFlag && errors.As(err, &detectionErrs) {
+		// the failed modules are rendered with their error in place of the licence
+		log.Printf("Failed to detect licences, continuing because of -soft-fail: %v", err)
+		err = nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect licences: %w", err)
+	}
+	currentRun.InputDigest = "sha256:" + hex.EncodeToString(inputDigest.Sum(nil))
+
+	if stream != nil {
+		if err := stream.close(); err != nil {
+			return nil, fmt.Errorf("failed to write results to %s: %w", *outFlag, err)
+		}
+	}
+
+	logWarnings(dependencies)
+	logSkipped(dependencies)
+
+	if *baselineFlag != "" {
+		baseline, err := loadBaseline(*baselineFlag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load baseline from %s: %w", *baselineFlag, err)
+		}
+		detector.CompareBaseline(dependencies, baseline)
+	}
+
+	if *osvFlag {
+		if err := lookupVulnerabilities(dependencies); err != nil {
+			return nil, fmt.Errorf("failed to look up vulnerabilities: %w", err)
+		}
+	}
+
+	if prof != nil {
+		if err := writeProfile(prof, *profileFlag); err != nil {
+			return nil, fmt.Errorf("failed to write profile to %s: %w", *profileFlag, err)
+		}
+	}
+
+	return dependencies, nil
+}
+
+// detectOptions returns the detection options set by the flags.
+func detectOptions() (*detector.Options, error) {
+	symlinks, err := detector.ParseSymlinkPolicy(*symlinksFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	errorStrategy, err := detector.ParseErrorStrategy(*errorsFlag)
+	if err != nil {
+		return nil, err
+	}
+	if *softFailFlag {
+		errorStrategy = detector.ErrorsCollect
+	}
+
+	if *policyFlag != "" {
+		if currentPolicy, err = loadPolicy(*policyFlag); err != nil {
+			return nil, fmt.Errorf("failed to load policy from %s: %w", *policyFlag, err)
+		}
+	}
+
+	opts := &detector.Options{
+		Indirect:       indirectPolicy(currentPolicy),
+		MaxDepth:       *maxDepthFlag,
+		Symlinks:       symlinks,
+		MaxLicenceSize: *maxLicenceSizeFlag,
+		ExecDetector:   strings.Fields(*execDetectorFlag),
+		SortedWalk:     *reproducibleFlag,
+		SkipMissing:    *skipMissingFlag,
+		Subcomponents:  *subcomponentsFlag,
+		ModuleTimeout:  *moduleTimeoutFlag,
+		Errors:         errorStrategy,
+		RecordEvidence: *evidenceFlag,
+		AllCandidates:  *allCandidatesFlag,
+	}
+	for _, pattern := range strings.Split(*licencePreferenceFlag, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			opts.LicencePreference = append(opts.LicencePreference, pattern)
+		}
+	}
+	if opts.Indirect == detector.IndirectLinked {
+		if *packagesFlag == "" {
+			return nil, fmt.Errorf("the linked indirect dependency policy requires -packages")
+		}
+		if opts.LinkedModules, err = loadLinkedModules(*packagesFlag); err != nil {
+			return nil, fmt.Errorf("failed to load packages from %s: %w", *packagesFlag, err)
+		}
+	}
+	if *licenceDirsFlag != "" {
+		if opts.LicenceDirs, err = loadLicenceDirs(*licenceDirsFlag); err != nil {
+			return nil, fmt.Errorf("failed to load licence directories from %s: %w", *licenceDirsFlag, err)
+		}
+	}
+	if *toolsFlag {
+		if opts.Tools, err = loadTools("."); err != nil {
+			return nil, fmt.Errorf("failed to load build tools: %w", err)
+		}
+	}
+	if *ignoreFlag != "" {
+		if opts.Ignore, err = loadIgnore(*ignoreFlag); err != nil {
+			return nil, fmt.Errorf("failed to load ignore rules from %s: %w", *ignoreFlag, err)
+		}
+	}
+	if *scanCodeFlag != "" {
+		opts.ScanCode, err = loadScanCode(*scanCodeFlag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load ScanCode results from %s: %w", *scanCodeFlag, err)
+		}
+	}
+	if *gopathFlag != "" {
+		if opts.GOPATH, err = loadGOPATHDependencies(*gopathFlag); err != nil {
+			return nil, fmt.Errorf("failed to load GOPATH dependencies from %s: %w", *gopathFlag, err)
+		}
+	}
+
+	if *supplementFlag != "" {
+		opts.Supplement, err = loadSupplement(*supplementFlag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load supplemental manifest from %s: %w", *supplementFlag, err)
+		}
+	}
+
+	return opts, nil
+}
+
+// logInfo logs informational messages unless -quiet or -porcelain is set.
+func logInfo(format string, v ...interface{}) {
+	if *quietFlag || *porcelainFlag {
+		return
+	}
+	log.Printf(format, v...)
+}
+
+// logWarnings summarises the non-fatal problems encountered while detecting licences.
+func logWarnings(dependencies *detector.Dependencies) {
+	var buf bytes.Buffer
+	for _, dep := range allDependencies(dependencies) {
+		for _, w := range dep.Warnings {
+			fmt.Fprintf(&buf, "\n  %s: %s", dep.Path, w)
+		}
+	}
+
+	if buf.Len() > 0 {
+		logInfo("Warnings:%s", buf.String())
+	}
+}
+
+// logSkipped summarises the modules of the input left out of the results, so that none is dropped unnoticed.
+func logSkipped(dependencies *detector.Dependencies) {
+	if len(dependencies.Skipped) == 0 {
+		return
 	}
 
-	if err := renderNotice(dependencies, *templateFlag, *outFlag); err != nil {
-		log.Fatalf("Failed to render notice: %v", err)
+	var reasons []string
+	counts := make(map[string]int)
+	var buf bytes.Buffer
+	for _, s := range dependencies.Skipped {
+		if counts[s.Reason] == 0 {
+			reasons = append(reasons, s.Reason)
+		}
+		counts[s.Reason]++
+
+		if s.Detail != "" {
+			fmt.Fprintf(&buf, "\n  %s: %s", s.Path, s.Detail)
+		}
 	}
+
+	summary := make([]string, len(reasons))
+	for i, reason := range reasons {
+		summary[i] = fmt.Sprintf("%s: %d", reason, counts[reason])
+	}
+	logInfo("Skipped %d modules of the input (%s)%s", len(dependencies.Skipped), strings.Join(summary, ", "), buf.String())
 }
 
 func mkReader(path string) (io.ReadCloser, error) {
@@ -50,31 +646,238 @@ func mkReader(path string) (io.ReadCloser, error) {
 		return ioutil.NopCloser(os.Stdin), nil
 	}
 
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return mkURLReader(path)
+	}
+
 	return os.Open(path)
 }
 
-func renderNotice(dependencies *detector.Dependencies, templatePath, outputPath string) error {
-	funcMap := template.FuncMap{
-		"currentYear": CurrentYear,
-		"line":        Line,
-		"licenceText": LicenceText,
+// mkURLReader fetches a dependency list published as a build artifact.
+func mkURLReader(url string) (io.ReadCloser, error) {
+	client := &http.Client{Timeout: 5 * time.Minute}
+
+	var body io.ReadCloser
+	err := remote.do(func() error {
+		resp, err := client.Get(url)
+		if err != nil {
+			return err
+		}
+
+		if err := checkResponseStatus(resp); err != nil {
+			resp.Body.Close()
+			return err
+		}
+
+		body = resp.Body
+		return nil
+	})
+
+	return body, err
+}
+
+func loadScanCode(path string) (*detector.ScanCodeResults, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
 	}
-	tmpl, err := template.New(filepath.Base(templatePath)).Funcs(funcMap).ParseFiles(templatePath)
+	defer f.Close()
+
+	return detector.ParseScanCode(f)
+}
+
+func loadIgnore(path string) ([]detector.IgnoreRule, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return fmt.Errorf("failed to parse template at %s: %w", templatePath, err)
+		return nil, err
 	}
+	defer f.Close()
 
-	w, cleanup, err := mkWriter(outputPath)
+	return detector.ParseIgnoreFile(f)
+}
+
+// loadLicenceDirs reads a JSON object mapping module paths to the directories holding their licence.
+func loadLicenceDirs(path string) (map[string]string, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return fmt.Errorf("failed to create output file %s: %w", outputPath, err)
+		return nil, err
+	}
+	defer f.Close()
+
+	var dirs map[string]string
+	if err := json.NewDecoder(f).Decode(&dirs); err != nil {
+		return nil, fmt.Errorf("failed to parse licence directories: %w", err)
 	}
-	defer cleanup()
 
-	if err := tmpl.Execute(w, dependencies); err != nil {
-		return fmt.Errorf("failed to render template: %w", err)
+	return dirs, nil
+}
+
+func loadGOPATHDependencies(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
 	}
+	defer f.Close()
 
-	return nil
+	return detector.ParseGOPATHDependencies(f, filepath.Dir(path), filepath.SplitList(build.Default.GOPATH))
+}
+
+func loadSupplement(path string) (*detector.Supplement, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return detector.ParseSupplement(f, filepath.Dir(path))
+}
+
+// renderNotice renders the notice and returns the paths of the files written, which are several if the notice was
+// split by -split-size or to fit -max-output-size.
+func renderNotice(dependencies *detector.Dependencies, templatePath, outputPath string) ([]string, error) {
+	tmpl, err := loadTemplate(templatePath, *presetFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted, err := sortedDependencies(dependencies, *sortFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxOutputSizeFlag == 0 && splitSizeFlag == 0 && *maxLineLengthFlag > 0 {
+		out, err := executeTemplate(tmpl, sorted)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeOutput(outputPath, out); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", outputPath, err)
+		}
+		return []string{outputPath}, nil
+	}
+
+	if maxOutputSizeFlag == 0 && splitSizeFlag == 0 {
+		w, cleanup, err := mkWriter(outputPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create output file %s: %w", outputPath, err)
+		}
+		defer cleanup()
+
+		if err := tmpl.Execute(w, sorted); err != nil {
+			return nil, fmt.Errorf("failed to render template: %w", err)
+		}
+
+		return []string{outputPath}, nil
+	}
+
+	var parts []noticePart
+	if splitSizeFlag > 0 {
+		parts, err = splitNotice(tmpl, sorted, int64(splitSizeFlag))
+	} else {
+		parts, err = fitNotice(tmpl, sorted, int64(maxOutputSizeFlag), *overflowFlag)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(parts) == 1 && splitSizeFlag == 0 {
+		if err := writeOutput(outputPath, parts[0].data); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", outputPath, err)
+		}
+		return []string{outputPath}, nil
+	}
+
+	// the parts are written next to the output file, which becomes their index
+	paths := splitPaths(outputPath, len(parts))
+	for i, path := range paths {
+		if err := writeOutput(path, parts[i].data); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+
+	var index bytes.Buffer
+	if err := writeSplitIndex(&index, paths, parts); err != nil {
+		return nil, err
+	}
+	if err := writeOutput(outputPath, index.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", outputPath, err)
+	}
+
+	return append([]string{outputPath}, paths...), nil
+}
+
+// templateFuncs are the functions available to notice templates.
+var templateFuncs = template.FuncMap{
+	"columns":         Columns,
+	"copyrightText":   CopyrightText,
+	"copyrightYears":  CopyrightYears,
+	"copyrights":      Copyrights,
+	"currentYear":     CurrentYear,
+	"displayName":     DisplayName,
+	"excerpt":         Excerpt,
+	"exclude":         Exclude,
+	"filterByLicence": FilterByLicence,
+	"filterByPrefix":  FilterByPrefix,
+	"firstParagraph":  FirstParagraph,
+	"footer":          Footer,
+	"generatedAt":     GeneratedAt,
+	"groupByFamily":   GroupByFamily,
+	"groupByLicence":  GroupByLicence,
+	"groupByOrg":      GroupByOrg,
+	"groupByProject":  GroupByProject,
+	"header":          Header,
+	"humanVersion":    HumanVersion,
+	"includeFile":     IncludeFile,
+	"join":            strings.Join,
+	"licenceFamily":   LicenceFamily,
+	"line":            Line,
+	"obligations":     ObligationsOf,
+	"licenceContents": LicenceContents,
+	"licencePath":     LicencePath,
+	"licenceText":     LicenceText,
+	"org":             Org,
+	"owner":           Owner,
+	"padRight":        PadRight,
+	"project":         Project,
+	"purl":            Purl,
+	"repoURL":         RepoURL,
+	"sortBy":          SortBy,
+	"spdxPretty":      SPDXPretty,
+	"spdxURL":         SPDXURL,
+	"table":           Table,
+	"targets":         Targets,
+	"toolVersion":     ToolVersion,
+	"upstream":        Upstream,
+	"vulnerabilities": Vulnerabilities,
+}
+
+// loadTemplate parses the template file, or the built-in template if a preset is given.
+func loadTemplate(templatePath, preset string) (*template.Template, error) {
+	if preset != "" {
+		text, ok := presets[preset]
+		if !ok {
+			return nil, fmt.Errorf("unknown preset %q: must be one of %s", preset, strings.Join(presetNames(), ", "))
+		}
+		// the presets are framed by the header and footer
+		return template.New(preset).Funcs(templateFuncs).Parse("{{ header }}" + text + "{{ footer }}")
+	}
+
+	tmpl, err := template.New(filepath.Base(templatePath)).Funcs(templateFuncs).ParseFiles(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template at %s: %w", templatePath, err)
+	}
+	return tmpl, nil
+}
+
+func writeOutput(path string, data []byte) error {
+	w, cleanup, err := mkWriter(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	_, err = w.Write(data)
+	return err
 }
 
 func mkWriter(path string) (io.Writer, func(), error) {
@@ -89,33 +892,195 @@ func mkWriter(path string) (io.Writer, func(), error) {
 /* Template functions */
 
 func CurrentYear() string {
-	return strconv.Itoa(time.Now().Year())
+	return strconv.Itoa(runYear())
+}
+
+// CopyrightYears renders the range of years from start, or from -inception-year if start is omitted, to the
+// current year.
+func CopyrightYears(start ...int) (string, error) {
+	if len(start) > 1 {
+		return "", fmt.Errorf("copyrightYears takes at most one argument, got %d", len(start))
+	}
+
+	from := *inceptionYearFlag
+	if len(start) == 1 {
+		from = start[0]
+	}
+
+	to := runYear()
+	if from == 0 || from >= to {
+		return strconv.Itoa(to), nil
+	}
+	return fmt.Sprintf("%d–%d", from, to), nil
+}
+
+func runYear() int {
+	if currentRun.time.IsZero() {
+		return time.Now().Year()
+	}
+	return currentRun.time.Year()
 }
 
 func Line(ch string) string {
 	return strings.Repeat(ch, 80)
 }
 
+// LicenceText renders the licence files of the dependency, each preceded by its path. Templates that control the
+// phrasing should use LicencePath and LicenceContents instead.
 func LicenceText(licInfo detector.LicenceInfo) string {
+	if licInfo.Error == detector.ErrLicenceNotFound {
+		return message(msgLicenceNotFound)
+	}
 	if licInfo.Error != nil {
 		return licInfo.Error.Error()
 	}
 
+	if truncatedLicences[licInfo.Path] {
+		return licenceReference(licInfo)
+	}
+
 	var buf bytes.Buffer
-	buf.WriteString("Contents of probable licence file ")
-	buf.WriteString(strings.Replace(licInfo.LicenceFile, goModCache, "$GOMODCACHE", -1))
-	buf.WriteString(":\n\n")
+	for i, licenceFile := range licenceFiles(licInfo) {
+		if i > 0 {
+			buf.WriteString("\n\n")
+		}
+		buf.WriteString(message(msgLicenceFile, "path", displayPath(licenceFile)))
+		buf.WriteString("\n\n")
+		buf.WriteString(readLicenceFile(licenceFile))
+	}
+
+	return buf.String()
+}
+
+// CopyrightText returns the contents of the copyright notice shipped by the dependency separately from its licence,
+// or an empty string if there is none.
+func CopyrightText(licInfo detector.LicenceInfo) string {
+	if licInfo.CopyrightFile == "" {
+		return ""
+	}
+	return readLicenceFile(licInfo.CopyrightFile)
+}
 
-	f, err := os.Open(licInfo.LicenceFile)
+// HumanVersion returns the version of the dependency, pseudo-versions being rendered as the short commit hash and
+// date, followed by the latest tag before the commit if any, as in abcdef1 (2023-01-02, after v1.2.3).
+func HumanVersion(licInfo detector.LicenceInfo) string {
+	version := effectiveModule(licInfo).Version
+	pv, ok := detector.ParsePseudoVersion(version)
+	if !ok {
+		return version
+	}
+
+	details := pv.Time.Format("2006-01-02")
+	if pv.Base != "" {
+		details += ", after " + pv.Base
+	}
+	return fmt.Sprintf("%.7s (%s)", pv.Commit, details)
+}
+
+// LicencePath returns the path of the licence file of the dependency, with the module cache directory replaced by
+// $GOMODCACHE, or an empty string if no licence file was found.
+func LicencePath(licInfo detector.LicenceInfo) string {
+	if licInfo.LicenceFile == "" {
+		return ""
+	}
+	return displayPath(licInfo.LicenceFile)
+}
+
+// LicenceContents returns the contents of the licence files of the dependency, separated by blank lines, or an empty
+// string if no licence file was found. A reference to the licence is returned instead if the text was left out to
+// fit -max-output-size.
+func LicenceContents(licInfo detector.LicenceInfo) (string, error) {
+	if licInfo.LicenceFile == "" {
+		return "", nil
+	}
+
+	if truncatedLicences[licInfo.Path] {
+		return licenceReference(licInfo), nil
+	}
+
+	files := licenceFiles(licInfo)
+	contents := make([]string, len(files))
+	for i, licenceFile := range files {
+		text, err := loadLicenceFile(licenceFile)
+		if err != nil {
+			return "", err
+		}
+		contents[i] = text
+	}
+	return strings.Join(contents, "\n\n"), nil
+}
+
+func licenceFiles(licInfo detector.LicenceInfo) []string {
+	if len(licInfo.LicenceFiles) > 0 {
+		return licInfo.LicenceFiles
+	}
+	return []string{licInfo.LicenceFile}
+}
+
+// readLicenceFile returns the text of a licence file, exiting if it cannot be read.
+func readLicenceFile(licenceFile string) string {
+	text, err := loadLicenceFile(licenceFile)
 	if err != nil {
-		log.Fatalf("Failed to open licence file %s: %v", licInfo.LicenceFile, err)
+		log.Fatal(err)
+	}
+	return text
+}
+
+// loadLicenceFile returns the text of a licence file. Files larger than -max-licence-bytes are streamed so that only
+// their beginning is held in memory, and their text is truncated at a line boundary and followed by a marker.
+func loadLicenceFile(licenceFile string) (string, error) {
+	f, err := os.Open(licenceFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read licence file %s: %w", licenceFile, err)
 	}
 	defer f.Close()
 
-	_, err = io.Copy(&buf, f)
+	var data []byte
+	digest := sha256.New()
+	src := io.TeeReader(f, digest)
+	if maxLicenceBytesFlag > 0 {
+		src = io.LimitReader(src, int64(maxLicenceBytesFlag))
+	}
+	if data, err = ioutil.ReadAll(src); err != nil {
+		return "", fmt.Errorf("failed to read licence file %s: %w", licenceFile, err)
+	}
+
+	// the remainder of a large file is only hashed
+	rest, err := io.Copy(digest, f)
 	if err != nil {
-		log.Fatalf("Failed to read licence file %s: %v", licInfo.LicenceFile, err)
+		return "", fmt.Errorf("failed to read licence file %s: %w", licenceFile, err)
+	}
+	size := int64(len(data)) + rest
+	if rest > 0 {
+		data = cutAtLine(data)
 	}
 
-	return buf.String()
+	text, err := detector.DecodeLicenceText(data, *strictEncodingFlag)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode licence file %s: %w", licenceFile, err)
+	}
+
+	if rest > 0 {
+		text += "\n\n" + message(msgLicenceTruncated,
+			"limit", strconv.FormatInt(int64(maxLicenceBytesFlag), 10),
+			"size", strconv.FormatInt(size, 10),
+			"digest", "sha256:"+hex.EncodeToString(digest.Sum(nil)))
+	}
+
+	return text, nil
+}
+
+// cutAtLine cuts the beginning of a truncated licence file after its last complete line, so that no character is
+// split. The NUL byte following the newline of UTF-16LE texts is kept.
+func cutAtLine(data []byte) []byte {
+	idx := bytes.LastIndexByte(data, '\n')
+	if idx < 0 {
+		return nil
+	}
+
+	end := idx + 1
+	if detector.DetectEncoding(data) == detector.EncodingUTF16LE && end < len(data) {
+		end++
+	}
+	return data[:end]
 }
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadLicenceFileTruncated(t *testing.T) {
+	f, err := ioutil.TempFile("", "LICENSE")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString("first line\nsecond line\nthird line\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	defer func(limit byteSizeFlag) { maxLicenceBytesFlag = limit }(maxLicenceBytesFlag)
+
+	maxLicenceBytesFlag = 0
+	require.Equal(t, "first line\nsecond line\nthird line\n", readLicenceFile(f.Name()))
+
+	maxLicenceBytesFlag = 20
+	text := readLicenceFile(f.Name())
+	require.True(t, strings.HasPrefix(text, "first line\n\n\n[Licence text truncated to 20 bytes: the complete file is 34 bytes"), text)
+	require.Contains(t, text, "sha256:")
+}
+
+func TestExcerpt(t *testing.T) {
+	testCases := []struct {
+		name string
+		n    int
+		text string
+		want string
+	}{
+		{name: "Short", n: 20, text: "MIT License\n", want: "MIT License"},
+		{name: "WordBoundary", n: 13, text: "Permission is hereby granted", want: "Permission is…"},
+		{name: "OnSpace", n: 10, text: "Permission is hereby granted", want: "Permission…"},
+		{name: "LongWord", n: 4, text: "Permission", want: "Perm…"},
+		{name: "Runes", n: 7, text: "Jürgen Müller", want: "Jürgen…"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, Excerpt(tc.n, tc.text))
+		})
+	}
+}
+
+func TestFirstParagraph(t *testing.T) {
+	text := "\r\n  MIT License\r\nCopyright (c) 2020\r\n \r\nPermission is hereby granted\n"
+	require.Equal(t, "  MIT License\nCopyright (c) 2020", FirstParagraph(text))
+}
+
+func TestResolveProjectPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "project")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	project := filepath.Join(dir, "project")
+	require.NoError(t, os.MkdirAll(filepath.Join(project, "legal"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(project, "legal", "EXPORT"), []byte("export notice\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "secret"), []byte("secret\n"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "secret"), filepath.Join(project, "link")))
+
+	testCases := []struct {
+		path    string
+		wantErr bool
+	}{
+		{path: "legal/EXPORT"},
+		{path: "legal/../legal/EXPORT"},
+		{path: "../secret", wantErr: true},
+		{path: "link", wantErr: true},
+		{path: filepath.Join(dir, "secret"), wantErr: true},
+		{path: "missing", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			_, err := resolveProjectPath(project, tc.path)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestGenerateSource(t *testing.T) {
+	src, err := generateSource("about", "Notice", "Notice is the notice.", []byte("Module  : \"example.com/a\"\n"), true)
+	require.NoError(t, err)
+	require.Equal(t, "// Code generated by licence-detector generate; DO NOT EDIT.\n\npackage about\n\n// Notice is the notice.\nconst Notice = \"Module  : \\\"example.com/a\\\"\\n\"\n", string(src))
+
+	src, err = generateSource("main", "Report", "Report is the report.", []byte{0x1f, 0x8b, 0x00}, false)
+	require.NoError(t, err)
+	require.Contains(t, string(src), "var Report = []byte(\"\\x1f\\x8b\\x00\")\n")
+}
+
+func TestPurl(t *testing.T) {
+	testCases := []struct {
+		name string
+		dep  detector.LicenceInfo
+		want string
+	}{
+		{
+			name: "module",
+			dep:  detector.LicenceInfo{Module: detector.Module{Path: "github.com/foo/bar", Version: "v1.2.3"}},
+			want: "pkg:golang/github.com/foo/bar@v1.2.3",
+		},
+		{
+			name: "incompatible",
+			dep:  detector.LicenceInfo{Module: detector.Module{Path: "github.com/foo/bar", Version: "v2.0.0+incompatible"}},
+			want: "pkg:golang/github.com/foo/bar@v2.0.0%2Bincompatible",
+		},
+		{
+			name: "replaced",
+			dep:  detector.LicenceInfo{Module: detector.Module{Path: "github.com/foo/bar", Version: "v1.2.3", Replace: &detector.Module{Path: "github.com/fork/bar", Version: "v1.2.4"}}},
+			want: "pkg:golang/github.com/fork/bar@v1.2.4",
+		},
+		{
+			name: "local replacement",
+			dep:  detector.LicenceInfo{Module: detector.Module{Path: "github.com/foo/bar", Version: "v1.2.3", Replace: &detector.Module{Path: "../bar"}}},
+			want: "pkg:golang/github.com/foo/bar@v1.2.3",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, Purl(tc.dep))
+		})
+	}
+}
+
+func TestWrapLines(t *testing.T) {
+	testCases := []struct {
+		name   string
+		text   string
+		maxLen int
+		want   string
+	}{
+		{name: "unlimited", text: "a long line of text\n", maxLen: 0, want: "a long line of text\n"},
+		{name: "short lines", text: "short\nlines\n", maxLen: 10, want: "short\nlines\n"},
+		{name: "at spaces", text: "a long line of text\nnext\n", maxLen: 10, want: "a long\nline of\ntext\nnext\n"},
+		{name: "space at limit", text: "0123456789 abc", maxLen: 10, want: "0123456789\nabc"},
+		{name: "within words", text: "abcdefghijklmnopqrstuvwxyz\n", maxLen: 10, want: "abcdefghij\nklmnopqrst\nuvwxyz\n"},
+		{name: "multi-byte", text: "éééééé ééé\r\n", maxLen: 8, want: "éééééé\nééé\r\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, string(wrapLines([]byte(tc.text), tc.maxLen)))
+		})
+	}
+}
+
+func TestEnforcePolicy(t *testing.T) {
+	defer func(p *policy) { currentPolicy = p }(currentPolicy)
+	defer func(v bool) { *softFailFlag = v }(*softFailFlag)
+	defer func(v string) { *violationsReportFlag = v }(*violationsReportFlag)
+	defer func(f func(int)) { osExit = f }(osExit)
+
+	currentPolicy = &policy{Deny: []string{"GPL-3.0"}}
+
+	dir, err := ioutil.TempDir("", "enforce")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dep := func(path string, licences ...string) detector.LicenceInfo {
+		return detector.LicenceInfo{Module: detector.Module{Path: path, Version: "v1.0.0"}, Licences: licences}
+	}
+	relicensed := &detector.Changelog{Relicensed: []detector.ChangelogEntry{{Path: "example.com/relicensed", OldLicences: []string{"MIT"}, NewLicences: []string{"BSD-3-Clause"}}}}
+
+	testCases := []struct {
+		name       string
+		softFail   bool
+		deps       []detector.LicenceInfo
+		changelog  *detector.Changelog
+		wantExit   []int
+		wantReport string
+	}{
+		{name: "Compliant", deps: []detector.LicenceInfo{dep("example.com/mit", "MIT")}, wantReport: "No policy violations"},
+		{name: "Violation", deps: []detector.LicenceInfo{dep("example.com/gpl", "GPL-3.0")}, wantExit: []int{exitPolicyViolation}, wantReport: "example.com/gpl"},
+		{name: "Relicensed", deps: []detector.LicenceInfo{dep("example.com/mit", "MIT")}, changelog: relicensed, wantExit: []int{exitRelicensed}},
+		{name: "ViolationAndRelicensed", deps: []detector.LicenceInfo{dep("example.com/gpl", "GPL-3.0")}, changelog: relicensed, wantExit: []int{exitPolicyViolation}, wantReport: "example.com/gpl"},
+		{name: "SoftFailViolation", softFail: true, deps: []detector.LicenceInfo{dep("example.com/gpl", "GPL-3.0")}, wantReport: "example.com/gpl"},
+		{name: "SoftFailRelicensed", softFail: true, deps: []detector.LicenceInfo{dep("example.com/mit", "MIT")}, changelog: relicensed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			*softFailFlag = tc.softFail
+			*violationsReportFlag = filepath.Join(dir, tc.name+".txt")
+
+			var exits []int
+			osExit = func(code int) { exits = append(exits, code) }
+
+			enforcePolicy(&detector.Dependencies{Direct: tc.deps, Changelog: tc.changelog})
+			require.Equal(t, tc.wantExit, exits)
+
+			// the violations are reported whether or not the run fails
+			report, err := ioutil.ReadFile(*violationsReportFlag)
+			require.NoError(t, err)
+			require.Contains(t, string(report), tc.wantReport)
+		})
+	}
+}
+
+func TestDetectOptionsSoftFail(t *testing.T) {
+	defer func(v bool) { *softFailFlag = v }(*softFailFlag)
+	defer func(v string) { *errorsFlag = v }(*errorsFlag)
+
+	*errorsFlag = "fail-fast"
+
+	*softFailFlag = false
+	opts, err := detectOptions()
+	require.NoError(t, err)
+	require.Equal(t, detector.ErrorsFailFast, opts.Errors)
+
+	// -soft-fail implies -errors collect, so that every failure reaches the output
+	*softFailFlag = true
+	opts, err = detectOptions()
+	require.NoError(t, err)
+	require.Equal(t, detector.ErrorsCollect, opts.Errors)
+}
+
+func TestLicenceExpression(t *testing.T) {
+	testCases := []struct {
+		name string
+		dep  detector.LicenceInfo
+		want string
+	}{
+		{name: "Unknown", dep: detector.LicenceInfo{}, want: noAssertion},
+		{name: "Single", dep: detector.LicenceInfo{Licences: []string{"MIT"}}, want: "MIT"},
+		{name: "Several", dep: detector.LicenceInfo{Licences: []string{"BSD-3-Clause", "MIT"}}, want: "BSD-3-Clause AND MIT"},
+		{name: "Declared", dep: detector.LicenceInfo{Licences: []string{"Apache-2.0", "MIT"}, LicenceExpression: "MIT OR Apache-2.0"}, want: "MIT OR Apache-2.0"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, licenceExpression(tc.dep))
+		})
+	}
+}
+
+func mkExportDeps() *detector.Dependencies {
+	return &detector.Dependencies{
+		Direct: []detector.LicenceInfo{
+			{
+				Module:      detector.Module{Path: "example.com/mit", Version: "v1.0.0"},
+				LicenceFile: filepath.Join(goModCache, "example.com", "mit@v1.0.0", "LICENSE"),
+				Licences:    []string{"MIT"},
+			},
+			{
+				Module:      detector.Module{Path: "example.com/multi", Version: "v1.2.0"},
+				LicenceFile: `/src/multi/LICENSE, "dual".txt`,
+				Licences:    []string{"Apache-2.0", "BSD-3-Clause"},
+			},
+			{
+				Module:            detector.Module{Path: "example.com/dual", Version: "v0.3.0"},
+				LicenceFile:       "/src/dual/LICENSES/MIT.txt",
+				Licences:          []string{"Apache-2.0", "MIT"},
+				LicenceExpression: "MIT OR Apache-2.0",
+			},
+		},
+		Indirect: []detector.LicenceInfo{
+			{
+				Module: detector.Module{
+					Path:     "example.com/upstream",
+					Version:  "v1.0.0",
+					Indirect: true,
+					Replace:  &detector.Module{Path: "example.com/fork", Version: "v1.0.1-fork"},
+				},
+				LicenceFile: "/src/fork/COPYING",
+				Licences:    []string{"BSD-2-Clause"},
+			},
+			{
+				Module: detector.Module{Path: "example.com/unknown", Version: "v0.0.1", Indirect: true},
+			},
+		},
+		Tools: []detector.LicenceInfo{
+			{
+				Module:   detector.Module{Path: "example.com/tool", Version: "v2.0.0"},
+				Licences: []string{"ISC"},
+			},
+		},
+	}
+}
+
+func TestExport(t *testing.T) {
+	testCases := []struct {
+		name   string
+		export exportFunc
+		golden string
+	}{
+		{name: "CSV", export: exportCSV, golden: "csv.golden"},
+		{name: "Fossa", export: exportFossa, golden: "fossa.golden"},
+		{name: "Porcelain", export: writePorcelain, golden: "porcelain.golden"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, tc.export(&buf, mkExportDeps()))
+
+			golden := filepath.Join("testdata", "export", tc.golden)
+			if *updateGoldenFlag {
+				require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0755))
+				require.NoError(t, ioutil.WriteFile(golden, buf.Bytes(), 0644))
+			}
+
+			want, err := ioutil.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(want), buf.String())
+		})
+	}
+}
+
+func TestExportCSVParses(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, exportCSV(&buf, mkExportDeps()))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 7)
+	for _, r := range records {
+		require.Len(t, r, 6)
+	}
+	require.Equal(t, `/src/multi/LICENSE, "dual".txt`, records[2][5])
+	require.Equal(t, []string{"example.com/fork", "v1.0.1-fork", "BSD-2-Clause", "golang", "Transitive", "/src/fork/COPYING"}, records[4])
+}
+
+func TestWritePorcelainLines(t *testing.T) {
+	dependencies := mkExportDeps()
+
+	var buf bytes.Buffer
+	require.NoError(t, writePorcelain(&buf, dependencies))
+
+	// one JSON object per line and per dependency, in the order of the report
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	all := allDependencies(dependencies)
+	require.Len(t, lines, len(all))
+	for i, line := range lines {
+		var dep reportDependency
+		require.NoError(t, json.Unmarshal([]byte(line), &dep))
+		require.Equal(t, all[i].Path, dep.Path)
+	}
+}
+
+func mkTestdataModule(t *testing.T) detector.LicenceInfo {
+	t.Helper()
+
+	modDir, err := filepath.Abs(filepath.Join("testdata", "licences", "mod", "example.com", "multi@v1.0.0"))
+	require.NoError(t, err)
+
+	return detector.LicenceInfo{
+		Module:      detector.Module{Path: "example.com/multi", Version: "v1.0.0", Dir: modDir},
+		Licences:    []string{"Apache-2.0", "MIT"},
+		LicenceFile: filepath.Join(modDir, "LICENSES", "Apache-2.0.txt"),
+		LicenceFiles: []string{
+			filepath.Join(modDir, "LICENSES", "Apache-2.0.txt"),
+			filepath.Join(modDir, "LICENSES", "MIT.txt"),
+		},
+	}
+}
+
+func TestLicencePath(t *testing.T) {
+	defer func(cache string) { goModCache = cache }(goModCache)
+
+	dep := mkTestdataModule(t)
+
+	goModCache, _ = filepath.Abs(filepath.Join("testdata", "licences", "mod"))
+	require.Equal(t, filepath.Join("$GOMODCACHE", "example.com", "multi@v1.0.0", "LICENSES", "Apache-2.0.txt"), LicencePath(dep))
+
+	// paths outside of the module cache are kept as they are
+	goModCache = filepath.Join(string(filepath.Separator), "nonexistent", "mod")
+	require.Equal(t, dep.LicenceFile, LicencePath(dep))
+
+	require.Equal(t, "", LicencePath(detector.LicenceInfo{Module: dep.Module, Error: detector.ErrLicenceNotFound}))
+}
+
+func TestLicenceContents(t *testing.T) {
+	defer func(truncated map[string]bool) { truncatedLicences = truncated }(truncatedLicences)
+	truncatedLicences = nil
+
+	dep := mkTestdataModule(t)
+
+	t.Run("Files", func(t *testing.T) {
+		have, err := LicenceContents(dep)
+		require.NoError(t, err)
+		require.Equal(t, "Apache License\nVersion 2.0, January 2004\n\n\nMIT License\n\nCopyright (c) 2020 Example\n", have)
+	})
+
+	t.Run("SingleFile", func(t *testing.T) {
+		single := dep
+		single.LicenceFiles = nil
+		have, err := LicenceContents(single)
+		require.NoError(t, err)
+		require.Equal(t, "Apache License\nVersion 2.0, January 2004\n", have)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		have, err := LicenceContents(detector.LicenceInfo{Module: dep.Module, Error: detector.ErrLicenceNotFound})
+		require.NoError(t, err)
+		require.Equal(t, "", have)
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		truncatedLicences = map[string]bool{dep.Path: true}
+		defer func() { truncatedLicences = nil }()
+
+		have, err := LicenceContents(dep)
+		require.NoError(t, err)
+		require.Equal(t, licenceReference(dep), have)
+		require.Contains(t, have, "https://pkg.go.dev/example.com/multi@v1.0.0?tab=licenses")
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		missing := dep
+		missing.LicenceFiles = append([]string{}, dep.LicenceFiles...)
+		missing.LicenceFiles[1] = filepath.Join(dep.Dir, "LICENSES", "BSD-3-Clause.txt")
+
+		_, err := LicenceContents(missing)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "BSD-3-Clause.txt")
+	})
+}
+
+func TestLicenceContentsTemplateError(t *testing.T) {
+	dep := mkTestdataModule(t)
+	dep.LicenceFile = filepath.Join(dep.Dir, "LICENSE")
+	dep.LicenceFiles = nil
+
+	tmpl, err := template.New("notice").Funcs(template.FuncMap{"licenceContents": LicenceContents}).
+		Parse("{{ licenceContents . }}")
+	require.NoError(t, err)
+
+	// a licence file that cannot be read fails the rendering instead of exiting
+	err = tmpl.Execute(ioutil.Discard, dep)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to read licence file")
+}
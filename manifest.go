@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// manifestInputFlags are the flags naming the files read during a run, which are recorded in the run manifest.
+var manifestInputFlags = []string{
+	"baseline", "display-names", "footer-file", "gopath", "header-file", "ignore", "licence-dirs", "messages",
+	"obligations", "owners", "packages", "policy", "release-notes-template", "scancode", "supplement", "template",
+	"violations-template",
+}
+
+// runManifest records how the artifacts of a run were generated so that they can be archived along with them.
+type runManifest struct {
+	runMetadata
+	Inputs  []manifestFile `json:"inputs"`
+	Counts  manifestCounts `json:"counts"`
+	Outputs []manifestFile `json:"outputs"`
+}
+
+// manifestFile is a file read or written during the run. The digest of the dependency list is the one of the data
+// read, which may come from stdin or a URL.
+type manifestFile struct {
+	Path   string `json:"path"`
+	Digest string `json:"digest"`
+}
+
+type manifestCounts struct {
+	Direct   int            `json:"direct"`
+	Indirect int            `json:"indirect"`
+	Tools    int            `json:"tools"`
+	Removed  int            `json:"removed"`
+	Skipped  int            `json:"skipped"`
+	Unknown  int            `json:"unknown"`  // dependencies whose licence is unknown
+	Families map[string]int `json:"families"` // dependencies by licence family
+}
+
+// writeManifest writes the run manifest to path, outputs being the files written during the run. The standard output
+// is left out as it has no digest.
+func writeManifest(path string, dependencies *detector.Dependencies, outputs []string) error {
+	manifest := runManifest{
+		runMetadata: currentRun,
+		Inputs:      []manifestFile{{Path: redactURLs(inputsFlag.String()), Digest: currentRun.InputDigest}},
+		Counts: manifestCounts{
+			Direct:   len(dependencies.Direct),
+			Indirect: len(dependencies.Indirect),
+			Tools:    len(dependencies.Tools),
+			Skipped:  len(dependencies.Skipped),
+			Families: make(map[string]int),
+		},
+	}
+
+	if dependencies.Changelog != nil {
+		manifest.Counts.Removed = len(dependencies.Changelog.Removed)
+	}
+	for _, dep := range allDependencies(dependencies) {
+		if len(dep.Licences) == 0 {
+			manifest.Counts.Unknown++
+		}
+		manifest.Counts.Families[LicenceFamily(dep)]++
+	}
+
+	// the template is only read when rendering a notice without a preset
+	readsTemplate := *formatFlag == "notice" && *presetFlag == ""
+	for _, name := range manifestInputFlags {
+		f := flag.Lookup(name)
+		if f.Value.String() == "" || (name == "template" && !readsTemplate) {
+			continue
+		}
+
+		file, err := mkManifestFile(f.Value.String())
+		if err != nil {
+			return err
+		}
+		manifest.Inputs = append(manifest.Inputs, file)
+	}
+
+	for _, output := range outputs {
+		if output == "-" {
+			continue
+		}
+
+		file, err := mkManifestFile(output)
+		if err != nil {
+			return err
+		}
+		manifest.Outputs = append(manifest.Outputs, file)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(path, append(data, '\n'))
+}
+
+func mkManifestFile(path string) (manifestFile, error) {
+	digest, err := sha256File(path)
+	if err != nil {
+		return manifestFile{}, fmt.Errorf("failed to compute digest of %s: %w", path, err)
+	}
+	return manifestFile{Path: path, Digest: "sha256:" + digest}, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Keys of the boilerplate strings produced by the template functions.
+const (
+	msgLicenceFile      = "licenceFile"
+	msgLicenceNotFound  = "licenceNotFound"
+	msgLicenceOmitted   = "licenceOmitted"
+	msgLicenceTruncated = "licenceTruncated"
+)
+
+// defaultMessages are the boilerplate strings in English. The placeholders in braces are replaced with their value
+// when the message is rendered.
+var defaultMessages = map[string]string{
+	msgLicenceFile:      "Contents of probable licence file {path}:",
+	msgLicenceNotFound:  "failed to detect licence",
+	msgLicenceOmitted:   "Licence text omitted to limit the size of this file, see {url}",
+	msgLicenceTruncated: "[Licence text truncated to {limit} bytes: the complete file is {size} bytes with digest {digest}]",
+}
+
+// messages are the boilerplate strings overridden with -messages.
+var messages map[string]string
+
+// loadMessages reads a JSON object mapping message keys to the strings replacing the default ones.
+func loadMessages(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var msgs map[string]string
+	if err := json.NewDecoder(f).Decode(&msgs); err != nil {
+		return nil, fmt.Errorf("failed to parse messages: %w", err)
+	}
+
+	for key := range msgs {
+		if _, ok := defaultMessages[key]; !ok {
+			return nil, fmt.Errorf("unknown message %q", key)
+		}
+	}
+
+	return msgs, nil
+}
+
+// message renders the message with the given key, args being placeholder names followed by their value.
+func message(key string, args ...string) string {
+	msg, ok := messages[key]
+	if !ok {
+		msg = defaultMessages[key]
+	}
+
+	for i := 0; i+1 < len(args); i += 2 {
+		args[i] = "{" + args[i] + "}"
+	}
+	return strings.NewReplacer(args...).Replace(msg)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"time"
+)
+
+// runMetadata records how an output was produced so that it can be traced back to the run.
+type runMetadata struct {
+	ToolVersion string            `json:"toolVersion"`
+	GeneratedAt string            `json:"generatedAt"`
+	InputDigest string            `json:"inputDigest,omitempty"`
+	Flags       map[string]string `json:"flags,omitempty"`
+
+	time time.Time
+}
+
+var currentRun runMetadata
+
+// Set at build time with -ldflags "-X main.version=... -X main.commit=... -X main.buildDate=...".
+var (
+	version   string
+	commit    string
+	buildDate string
+)
+
+func printVersion(w io.Writer) {
+	c, d := commit, buildDate
+	if c == "" {
+		c = "unknown"
+	}
+	if d == "" {
+		d = "unknown"
+	}
+	fmt.Fprintf(w, "licence-detector %s (commit %s, built %s, %s)\n", ToolVersion(), c, d, runtime.Version())
+}
+
+// secretFlags are the flags whose values are left out of the run metadata, as webhook URLs usually embed a token.
+var secretFlags = map[string]bool{
+	"notify-webhook": true,
+}
+
+func startRun() {
+	now := time.Now()
+	if *reproducibleFlag {
+		// validateFlags ensures that SOURCE_DATE_EPOCH is valid
+		now, _ = sourceDateEpoch()
+	}
+
+	currentRun = runMetadata{
+		ToolVersion: ToolVersion(),
+		GeneratedAt: now.UTC().Format(time.RFC3339),
+		Flags:       make(map[string]string),
+		time:        now,
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		currentRun.Flags[f.Name] = redactURLs(f.Value.String())
+		if secretFlags[f.Name] {
+			currentRun.Flags[f.Name] = "<redacted>"
+		}
+	})
+}
+
+var urlRegex = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s,|]+`)
+
+// redactURLs strips the credentials from the URLs found in a flag value, such as the user:password@ of a cache or
+// proxy URL, along with the values of their query parameters, which may hold access tokens.
+func redactURLs(value string) string {
+	return urlRegex.ReplaceAllStringFunc(value, func(s string) string {
+		u, err := url.Parse(s)
+		if err != nil {
+			return "<redacted>"
+		}
+
+		u.User = nil
+		if u.RawQuery != "" {
+			query := u.Query()
+			for k := range query {
+				query[k] = []string{"redacted"}
+			}
+			u.RawQuery = query.Encode()
+		}
+		return u.String()
+	})
+}
+
+// sourceDateEpoch returns the time set by the SOURCE_DATE_EPOCH environment variable, which reproducible builds use
+// in place of the current time. See https://reproducible-builds.org/specs/source-date-epoch/
+func sourceDateEpoch() (time.Time, error) {
+	value, ok := os.LookupEnv("SOURCE_DATE_EPOCH")
+	if !ok {
+		return time.Time{}, errors.New("SOURCE_DATE_EPOCH is not set")
+	}
+
+	secs, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %w", value, err)
+	}
+
+	return time.Unix(secs, 0).UTC(), nil
+}
+
+/* Template functions */
+
+func ToolVersion() string {
+	if version != "" {
+		return version
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Version != "" {
+		return bi.Main.Version
+	}
+	return "(devel)"
+}
+
+func GeneratedAt() string {
+	return currentRun.GeneratedAt
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// displayNames maps module paths to human-friendly project names.
+var displayNames map[string]string
+
+// loadDisplayNames reads a JSON object mapping module paths to display names.
+func loadDisplayNames(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var names map[string]string
+	if err := json.NewDecoder(f).Decode(&names); err != nil {
+		return nil, fmt.Errorf("failed to parse display names: %w", err)
+	}
+
+	return names, nil
+}
+
+/* Template functions */
+
+// DisplayName returns the display name of the dependency, falling back to its module path. A display name given for
+// the module path without its major version suffix applies to every major version.
+func DisplayName(dep detector.LicenceInfo) string {
+	if name, ok := displayNames[dep.Path]; ok {
+		return name
+	}
+	if name, ok := displayNames[detector.ModulePathBase(dep.Path)]; ok {
+		return name
+	}
+	return dep.Path
+}
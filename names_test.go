@@ -0,0 +1,89 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisplayName(t *testing.T) {
+	defer func(names map[string]string) { displayNames = names }(displayNames)
+
+	displayNames = map[string]string{
+		"github.com/elastic/go-ucfg":  "Elastic go-ucfg",
+		"github.com/foo/bar":          "Foo Bar",
+		"github.com/foo/bar/v3":       "Foo Bar 3",
+		"gopkg.in/yaml":               "YAML for Go",
+		"go.uber.org/zap":             "Zap",
+		"golang.org/x/net":            "Go networking",
+		"github.com/versionless/v":    "Versionless",
+		"github.com/elastic/go-ucfg2": "Elastic go-ucfg 2",
+	}
+
+	testCases := []struct {
+		path string
+		want string
+	}{
+		{path: "github.com/elastic/go-ucfg", want: "Elastic go-ucfg"},
+		{path: "github.com/elastic/go-ucfg2", want: "Elastic go-ucfg 2"},
+		{path: "github.com/elastic/go-ucfg/v2", want: "Elastic go-ucfg"},
+		// an override of a specific major version takes precedence over the one of the base path
+		{path: "github.com/foo/bar/v3", want: "Foo Bar 3"},
+		{path: "github.com/foo/bar/v2", want: "Foo Bar"},
+		{path: "github.com/foo/bar", want: "Foo Bar"},
+		// only valid major version suffixes are stripped
+		{path: "github.com/foo/bar/v1", want: "github.com/foo/bar/v1"},
+		{path: "github.com/foo/bar/v02", want: "github.com/foo/bar/v02"},
+		{path: "github.com/foo/bar/vendor", want: "github.com/foo/bar/vendor"},
+		{path: "github.com/foo/bar/sub", want: "github.com/foo/bar/sub"},
+		{path: "gopkg.in/yaml.v2", want: "YAML for Go"},
+		{path: "gopkg.in/yaml.v3", want: "YAML for Go"},
+		{path: "go.uber.org/zap", want: "Zap"},
+		{path: "go.uber.org/zap/v2", want: "Zap"},
+		{path: "go.uber.org/atomic", want: "go.uber.org/atomic"},
+		{path: "golang.org/x/net", want: "Go networking"},
+		{path: "golang.org/x/text", want: "golang.org/x/text"},
+		{path: "github.com/versionless/v", want: "Versionless"},
+		{path: "k8s.io/client-go", want: "k8s.io/client-go"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			dep := detector.LicenceInfo{Module: detector.Module{Path: tc.path, Version: "v1.0.0"}}
+			require.Equal(t, tc.want, DisplayName(dep))
+		})
+	}
+}
+
+func TestDisplayNameWithoutOverrides(t *testing.T) {
+	defer func(names map[string]string) { displayNames = names }(displayNames)
+
+	displayNames = nil
+	dep := detector.LicenceInfo{Module: detector.Module{Path: "github.com/foo/bar/v2"}}
+	require.Equal(t, "github.com/foo/bar/v2", DisplayName(dep))
+}
+
+func TestLoadDisplayNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "names")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	valid := filepath.Join(dir, "valid.json")
+	require.NoError(t, ioutil.WriteFile(valid, []byte(`{"go.uber.org/zap": "Zap"}`), 0600))
+	names, err := loadDisplayNames(valid)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"go.uber.org/zap": "Zap"}, names)
+
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, ioutil.WriteFile(invalid, []byte(`["go.uber.org/zap"]`), 0600))
+	_, err = loadDisplayNames(invalid)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to parse display names")
+
+	_, err = loadDisplayNames(filepath.Join(dir, "missing.json"))
+	require.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// webhookPayload is the summary posted to -notify-webhook. Text is a human-readable summary, as expected by Slack
+// incoming webhooks, and the other fields hold the details for other consumers.
+type webhookPayload struct {
+	Text       string                    `json:"text"`
+	New        []detector.ChangelogEntry `json:"new,omitempty"`
+	Removed    []detector.ChangelogEntry `json:"removed,omitempty"`
+	Violations []webhookViolation        `json:"violations,omitempty"`
+	Unknown    []string                  `json:"unknown,omitempty"` // module paths of the dependencies of unknown licence
+}
+
+type webhookViolation struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Licence string `json:"licence"`
+	Expired bool   `json:"expired,omitempty"` // an exception expired
+	Rule    string `json:"rule,omitempty"`    // name of the deny rule
+	Reason  string `json:"reason,omitempty"`  // explanation of the deny rule
+}
+
+func mkWebhookPayload(dependencies *detector.Dependencies, violations []policyViolation) webhookPayload {
+	var p webhookPayload
+	if dependencies.Changelog != nil {
+		p.New, p.Removed = dependencies.Changelog.New, dependencies.Changelog.Removed
+	}
+
+	for _, v := range violations {
+		wv := webhookViolation{
+			Path:    v.Dependency.Path,
+			Version: v.Dependency.Version,
+			Licence: v.Licence,
+			Expired: v.Expired != nil,
+		}
+		if v.Rule != nil {
+			wv.Rule, wv.Reason = v.Rule.Name, v.Rule.explain()
+		}
+		p.Violations = append(p.Violations, wv)
+	}
+
+	for _, dep := range allDependencies(dependencies) {
+		if len(dep.Licences) == 0 {
+			p.Unknown = append(p.Unknown, dep.Path)
+		}
+	}
+
+	var lines []string
+	if len(p.New) > 0 {
+		lines = append(lines, fmt.Sprintf("%d new dependencies", len(p.New)))
+	}
+	if len(p.Removed) > 0 {
+		lines = append(lines, fmt.Sprintf("%d removed dependencies", len(p.Removed)))
+	}
+	if len(p.Violations) > 0 {
+		lines = append(lines, fmt.Sprintf("%d policy violations", len(p.Violations)))
+	}
+	if len(p.Unknown) > 0 {
+		lines = append(lines, fmt.Sprintf("%d dependencies of unknown licence: %s", len(p.Unknown), strings.Join(p.Unknown, ", ")))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "no changes")
+	}
+	p.Text = "Licence detection: " + strings.Join(lines, "\n")
+
+	return p
+}
+
+// notifyWebhook posts a summary of the run to the webhook URL. The URL is left out of the errors as it usually
+// embeds a secret.
+func notifyWebhook(url string, dependencies *detector.Dependencies, violations []policyViolation) error {
+	body, err := json.Marshal(mkWebhookPayload(dependencies, violations))
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	return remote.do(func() error {
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return permanentError{fmt.Errorf("invalid webhook URL")}
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			var urlErr *neturl.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			return fmt.Errorf("failed to post to webhook: %w", err)
+		}
+		defer resp.Body.Close()
+
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			return nil
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			return fmt.Errorf("unexpected webhook response status: %s", resp.Status)
+		default:
+			return permanentError{fmt.Errorf("unexpected webhook response status: %s", resp.Status)}
+		}
+	})
+}
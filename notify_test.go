@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyWebhook(t *testing.T) {
+	var got webhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	unknown := detector.LicenceInfo{Module: detector.Module{Path: "example.com/unknown", Version: "v1.0.0"}}
+	dependencies := &detector.Dependencies{
+		Direct: []detector.LicenceInfo{
+			{Module: detector.Module{Path: "example.com/gpl", Version: "v1.0.0"}, Licences: []string{"GPL-3.0"}},
+			unknown,
+		},
+		Changelog: &detector.Changelog{
+			New:     []detector.ChangelogEntry{{Path: "example.com/gpl", NewVersion: "v1.0.0"}},
+			Removed: []detector.ChangelogEntry{{Path: "example.com/old", OldVersion: "v0.1.0"}},
+		},
+	}
+	violations := []policyViolation{{Dependency: dependencies.Direct[0], Licence: "GPL-3.0"}}
+
+	require.NoError(t, notifyWebhook(srv.URL, dependencies, violations))
+	require.Equal(t, webhookPayload{
+		Text:       "Licence detection: 1 new dependencies\n1 removed dependencies\n1 policy violations\n1 dependencies of unknown licence: example.com/unknown",
+		New:        dependencies.Changelog.New,
+		Removed:    dependencies.Changelog.Removed,
+		Violations: []webhookViolation{{Path: "example.com/gpl", Version: "v1.0.0", Licence: "GPL-3.0"}},
+		Unknown:    []string{"example.com/unknown"},
+	}, got)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// obligationOverrides replace the built-in obligations of the licences they list.
+var obligationOverrides map[string]detector.Obligations
+
+// loadObligations reads a JSON object mapping SPDX identifiers to obligations.
+func loadObligations(path string) (map[string]detector.Obligations, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var obligations map[string]detector.Obligations
+	if err := json.NewDecoder(f).Decode(&obligations); err != nil {
+		return nil, fmt.Errorf("failed to parse obligations: %w", err)
+	}
+
+	return obligations, nil
+}
+
+// LicenceObligations are the obligations of one of the licences of a dependency. Known is false if the licence is
+// not in the knowledge base, in which case the obligations must be reviewed manually.
+type LicenceObligations struct {
+	Licence string
+	Known   bool
+	detector.Obligations
+}
+
+/* Template functions */
+
+// ObligationsOf returns the obligations of each licence of the dependency.
+func ObligationsOf(dep detector.LicenceInfo) []LicenceObligations {
+	obligations := make([]LicenceObligations, len(dep.Licences))
+	for i, id := range dep.Licences {
+		o, known := detector.LicenceObligations(id, obligationOverrides)
+		obligations[i] = LicenceObligations{Licence: id, Known: known, Obligations: o}
+	}
+	return obligations
+}
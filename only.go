@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// parseOnlyPatterns returns the module path patterns of -only.
+func parseOnlyPatterns(value string) []string {
+	var patterns []string
+	for _, p := range strings.Split(value, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// loadCachedResults returns the Options.Cached function serving the results recorded in the report at path for the
+// modules that match none of the patterns. Results are only reused for the same version of a module, and when their
+// licence file still exists, as the module cache may have been cleaned since.
+func loadCachedResults(path string, patterns []string) (func(detector.Module) (detector.LicenceInfo, bool), error) {
+	r, err := loadReport(path)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := make(map[string]reportDependency)
+	for _, deps := range [][]reportDependency{r.Direct, r.Indirect, r.Tools} {
+		for _, dep := range deps {
+			previous[dep.Path] = dep
+		}
+	}
+
+	return func(mod detector.Module) (detector.LicenceInfo, bool) {
+		if matchPrefixPatterns(patterns, mod.Path) {
+			return detector.LicenceInfo{}, false
+		}
+
+		rd, ok := previous[mod.Path]
+		if !ok {
+			return detector.LicenceInfo{}, false
+		}
+		return cachedResult(rd, mod)
+	}, nil
+}
+
+// cachedResult converts the result of a previous run back to the detection result of the module.
+func cachedResult(rd reportDependency, mod detector.Module) (detector.LicenceInfo, bool) {
+	src, version := mod.Path, mod.Version
+	if mod.Replace != nil {
+		src, version = mod.Replace.Path, mod.Replace.Version
+	}
+	prevSrc, prevVersion := rd.Path, rd.Version
+	if rd.Replace != nil {
+		prevSrc, prevVersion = rd.Replace.Path, rd.Replace.Version
+	}
+	if src != prevSrc || version != prevVersion {
+		return detector.LicenceInfo{}, false
+	}
+
+	dep := detector.LicenceInfo{
+		Licences:      rd.Licences,
+		Language:      rd.Language,
+		LicenceFile:   cachedPath(rd.LicenceFile),
+		CopyrightFile: cachedPath(rd.CopyrightFile),
+		Source:        rd.Source,
+		Warnings:      rd.Warnings,
+	}
+
+	switch rd.Error {
+	case "":
+	case detector.ErrLicenceNotFound.Error():
+		dep.Error = detector.ErrLicenceNotFound
+	default:
+		// other errors, such as timeouts, may not happen again
+		return detector.LicenceInfo{}, false
+	}
+
+	if dep.LicenceFile != "" {
+		if _, err := os.Stat(dep.LicenceFile); err != nil {
+			return detector.LicenceInfo{}, false
+		}
+	}
+
+	for _, c := range rd.CandidateFiles {
+		dep.CandidateFiles = append(dep.CandidateFiles, cachedPath(c.Path))
+	}
+	for _, sc := range rd.Subcomponents {
+		dep.Subcomponents = append(dep.Subcomponents, detector.Subcomponent{Dir: sc.Dir, Licences: sc.Licences, LicenceFile: cachedPath(sc.LicenceFile)})
+	}
+
+	return dep, true
+}
+
+// cachedPath reverts displayPath.
+func cachedPath(path string) string {
+	return strings.Replace(path, "$GOMODCACHE", goModCache, -1)
+}
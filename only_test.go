@@ -0,0 +1,70 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedResult(t *testing.T) {
+	f, err := ioutil.TempFile("", "LICENSE")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	defer os.Remove(f.Name())
+
+	previous := reportDependency{
+		Path:           "example.com/a",
+		Version:        "v1.0.0",
+		Licences:       []string{"MIT"},
+		LicenceFile:    f.Name(),
+		CandidateFiles: []reportCandidate{{Path: f.Name(), Chosen: true}},
+		Source:         detector.SourceFile,
+	}
+
+	testCases := []struct {
+		name     string
+		previous reportDependency
+		mod      detector.Module
+		wantOK   bool
+	}{
+		{name: "same version", previous: previous, mod: detector.Module{Path: "example.com/a", Version: "v1.0.0"}, wantOK: true},
+		{name: "new version", previous: previous, mod: detector.Module{Path: "example.com/a", Version: "v1.1.0"}},
+		{
+			name:     "replaced",
+			previous: previous,
+			mod:      detector.Module{Path: "example.com/a", Version: "v1.0.0", Replace: &detector.Module{Path: "example.com/fork", Version: "v1.0.0"}},
+		},
+		{
+			name:     "licence file removed",
+			previous: reportDependency{Path: "example.com/a", Version: "v1.0.0", LicenceFile: f.Name() + ".missing"},
+			mod:      detector.Module{Path: "example.com/a", Version: "v1.0.0"},
+		},
+		{
+			name:     "licence not found",
+			previous: reportDependency{Path: "example.com/a", Version: "v1.0.0", Error: detector.ErrLicenceNotFound.Error()},
+			mod:      detector.Module{Path: "example.com/a", Version: "v1.0.0"},
+			wantOK:   true,
+		},
+		{
+			name:     "timeout",
+			previous: reportDependency{Path: "example.com/a", Version: "v1.0.0", Error: "licence detection timed out after 1s"},
+			mod:      detector.Module{Path: "example.com/a", Version: "v1.0.0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, ok := cachedResult(tc.previous, tc.mod)
+			require.Equal(t, tc.wantOK, ok)
+		})
+	}
+
+	dep, ok := cachedResult(previous, detector.Module{Path: "example.com/a", Version: "v1.0.0"})
+	require.True(t, ok)
+	require.Equal(t, []string{"MIT"}, dep.Licences)
+	require.Equal(t, f.Name(), dep.LicenceFile)
+	require.Equal(t, []string{f.Name()}, dep.CandidateFiles)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// osvBatchURL is the endpoint of the OSV.dev batch query API.
+var osvBatchURL = "https://api.osv.dev/v1/querybatch"
+
+// osvBatchSize is the maximum number of queries in a batch accepted by OSV.dev.
+const osvBatchSize = 1000
+
+type osvQuery struct {
+	Package osvPackage `json:"package"`
+	Version string     `json:"version"`
+}
+
+type osvPackage struct {
+	Name      string `json:"name"`
+	Ecosystem string `json:"ecosystem"`
+}
+
+type osvBatchResponse struct {
+	Results []struct {
+		Vulns []struct {
+			ID string `json:"id"`
+		} `json:"vulns"`
+	} `json:"results"`
+}
+
+// vulnerabilities holds the IDs of the known vulnerabilities of each module version, by path@version.
+var vulnerabilities map[string][]string
+
+// lookupVulnerabilities queries OSV.dev for the known vulnerabilities of the modules built for the dependencies and
+// records their IDs in vulnerabilities. Dependencies replaced by a local directory have no published version and are
+// not looked up.
+func lookupVulnerabilities(dependencies *detector.Dependencies) error {
+	vulnerabilities = make(map[string][]string)
+
+	var queries []osvQuery
+	var keys []string
+	seen := make(map[string]bool)
+	for _, dep := range allDependencies(dependencies) {
+		mod := effectiveModule(dep)
+		key := mod.Path + "@" + mod.Version
+		if mod.Version == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		// OSV records the versions of Go modules without the v prefix
+		queries = append(queries, osvQuery{Package: osvPackage{Name: mod.Path, Ecosystem: "Go"}, Version: strings.TrimPrefix(mod.Version, "v")})
+		keys = append(keys, key)
+	}
+
+	client := &http.Client{Timeout: 60 * time.Second}
+	for start := 0; start < len(queries); start += osvBatchSize {
+		end := start + osvBatchSize
+		if end > len(queries) {
+			end = len(queries)
+		}
+
+		resp, err := queryOSV(client, queries[start:end])
+		if err != nil {
+			return err
+		}
+		if len(resp.Results) != end-start {
+			return fmt.Errorf("OSV.dev returned %d results for %d queries", len(resp.Results), end-start)
+		}
+
+		for i, result := range resp.Results {
+			for _, v := range result.Vulns {
+				vulnerabilities[keys[start+i]] = append(vulnerabilities[keys[start+i]], v.ID)
+			}
+		}
+	}
+
+	return nil
+}
+
+func queryOSV(client *http.Client, queries []osvQuery) (*osvBatchResponse, error) {
+	body, err := json.Marshal(struct {
+		Queries []osvQuery `json:"queries"`
+	}{Queries: queries})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &osvBatchResponse{}
+	err = remote.do(func() error {
+		resp, err := client.Post(osvBatchURL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to query OSV.dev: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if err := checkResponseStatus(resp); err != nil {
+			return err
+		}
+		return json.NewDecoder(resp.Body).Decode(result)
+	})
+	return result, err
+}
+
+/* Template functions */
+
+// Vulnerabilities returns the IDs of the known vulnerabilities of the module built for the dependency, as found on
+// OSV.dev with -osv.
+func Vulnerabilities(licInfo detector.LicenceInfo) []string {
+	mod := effectiveModule(licInfo)
+	return vulnerabilities[mod.Path+"@"+mod.Version]
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupVulnerabilities(t *testing.T) {
+	var got struct {
+		Queries []osvQuery `json:"queries"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Write([]byte(`{"results": [{"vulns": [{"id": "GO-2020-0001"}, {"id": "GHSA-xxxx-yyyy-zzzz"}]}, {}]}`))
+	}))
+	defer srv.Close()
+
+	defer func(url string) { osvBatchURL = url }(osvBatchURL)
+	osvBatchURL = srv.URL
+	defer func() { vulnerabilities = nil }()
+
+	vulnerable := detector.LicenceInfo{Module: detector.Module{Path: "example.com/a", Version: "v1.0.0"}}
+	forked := detector.LicenceInfo{Module: detector.Module{Path: "example.com/b", Version: "v1.0.0", Replace: &detector.Module{Path: "example.com/fork", Version: "v1.0.1"}}}
+	local := detector.LicenceInfo{Module: detector.Module{Path: "example.com/c", Version: "v1.0.0", Replace: &detector.Module{Path: "../c"}}}
+	dependencies := &detector.Dependencies{Direct: []detector.LicenceInfo{vulnerable, forked}, Indirect: []detector.LicenceInfo{local}}
+
+	require.NoError(t, lookupVulnerabilities(dependencies))
+	require.Equal(t, []osvQuery{
+		{Package: osvPackage{Name: "example.com/a", Ecosystem: "Go"}, Version: "1.0.0"},
+		{Package: osvPackage{Name: "example.com/fork", Ecosystem: "Go"}, Version: "1.0.1"},
+	}, got.Queries)
+	require.Equal(t, []string{"GO-2020-0001", "GHSA-xxxx-yyyy-zzzz"}, Vulnerabilities(vulnerable))
+	require.Empty(t, Vulnerabilities(forked))
+	require.Empty(t, Vulnerabilities(local))
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// Strategies applied when the notice exceeds -max-output-size.
+const (
+	overflowFail     = "fail"
+	overflowTruncate = "truncate"
+	overflowSplit    = "split"
+)
+
+var overflowStrategies = []string{overflowFail, overflowTruncate, overflowSplit}
+
+// byteSizeFlag is a size in bytes that accepts the KB, MB and GB suffixes, which are powers of 1024.
+type byteSizeFlag int64
+
+var byteSizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{suffix: "GB", size: 1 << 30},
+	{suffix: "MB", size: 1 << 20},
+	{suffix: "KB", size: 1 << 10},
+	{suffix: "B", size: 1},
+}
+
+func (bs *byteSizeFlag) String() string {
+	return strconv.FormatInt(int64(*bs), 10)
+}
+
+func (bs *byteSizeFlag) Set(value string) error {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	unit := int64(1)
+	for _, u := range byteSizeUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			s, unit = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 || n > math.MaxInt64/unit {
+		return fmt.Errorf("invalid size %q", value)
+	}
+
+	*bs = byteSizeFlag(n * unit)
+	return nil
+}
+
+// truncatedLicences holds the paths of the dependencies whose licence text is replaced by a reference URL to keep
+// the notice within -max-output-size.
+var truncatedLicences map[string]bool
+
+// licenceReference returns the text rendered instead of the licence of a truncated dependency.
+func licenceReference(dep detector.LicenceInfo) string {
+	mod := effectiveModule(dep)
+	ref := "https://pkg.go.dev/" + mod.Path
+	if mod.Version != "" {
+		ref += "@" + mod.Version
+	}
+	return message(msgLicenceOmitted, "url", ref+"?tab=licenses")
+}
+
+// noticePart is a rendered notice file and the dependencies it holds.
+type noticePart struct {
+	data         []byte
+	dependencies []detector.LicenceInfo
+}
+
+func executeTemplate(tmpl *template.Template, dependencies *detector.Dependencies) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, dependencies); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return wrapLines(buf.Bytes(), *maxLineLengthFlag), nil
+}
+
+// fitNotice renders the notice and applies the overflow strategy if it is larger than maxSize. It returns the
+// contents of each output file.
+func fitNotice(tmpl *template.Template, dependencies *detector.Dependencies, maxSize int64, strategy string) ([]noticePart, error) {
+	out, err := executeTemplate(tmpl, dependencies)
+	if err != nil || int64(len(out)) <= maxSize {
+		return []noticePart{{data: out, dependencies: allDependencies(dependencies)}}, err
+	}
+
+	switch strategy {
+	case overflowTruncate:
+		out, err := truncateNotice(tmpl, dependencies, maxSize)
+		return []noticePart{{data: out, dependencies: allDependencies(dependencies)}}, err
+	case overflowSplit:
+		return splitNotice(tmpl, dependencies, maxSize)
+	default:
+		return nil, fmt.Errorf("notice is %d bytes, which exceeds the maximum of %d bytes", len(out), maxSize)
+	}
+}
+
+// truncateNotice replaces the licence texts with reference URLs, starting with the longest, until the notice fits.
+func truncateNotice(tmpl *template.Template, dependencies *detector.Dependencies, maxSize int64) ([]byte, error) {
+	type candidate struct {
+		path    string
+		savings int64
+	}
+
+	var candidates []candidate
+	for _, dep := range allDependencies(dependencies) {
+		savings := int64(len(LicenceText(dep)) - len(licenceReference(dep)))
+		if savings > 0 {
+			candidates = append(candidates, candidate{path: dep.Path, savings: savings})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].savings > candidates[j].savings
+	})
+
+	truncatedLicences = make(map[string]bool)
+	defer func() { truncatedLicences = nil }()
+
+	// the template may render a licence any number of times, so the savings only order the candidates and the size
+	// of the notice is measured by rendering it again after each truncation
+	for _, c := range candidates {
+		truncatedLicences[c.path] = true
+
+		out, err := executeTemplate(tmpl, dependencies)
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(out)) <= maxSize {
+			return out, nil
+		}
+	}
+
+	return nil, fmt.Errorf("notice exceeds the maximum of %d bytes even without licence texts", maxSize)
+}
+
+// splitNotice renders the dependencies across several notices, each within maxSize. The dependencies are split in
+// order and never across notices.
+func splitNotice(tmpl *template.Template, dependencies *detector.Dependencies, maxSize int64) ([]noticePart, error) {
+	var parts []noticePart
+	remaining := *dependencies
+	for len(remaining.Direct)+len(remaining.Indirect) > 0 {
+		out, n, err := renderLargestPart(tmpl, &remaining, maxSize)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, noticePart{data: out, dependencies: allDependencies(&remaining)[:n]})
+
+		if n <= len(remaining.Direct) {
+			remaining.Direct = remaining.Direct[n:]
+		} else {
+			remaining.Indirect = remaining.Indirect[n-len(remaining.Direct):]
+			remaining.Direct = nil
+		}
+	}
+
+	return parts, nil
+}
+
+// renderLargestPart renders the longest run of leading dependencies that fits within maxSize and returns the number
+// of dependencies it holds.
+func renderLargestPart(tmpl *template.Template, dependencies *detector.Dependencies, maxSize int64) ([]byte, int, error) {
+	total := len(dependencies.Direct) + len(dependencies.Indirect)
+	render := func(n int) ([]byte, error) {
+		part := *dependencies
+		if n <= len(part.Direct) {
+			part.Direct, part.Indirect = part.Direct[:n], nil
+		} else {
+			part.Indirect = part.Indirect[:n-len(part.Direct)]
+		}
+		return executeTemplate(tmpl, &part)
+	}
+
+	// binary search for the largest number of dependencies that fits
+	var best []byte
+	lo, hi := 1, total
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		out, err := render(mid)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if int64(len(out)) <= maxSize {
+			best, lo = out, mid+1
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	if best == nil {
+		dep := allDependencies(dependencies)[0]
+		return nil, 0, fmt.Errorf("notice for %s alone exceeds the maximum of %d bytes", dep.Path, maxSize)
+	}
+
+	return best, hi, nil
+}
+
+// splitPaths returns the paths of the numbered files holding the parts of a split notice: NOTICE.txt is split into
+// NOTICE-1.txt, NOTICE-2.txt and so on.
+func splitPaths(outputPath string, n int) []string {
+	ext := filepath.Ext(outputPath)
+	base := strings.TrimSuffix(outputPath, ext)
+
+	paths := make([]string, n)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("%s-%d%s", base, i+1, ext)
+	}
+	return paths
+}
+
+// writeSplitIndex writes the index of a split notice, listing the dependencies held by each file.
+func writeSplitIndex(w io.Writer, paths []string, parts []noticePart) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "The notice is split into %d files because of its size.\n", len(parts))
+
+	for i, part := range parts {
+		fmt.Fprintf(bw, "\n%s\n", filepath.Base(paths[i]))
+		for _, dep := range part.dependencies {
+			mod := effectiveModule(dep)
+			fmt.Fprintf(bw, "  %s %s\n", dep.Path, mod.Version)
+		}
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+const overflowTemplate = `NOTICE HEADER
+{{ range .Direct }}{{ .Path }}
+{{ licenceText . }}
+{{ end }}{{ range .Indirect }}{{ .Path }}
+{{ licenceText . }}
+{{ end }}`
+
+// mkOverflowDeps returns two direct and two indirect dependencies whose licence files are of decreasing sizes.
+func mkOverflowDeps(t *testing.T, dir string) *detector.Dependencies {
+	var deps []detector.LicenceInfo
+	for i, size := range []int{1000, 300, 200, 100} {
+		name := string(rune('a' + i))
+		path := filepath.Join(dir, name+".txt")
+		require.NoError(t, ioutil.WriteFile(path, []byte(strings.Repeat(name, size)), 0644))
+		deps = append(deps, detector.LicenceInfo{
+			Module:      detector.Module{Path: "example.com/" + name, Version: "v1.0.0"},
+			LicenceFile: path,
+		})
+	}
+	return &detector.Dependencies{Direct: deps[:2], Indirect: deps[2:]}
+}
+
+func mkOverflowTemplate() *template.Template {
+	return template.Must(template.New("overflow").Funcs(templateFuncs).Parse(overflowTemplate))
+}
+
+// renderTruncated renders the notice with the licence texts of the given dependencies replaced by their references.
+func renderTruncated(t *testing.T, tmpl *template.Template, dependencies *detector.Dependencies, paths ...string) []byte {
+	truncatedLicences = make(map[string]bool)
+	defer func() { truncatedLicences = nil }()
+	for _, p := range paths {
+		truncatedLicences[p] = true
+	}
+
+	out, err := executeTemplate(tmpl, dependencies)
+	require.NoError(t, err)
+	return out
+}
+
+func TestByteSizeFlag(t *testing.T) {
+	testCases := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{value: "0", want: 0},
+		{value: "512", want: 512},
+		{value: "512B", want: 512},
+		{value: "64KB", want: 64 << 10},
+		{value: "64kb", want: 64 << 10},
+		{value: "2MB", want: 2 << 20},
+		{value: " 2 MB ", want: 2 << 20},
+		{value: "1GB", want: 1 << 30},
+		{value: "", wantErr: true},
+		{value: "KB", wantErr: true},
+		{value: "-1KB", wantErr: true},
+		{value: "1.5MB", wantErr: true},
+		{value: "2K", wantErr: true},
+		{value: "1TB", wantErr: true},
+		{value: "MB2", wantErr: true},
+		{value: "9223372036854775807KB", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			var bs byteSizeFlag
+			err := bs.Set(tc.value)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, int64(bs))
+		})
+	}
+}
+
+func TestTruncateNotice(t *testing.T) {
+	dir, err := ioutil.TempDir("", "truncate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dependencies := mkOverflowDeps(t, dir)
+	tmpl := mkOverflowTemplate()
+
+	full, err := executeTemplate(tmpl, dependencies)
+	require.NoError(t, err)
+	largestTruncated := renderTruncated(t, tmpl, dependencies, "example.com/a")
+	allTruncated := renderTruncated(t, tmpl, dependencies, "example.com/a", "example.com/b", "example.com/c", "example.com/d")
+
+	testCases := []struct {
+		name    string
+		maxSize int64
+		want    []byte
+		wantErr bool
+	}{
+		{name: "AtLimit", maxSize: int64(len(full)), want: full},
+		{name: "JustOverLimit", maxSize: int64(len(full)) - 1, want: largestTruncated},
+		{name: "LargestTruncatedAtLimit", maxSize: int64(len(largestTruncated)), want: largestTruncated},
+		{name: "AllTruncatedAtLimit", maxSize: int64(len(allTruncated)), want: allTruncated},
+		{name: "BelowAllTruncated", maxSize: int64(len(allTruncated)) - 1, wantErr: true},
+		{name: "SmallerThanHeader", maxSize: 5, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parts, err := fitNotice(tmpl, dependencies, tc.maxSize, overflowTruncate)
+			if tc.wantErr {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "even without licence texts")
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, parts, 1)
+			require.Equal(t, string(tc.want), string(parts[0].data))
+			require.True(t, int64(len(parts[0].data)) <= tc.maxSize)
+			require.Nil(t, truncatedLicences)
+		})
+	}
+}
+
+func TestTruncateNoticeRepeatedLicences(t *testing.T) {
+	dir, err := ioutil.TempDir("", "truncate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dependencies := mkOverflowDeps(t, dir)
+
+	// the licence texts are rendered twice, so truncating one saves twice as much as estimated
+	tmpl := template.Must(template.New("overflow").Funcs(templateFuncs).Parse(`NOTICE HEADER
+{{ range .Direct }}{{ .Path }}
+{{ licenceText . }}
+{{ licenceContents . }}
+{{ end }}{{ range .Indirect }}{{ .Path }}
+{{ licenceText . }}
+{{ licenceContents . }}
+{{ end }}`))
+
+	full, err := executeTemplate(tmpl, dependencies)
+	require.NoError(t, err)
+	largestTruncated := renderTruncated(t, tmpl, dependencies, "example.com/a")
+	allTruncated := renderTruncated(t, tmpl, dependencies, "example.com/a", "example.com/b", "example.com/c", "example.com/d")
+
+	testCases := []struct {
+		name    string
+		maxSize int64
+		want    []byte
+		wantErr bool
+	}{
+		{name: "AtLimit", maxSize: int64(len(full)), want: full},
+		{name: "JustOverLimit", maxSize: int64(len(full)) - 1, want: largestTruncated},
+		{name: "LargestTruncatedAtLimit", maxSize: int64(len(largestTruncated)), want: largestTruncated},
+		{name: "AllTruncatedAtLimit", maxSize: int64(len(allTruncated)), want: allTruncated},
+		{name: "AllTruncatedJustOverLimit", maxSize: int64(len(allTruncated)) + 1, want: allTruncated},
+		{name: "BelowAllTruncated", maxSize: int64(len(allTruncated)) - 1, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parts, err := fitNotice(tmpl, dependencies, tc.maxSize, overflowTruncate)
+			if tc.wantErr {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "even without licence texts")
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, parts, 1)
+			require.True(t, int64(len(parts[0].data)) <= tc.maxSize)
+			require.Equal(t, string(tc.want), string(parts[0].data))
+		})
+	}
+}
+
+func TestRenderLargestPart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "largest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dependencies := mkOverflowDeps(t, dir)
+	tmpl := mkOverflowTemplate()
+
+	// the notices holding the first n dependencies, the third one being indirect
+	var prefixes [][]byte
+	for n := 1; n <= 4; n++ {
+		part := &detector.Dependencies{Direct: dependencies.Direct}
+		if n <= 2 {
+			part.Direct = dependencies.Direct[:n]
+		} else {
+			part.Indirect = dependencies.Indirect[:n-2]
+		}
+		out, err := executeTemplate(tmpl, part)
+		require.NoError(t, err)
+		prefixes = append(prefixes, out)
+	}
+
+	testCases := []struct {
+		name    string
+		maxSize int64
+		wantN   int
+		wantErr bool
+	}{
+		{name: "SmallerThanHeader", maxSize: 5, wantErr: true},
+		{name: "SmallerThanFirst", maxSize: int64(len(prefixes[0])) - 1, wantErr: true},
+		{name: "FirstAtLimit", maxSize: int64(len(prefixes[0])), wantN: 1},
+		{name: "BelowSecond", maxSize: int64(len(prefixes[1])) - 1, wantN: 1},
+		{name: "SecondAtLimit", maxSize: int64(len(prefixes[1])), wantN: 2},
+		{name: "AcrossIndirect", maxSize: int64(len(prefixes[2])), wantN: 3},
+		{name: "Everything", maxSize: int64(len(prefixes[3])) + 100, wantN: 4},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, n, err := renderLargestPart(tmpl, dependencies, tc.maxSize)
+			if tc.wantErr {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "notice for example.com/a alone exceeds the maximum")
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.wantN, n)
+			require.Equal(t, string(prefixes[n-1]), string(out))
+		})
+	}
+}
+
+func TestSplitNotice(t *testing.T) {
+	dir, err := ioutil.TempDir("", "split")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dependencies := mkOverflowDeps(t, dir)
+	tmpl := mkOverflowTemplate()
+
+	full, err := executeTemplate(tmpl, dependencies)
+	require.NoError(t, err)
+	largest, err := executeTemplate(tmpl, &detector.Dependencies{Direct: dependencies.Direct[:1]})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		maxSize   int64
+		wantParts [][]string
+		wantErr   bool
+	}{
+		{
+			name:      "LargestAtLimit",
+			maxSize:   int64(len(largest)),
+			wantParts: [][]string{{"example.com/a"}, {"example.com/b", "example.com/c", "example.com/d"}},
+		},
+		{
+			name:      "JustOverLimit",
+			maxSize:   int64(len(full)) - 1,
+			wantParts: [][]string{{"example.com/a", "example.com/b", "example.com/c"}, {"example.com/d"}},
+		},
+		{
+			name:    "DependencyTooLarge",
+			maxSize: int64(len(largest)) - 1,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parts, err := fitNotice(tmpl, dependencies, tc.maxSize, overflowSplit)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, parts, len(tc.wantParts))
+
+			for i, part := range parts {
+				require.True(t, int64(len(part.data)) <= tc.maxSize, fmt.Sprintf("part %d is %d bytes", i+1, len(part.data)))
+				require.True(t, bytes.HasPrefix(part.data, []byte("NOTICE HEADER\n")))
+
+				var paths []string
+				for _, dep := range part.dependencies {
+					paths = append(paths, dep.Path)
+					require.Contains(t, string(part.data), dep.Path+"\n")
+				}
+				require.Equal(t, tc.wantParts[i], paths)
+			}
+		})
+	}
+}
+
+func TestSplitPaths(t *testing.T) {
+	require.Equal(t, []string{"out/NOTICE-1.txt", "out/NOTICE-2.txt", "out/NOTICE-3.txt"}, splitPaths("out/NOTICE.txt", 3))
+	require.Equal(t, []string{"NOTICE-1", "NOTICE-2"}, splitPaths("NOTICE", 2))
+	require.Equal(t, []string{"out.d/NOTICE-1"}, splitPaths("out.d/NOTICE", 1))
+}
+
+func TestWriteSplitIndex(t *testing.T) {
+	parts := []noticePart{
+		{dependencies: []detector.LicenceInfo{
+			{Module: detector.Module{Path: "example.com/a", Version: "v1.0.0"}},
+			{Module: detector.Module{Path: "example.com/b", Version: "v1.0.0", Replace: &detector.Module{Path: "example.com/fork", Version: "v1.0.1"}}},
+		}},
+		{dependencies: []detector.LicenceInfo{
+			{Module: detector.Module{Path: "example.com/c", Version: "v0.1.0"}},
+		}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeSplitIndex(&buf, splitPaths("out/NOTICE.txt", len(parts)), parts))
+	require.Equal(t, `The notice is split into 2 files because of its size.
+
+NOTICE-1.txt
+  example.com/a v1.0.0
+  example.com/b v1.0.1
+
+NOTICE-2.txt
+  example.com/c v0.1.0
+`, buf.String())
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// unowned is the name of the review queue of the dependencies matched by no owner pattern.
+const unowned = "unowned"
+
+// ownerPattern assigns the modules matching a path pattern, as used by GOPRIVATE, to an owning team.
+type ownerPattern struct {
+	pattern string
+	owner   string
+}
+
+// owners are the owner patterns loaded with -owners, the most specific first.
+var owners []ownerPattern
+
+// loadOwners reads a JSON object mapping module path patterns to owning teams.
+func loadOwners(file string) ([]ownerPattern, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var byPattern map[string]string
+	if err := json.NewDecoder(f).Decode(&byPattern); err != nil {
+		return nil, fmt.Errorf("failed to parse owners: %w", err)
+	}
+
+	patterns := make([]ownerPattern, 0, len(byPattern))
+	for pattern, owner := range byPattern {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		patterns = append(patterns, ownerPattern{pattern: pattern, owner: owner})
+	}
+
+	// patterns with more path elements are more specific, then the longest
+	sort.Slice(patterns, func(i, j int) bool {
+		ni, nj := strings.Count(patterns[i].pattern, "/"), strings.Count(patterns[j].pattern, "/")
+		if ni != nj {
+			return ni > nj
+		}
+		if len(patterns[i].pattern) != len(patterns[j].pattern) {
+			return len(patterns[i].pattern) > len(patterns[j].pattern)
+		}
+		return patterns[i].pattern < patterns[j].pattern
+	})
+
+	return patterns, nil
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// writeReviewQueues writes the dependencies whose licence is unknown to one JSON file per owner in dir, so that the
+// follow-ups can be routed to the owning teams. It returns the paths of the files written.
+func writeReviewQueues(dependencies *detector.Dependencies, dir string) ([]string, error) {
+	queues := make(map[string][]reportDependency)
+	for _, dep := range allDependencies(dependencies) {
+		if len(dep.Licences) > 0 {
+			continue
+		}
+
+		owner := Owner(dep)
+		if owner == "" {
+			owner = unowned
+		}
+		queues[owner] = append(queues[owner], mkReportDependency(dep))
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for owner, deps := range queues {
+		data, err := json.MarshalIndent(deps, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		path := filepath.Join(dir, unsafeFileChars.ReplaceAllString(owner, "_")+".json")
+		if err := writeOutput(path, append(data, '\n')); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+/* Template functions */
+
+// Owner returns the team owning the dependency according to -owners, or an empty string if no pattern matches it.
+func Owner(dep detector.LicenceInfo) string {
+	for _, o := range owners {
+		if matchPrefixPatterns([]string{o.pattern}, dep.Path) {
+			return o.owner
+		}
+	}
+	return ""
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwner(t *testing.T) {
+	f, err := ioutil.TempFile("", "owners-*.json")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(`{"github.com/elastic": "platform", "github.com/elastic/go-*": "go-team", "*.io": "infra"}`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	owners, err = loadOwners(f.Name())
+	require.NoError(t, err)
+	defer func() { owners = nil }()
+
+	testCases := map[string]string{
+		"github.com/elastic/go-ucfg":     "go-team",
+		"github.com/elastic/go-ucfg/v2":  "go-team",
+		"github.com/elastic/beats/v7":    "platform",
+		"k8s.io/api":                     "infra",
+		"github.com/stretchr/testify":    "",
+		"github.com/elasticsearch/other": "",
+	}
+
+	for modPath, want := range testCases {
+		require.Equal(t, want, Owner(detector.LicenceInfo{Module: detector.Module{Path: modPath}}), modPath)
+	}
+
+	dir, err := ioutil.TempDir("", "review-queue")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	paths, err := writeReviewQueues(&detector.Dependencies{
+		Direct: []detector.LicenceInfo{
+			{Module: detector.Module{Path: "github.com/elastic/go-ucfg"}},
+			{Module: detector.Module{Path: "github.com/elastic/beats/v7"}, Licences: []string{"Apache-2.0"}},
+			{Module: detector.Module{Path: "github.com/stretchr/testify"}},
+		},
+	}, dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "go-team.json"), filepath.Join(dir, "unowned.json")}, paths)
+}
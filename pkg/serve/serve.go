@@ -0,0 +1,177 @@
+// Package serve exposes the third party attributions of a product at runtime from the report embedded by
+// licence-detector generate -format json.
+//
+//	//go:generate sh -c "go list -m -json all | licence-detector generate -format json -out report_gen.go"
+//
+//	handler, err := serve.Handler(Report)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	http.Handle("/about/licenses", handler)
+package serve
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	textTemplate "text/template"
+)
+
+// Report holds the dependencies of a machine-readable report written by licence-detector -format json.
+type Report struct {
+	Direct   []Dependency `json:"direct"`
+	Indirect []Dependency `json:"indirect,omitempty"`
+	Tools    []Dependency `json:"tools,omitempty"`
+}
+
+// Dependency is a dependency of the report.
+type Dependency struct {
+	Path        string   `json:"path"`
+	DisplayName string   `json:"displayName,omitempty"`
+	Version     string   `json:"version,omitempty"`
+	Replace     *Replace `json:"replace,omitempty"`
+	Licences    []string `json:"licences,omitempty"`
+}
+
+// Replace is the module replacing a dependency.
+type Replace struct {
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+}
+
+// Name returns the display name of the dependency, or its module path if it has none.
+func (d Dependency) Name() string {
+	if d.DisplayName != "" {
+		return d.DisplayName
+	}
+	return d.Path
+}
+
+// Licence returns the licence expression of the dependency, or Unknown if no licence was detected.
+func (d Dependency) Licence() string {
+	if len(d.Licences) == 0 {
+		return "Unknown"
+	}
+	return strings.Join(d.Licences, " AND ")
+}
+
+// ParseReport parses a JSON report, which may be gzip-compressed.
+func ParseReport(data []byte) (*Report, error) {
+	var r io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress report: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	uncompressed, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress report: %w", err)
+	}
+
+	report := &Report{}
+	if err := json.Unmarshal(uncompressed, report); err != nil {
+		return nil, fmt.Errorf("failed to parse report: %w", err)
+	}
+	return report, nil
+}
+
+type section struct {
+	Title        string
+	Dependencies []Dependency
+}
+
+func (r *Report) sections() []section {
+	var sections []section
+	for _, s := range []section{
+		{Title: "Dependencies", Dependencies: r.Direct},
+		{Title: "Indirect dependencies", Dependencies: r.Indirect},
+		{Title: "Build tools", Dependencies: r.Tools},
+	} {
+		if len(s.Dependencies) > 0 {
+			sections = append(sections, s)
+		}
+	}
+	return sections
+}
+
+var htmlTemplate = template.Must(template.New("html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Third party licences</title>
+</head>
+<body>
+<h1>Third party licences</h1>
+{{- range . }}
+<h2>{{ .Title }}</h2>
+<table>
+<tr><th>Module</th><th>Version</th><th>Licence</th></tr>
+{{- range .Dependencies }}
+<tr><td>{{ .Name }}</td><td>{{ with .Replace }}{{ .Path }} {{ .Version }}{{ else }}{{ .Version }}{{ end }}</td><td>{{ .Licence }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+</body>
+</html>
+`))
+
+var plainTemplate = textTemplate.Must(textTemplate.New("text").Parse(`
+{{- range $i, $s := . }}{{ if $i }}
+{{ end }}{{ $s.Title }}
+{{- range $s.Dependencies }}
+  {{ .Name }} {{ with .Replace }}{{ .Path }} {{ .Version }}{{ else }}{{ .Version }}{{ end }}: {{ .Licence }}
+{{- end }}
+{{ end }}`))
+
+// Handler returns a handler rendering the report as an HTML page, or as plain text if the request has a format=text
+// query parameter or only accepts text/plain. The report is parsed once, so that errors surface at startup.
+func Handler(report []byte) (http.Handler, error) {
+	r, err := ParseReport(report)
+	if err != nil {
+		return nil, err
+	}
+
+	var htmlBuf, textBuf bytes.Buffer
+	if err := htmlTemplate.Execute(&htmlBuf, r.sections()); err != nil {
+		return nil, fmt.Errorf("failed to render report: %w", err)
+	}
+	if err := plainTemplate.Execute(&textBuf, r.sections()); err != nil {
+		return nil, fmt.Errorf("failed to render report: %w", err)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		if wantsText(req) {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Write(textBuf.Bytes())
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(htmlBuf.Bytes())
+	}), nil
+}
+
+func wantsText(req *http.Request) bool {
+	if req.URL.Query().Get("format") == "text" {
+		return true
+	}
+
+	accept := req.Header.Get("Accept")
+	return strings.Contains(accept, "text/plain") && !strings.Contains(accept, "text/html")
+}
@@ -0,0 +1,95 @@
+package serve
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testReport = `{
+  "direct": [
+    {"path": "github.com/foo/lib", "version": "v1.0.0", "licences": ["MIT"]},
+    {"path": "github.com/foo/<script>", "displayName": "Script", "version": "v0.1.0"}
+  ],
+  "indirect": [
+    {"path": "github.com/bar/lib", "version": "v2.0.0", "replace": {"path": "github.com/fork/lib", "version": "v2.0.1"}, "licences": ["Apache-2.0", "MIT"]}
+  ]
+}`
+
+func TestHandler(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, err := zw.Write([]byte(testReport))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	handler, err := Handler(compressed.Bytes())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name            string
+		target          string
+		accept          string
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "text",
+			target:          "/?format=text",
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody: `Dependencies
+  github.com/foo/lib v1.0.0: MIT
+  Script v0.1.0: Unknown
+
+Indirect dependencies
+  github.com/bar/lib github.com/fork/lib v2.0.1: Apache-2.0 AND MIT
+`,
+		},
+		{
+			name:            "accept text",
+			target:          "/",
+			accept:          "text/plain",
+			wantContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:            "html",
+			target:          "/",
+			accept:          "text/html,application/xhtml+xml",
+			wantContentType: "text/html; charset=utf-8",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			req.Header.Set("Accept", tc.accept)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Equal(t, tc.wantContentType, rec.Header().Get("Content-Type"))
+			if tc.wantBody != "" {
+				require.Equal(t, tc.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandlerEscapesHTML(t *testing.T) {
+	handler, err := Handler([]byte(`{"direct": [{"path": "github.com/foo/<script>", "version": "v1.0.0"}]}`))
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Contains(t, rec.Body.String(), "github.com/foo/&lt;script&gt;")
+	require.NotContains(t, rec.Body.String(), "<script>")
+}
+
+func TestHandlerInvalidReport(t *testing.T) {
+	_, err := Handler([]byte("not a report"))
+	require.Error(t, err)
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// exitPolicyViolation is the exit code used when a dependency uses a licence denied by the policy.
+const exitPolicyViolation = 4
+
+const exceptionDateLayout = "2006-01-02"
+
+// policy is the content of the policy file given with -policy.
+type policy struct {
+	// Indirect selects the indirect dependencies included in the results: none, all, copyleft or linked. The linked
+	// policy requires -packages.
+	Indirect string `json:"indirect"`
+
+	// Deny lists the SPDX identifiers of the licences that the dependencies must not use.
+	Deny []string `json:"deny"`
+
+	// Exceptions exempt modules from the deny list until they expire.
+	Exceptions []policyException `json:"exceptions"`
+
+	// Rules are expressions evaluated against every dependency, in order. An allow rule exempts the matching modules
+	// from the deny list and the deny rules.
+	Rules []policyRule `json:"rules"`
+}
+
+// policyRule denies or allows the dependencies matching a CEL expression, such as
+// `copyleft && direct && "cmd" in targets` or `tool && family == "GPL"`.
+type policyRule struct {
+	Name   string `json:"name"`
+	Allow  string `json:"allow,omitempty"`
+	Deny   string `json:"deny,omitempty"`
+	Reason string `json:"reason,omitempty"` // explanation given with the violations
+
+	expr *expr
+}
+
+// ruleVars are the variables of the rule expressions.
+var ruleVars = map[string]exprType{
+	"path":     typeString, // module path
+	"version":  typeString, // version of the replacement, if any
+	"licence":  typeString, // SPDX expression of the licences
+	"licences": typeList,   // SPDX identifiers of the licences
+	"family":   typeString, // licence family, as with groupByFamily
+	"copyleft": typeBool,   // one of the licences is copyleft
+	"direct":   typeBool,
+	"indirect": typeBool,
+	"tool":     typeBool, // build tool, with -tools
+	"owner":    typeString,
+	"source":   typeString, // how the licence was detected
+	"replaced": typeBool,
+	"targets":  typeList, // build targets using the module, with labelled inputs or -platforms
+}
+
+// policyException is a time-boxed exemption from the deny list, documented with who granted it and why.
+type policyException struct {
+	Module        string `json:"module"`            // module path
+	Licence       string `json:"licence,omitempty"` // exempted licence, every denied licence if empty
+	Owner         string `json:"owner"`
+	Justification string `json:"justification"`
+	Expires       string `json:"expires"` // last day of validity, as YYYY-MM-DD
+
+	expires time.Time
+}
+
+// policyViolation is a dependency using a denied licence. Expired is set to the exception that would have exempted
+// it had it not expired, and Rule to the deny rule matching the dependency, if any.
+type policyViolation struct {
+	Dependency detector.LicenceInfo
+	Licence    string
+	Expired    *policyException
+	Rule       *policyRule
+}
+
+// currentPolicy is the policy loaded with -policy, if any.
+var currentPolicy *policy
+
+// policyResults caches the violations of the dependencies of the run, which are used by some output formats as well
+// as to set the exit code, so that the exceptions applied are logged once.
+var policyResults struct {
+	dependencies *detector.Dependencies
+	violations   []policyViolation
+}
+
+// checkPolicy returns the violations of the current policy by the dependencies, if a policy is loaded.
+func checkPolicy(dependencies *detector.Dependencies) []policyViolation {
+	if currentPolicy == nil {
+		return nil
+	}
+
+	if policyResults.dependencies != dependencies {
+		policyResults.dependencies = dependencies
+		policyResults.violations = currentPolicy.check(dependencies, currentRun.time)
+	}
+	return policyResults.violations
+}
+
+func loadPolicy(path string) (*policy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var p policy
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+
+	if p.Indirect != "" {
+		if _, err := detector.ParseIndirectPolicy(p.Indirect); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range p.Exceptions {
+		if err := p.Exceptions[i].validate(); err != nil {
+			return nil, fmt.Errorf("invalid exception %d: %w", i+1, err)
+		}
+	}
+
+	for i := range p.Rules {
+		if err := p.Rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("invalid rule %d: %w", i+1, err)
+		}
+	}
+
+	return &p, nil
+}
+
+func (e *policyException) validate() error {
+	switch {
+	case e.Module == "":
+		return errors.New("module is required")
+	case e.Owner == "":
+		return fmt.Errorf("%s: owner is required", e.Module)
+	case e.Justification == "":
+		return fmt.Errorf("%s: justification is required", e.Module)
+	}
+
+	expires, err := time.Parse(exceptionDateLayout, e.Expires)
+	if err != nil {
+		return fmt.Errorf("%s: invalid expiry date %q: must be YYYY-MM-DD", e.Module, e.Expires)
+	}
+	// the exception remains valid for the whole expiry day
+	e.expires = expires.AddDate(0, 0, 1)
+	return nil
+}
+
+func (r *policyRule) compile() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+
+	source := r.Deny
+	switch {
+	case r.Allow != "" && r.Deny != "":
+		return fmt.Errorf("%s: only one of allow and deny can be set", r.Name)
+	case r.Allow != "":
+		source = r.Allow
+	case r.Deny == "":
+		return fmt.Errorf("%s: allow or deny is required", r.Name)
+	}
+
+	e, err := compileExpr(source, ruleVars)
+	if err != nil {
+		return fmt.Errorf("%s: %w", r.Name, err)
+	}
+	r.expr = e
+	return nil
+}
+
+// explain returns why the rule matched.
+func (r *policyRule) explain() string {
+	if r.Reason != "" {
+		return r.Reason
+	}
+	return r.expr.source
+}
+
+func ruleEnv(dep detector.LicenceInfo, direct, tool bool) exprEnv {
+	copyleft := false
+	for _, id := range dep.Licences {
+		copyleft = copyleft || detector.IsCopyleft(id)
+	}
+
+	version := dep.Version
+	if dep.Replace != nil && dep.Replace.Version != "" {
+		version = dep.Replace.Version
+	}
+
+	licences := dep.Licences
+	if licences == nil {
+		licences = []string{}
+	}
+	targets := Targets(dep)
+	if targets == nil {
+		targets = []string{}
+	}
+
+	return exprEnv{
+		"path":     dep.Path,
+		"version":  version,
+		"licence":  licenceExpression(dep),
+		"licences": licences,
+		"family":   LicenceFamily(dep),
+		"copyleft": copyleft,
+		"direct":   direct,
+		"indirect": !direct && !tool,
+		"tool":     tool,
+		"owner":    Owner(dep),
+		"source":   dep.Source,
+		"replaced": dep.Replace != nil,
+		"targets":  targets,
+	}
+}
+
+// indirectPolicy returns the indirect dependency policy of the run. -includeIndirect is the same as the all policy
+// and is only used if the policy file does not set one.
+func indirectPolicy(p *policy) detector.IndirectPolicy {
+	switch {
+	case p != nil && p.Indirect != "":
+		return detector.IndirectPolicy(p.Indirect)
+	case *includeIndirectFlag:
+		return detector.IndirectAll
+	default:
+		return detector.IndirectNone
+	}
+}
+
+func loadLinkedModules(path string) (map[string]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return detector.ParseLinkedModules(f)
+}
+
+// check returns the dependencies using a denied licence that are not exempted by an exception valid at the given
+// time, followed by those matching a deny rule. The exceptions and allow rules applied are logged so that they remain
+// visible.
+func (p *policy) check(dependencies *detector.Dependencies, now time.Time) []policyViolation {
+	denied := make(map[string]struct{}, len(p.Deny))
+	for _, id := range p.Deny {
+		denied[id] = struct{}{}
+	}
+
+	groups := []struct {
+		deps         []detector.LicenceInfo
+		direct, tool bool
+	}{
+		{deps: dependencies.Direct, direct: true},
+		{deps: dependencies.Indirect},
+		{deps: dependencies.Tools, tool: true},
+	}
+
+	var violations []policyViolation
+	for _, g := range groups {
+		for _, dep := range g.deps {
+			violations = append(violations, p.checkDependency(dep, g.direct, g.tool, denied, now)...)
+		}
+	}
+
+	return violations
+}
+
+func (p *policy) checkDependency(dep detector.LicenceInfo, direct, tool bool, denied map[string]struct{}, now time.Time) []policyViolation {
+	var env exprEnv
+	if len(p.Rules) > 0 {
+		env = ruleEnv(dep, direct, tool)
+	}
+
+	for i, r := range p.Rules {
+		if r.Allow != "" && r.expr.match(env) {
+			logInfo("Allowing %s: rule %s: %s", dep.Path, r.Name, p.Rules[i].explain())
+			return nil
+		}
+	}
+
+	var violations []policyViolation
+	for _, licence := range dep.Licences {
+		if _, ok := denied[licence]; !ok {
+			continue
+		}
+
+		e := p.exception(dep.Path, licence)
+		switch {
+		case e == nil:
+			violations = append(violations, policyViolation{Dependency: dep, Licence: licence})
+		case !now.Before(e.expires):
+			violations = append(violations, policyViolation{Dependency: dep, Licence: licence, Expired: e})
+		default:
+			logInfo("Allowing %s licensed under %s until %s: exception owned by %s: %s", dep.Path, licence, e.Expires, e.Owner, e.Justification)
+		}
+	}
+
+	for i, r := range p.Rules {
+		if r.Deny != "" && r.expr.match(env) {
+			violations = append(violations, policyViolation{Dependency: dep, Licence: licenceExpression(dep), Rule: &p.Rules[i]})
+		}
+	}
+
+	return violations
+}
+
+func (p *policy) exception(modPath, licence string) *policyException {
+	for i, e := range p.Exceptions {
+		if e.Module == modPath && (e.Licence == "" || e.Licence == licence) {
+			return &p.Exceptions[i]
+		}
+	}
+	return nil
+}
+
+func logPolicyViolations(violations []policyViolation) {
+	var buf bytes.Buffer
+	for _, v := range violations {
+		fmt.Fprintf(&buf, "\n  %s %s: %s", v.Dependency.Path, v.Dependency.Version, v.Licence)
+		if v.Expired != nil {
+			fmt.Fprintf(&buf, " (exception owned by %s expired on %s)", v.Expired.Owner, v.Expired.Expires)
+		}
+		if v.Rule != nil {
+			fmt.Fprintf(&buf, " (rule %s: %s)", v.Rule.Name, v.Rule.explain())
+		}
+	}
+	log.Printf("Dependencies use licences denied by the policy:%s", buf.String())
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	p := &policy{
+		Deny: []string{"AGPL-3.0", "GPL-3.0"},
+		Exceptions: []policyException{
+			{Module: "example.com/approved", Owner: "legal", Justification: "internal tool", Expires: "2020-06-30"},
+			{Module: "example.com/expired", Licence: "GPL-3.0", Owner: "legal", Justification: "migration", Expires: "2020-05-31"},
+			{Module: "example.com/other", Licence: "GPL-3.0", Owner: "legal", Justification: "linked dynamically", Expires: "2020-12-31"},
+		},
+	}
+	for i := range p.Exceptions {
+		require.NoError(t, p.Exceptions[i].validate())
+	}
+
+	dep := func(path string, licences ...string) detector.LicenceInfo {
+		return detector.LicenceInfo{Module: detector.Module{Path: path, Version: "v1.0.0"}, Licences: licences}
+	}
+	dependencies := &detector.Dependencies{
+		Direct: []detector.LicenceInfo{
+			dep("example.com/allowed", "MIT"),
+			dep("example.com/approved", "AGPL-3.0"),
+			dep("example.com/denied", "GPL-3.0"),
+			dep("example.com/expired", "GPL-3.0"),
+		},
+		Indirect: []detector.LicenceInfo{
+			dep("example.com/other", "AGPL-3.0", "GPL-3.0"),
+		},
+	}
+
+	violations := p.check(dependencies, time.Date(2020, 6, 30, 23, 0, 0, 0, time.UTC))
+
+	var got []string
+	for _, v := range violations {
+		desc := v.Dependency.Path + " " + v.Licence
+		if v.Expired != nil {
+			desc += " expired"
+		}
+		got = append(got, desc)
+	}
+	require.Equal(t, []string{
+		"example.com/denied GPL-3.0",
+		"example.com/expired GPL-3.0 expired",
+		"example.com/other AGPL-3.0",
+	}, got)
+}
+
+func TestPolicyExceptionValidate(t *testing.T) {
+	testCases := []struct {
+		name      string
+		exception policyException
+		wantErr   bool
+	}{
+		{name: "Valid", exception: policyException{Module: "example.com/a", Owner: "legal", Justification: "approved", Expires: "2021-01-31"}},
+		{name: "NoOwner", exception: policyException{Module: "example.com/a", Justification: "approved", Expires: "2021-01-31"}, wantErr: true},
+		{name: "NoJustification", exception: policyException{Module: "example.com/a", Owner: "legal", Expires: "2021-01-31"}, wantErr: true},
+		{name: "InvalidExpiry", exception: policyException{Module: "example.com/a", Owner: "legal", Justification: "approved", Expires: "31/01/2021"}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.exception.validate()
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestPolicyRules(t *testing.T) {
+	p := &policy{
+		Deny: []string{"GPL-3.0"},
+		Rules: []policyRule{
+			{Name: "build-tools", Allow: `tool && family == "GPL"`},
+			{Name: "copyleft-direct", Deny: `copyleft && direct`, Reason: "copyleft licences are not allowed for direct dependencies"},
+			{Name: "forks", Deny: `replaced && !path.startsWith("example.com/")`},
+		},
+	}
+	for i := range p.Rules {
+		require.NoError(t, p.Rules[i].compile())
+	}
+
+	dep := func(path string, licences ...string) detector.LicenceInfo {
+		return detector.LicenceInfo{Module: detector.Module{Path: path, Version: "v1.0.0"}, Licences: licences}
+	}
+	fork := dep("github.com/upstream/fork", "MIT")
+	fork.Replace = &detector.Module{Path: "github.com/someone/fork", Version: "v1.0.1"}
+
+	dependencies := &detector.Dependencies{
+		Direct: []detector.LicenceInfo{
+			dep("example.com/lgpl", "LGPL-3.0"),
+			dep("example.com/mit", "MIT"),
+			fork,
+		},
+		Indirect: []detector.LicenceInfo{
+			dep("example.com/indirect", "LGPL-3.0"),
+		},
+		Tools: []detector.LicenceInfo{
+			dep("example.com/tool", "GPL-3.0"),
+		},
+	}
+
+	var got []string
+	for _, v := range p.check(dependencies, time.Now()) {
+		require.NotNil(t, v.Rule)
+		got = append(got, v.Dependency.Path+" "+v.Rule.Name+": "+v.Rule.explain())
+	}
+	require.Equal(t, []string{
+		"example.com/lgpl copyleft-direct: copyleft licences are not allowed for direct dependencies",
+		`github.com/upstream/fork forks: replaced && !path.startsWith("example.com/")`,
+	}, got)
+}
+
+func TestPolicyRuleCompile(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rule    policyRule
+		wantErr bool
+	}{
+		{name: "Valid", rule: policyRule{Name: "r", Deny: `"GPL-3.0" in licences && size(targets) > 0`}},
+		{name: "NoName", rule: policyRule{Deny: "copyleft"}, wantErr: true},
+		{name: "NoExpression", rule: policyRule{Name: "r"}, wantErr: true},
+		{name: "AllowAndDeny", rule: policyRule{Name: "r", Allow: "tool", Deny: "copyleft"}, wantErr: true},
+		{name: "UnknownVariable", rule: policyRule{Name: "r", Deny: "licensed"}, wantErr: true},
+		{name: "NotBool", rule: policyRule{Name: "r", Deny: "path"}, wantErr: true},
+		{name: "TypeMismatch", rule: policyRule{Name: "r", Deny: `direct == "true"`}, wantErr: true},
+		{name: "InvalidPattern", rule: policyRule{Name: "r", Deny: `path.matches("(")`}, wantErr: true},
+		{name: "Unterminated", rule: policyRule{Name: "r", Deny: `path == "a`}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.rule.compile()
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
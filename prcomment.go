@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// exportPRComment writes a compact Markdown summary of the licence impact of the changes since the baseline, meant
+// to be posted on dependency update pull requests: the new dependencies and licence changes, the removed
+// dependencies and the policy violations and unknown licences.
+func exportPRComment(w io.Writer, dependencies *detector.Dependencies) error {
+	bw := bufio.NewWriter(w)
+	changelog := dependencies.Changelog
+	if changelog == nil {
+		changelog = &detector.Changelog{}
+	}
+
+	fmt.Fprintln(bw, "### Licence impact")
+	fmt.Fprintln(bw)
+
+	if len(changelog.New) == 0 && len(changelog.LicenceChanged) == 0 {
+		fmt.Fprintln(bw, "No new dependencies or licence changes.")
+	}
+
+	if len(changelog.New) > 0 {
+		fmt.Fprintf(bw, "**%s**\n\n", plural(len(changelog.New), "new dependency", "new dependencies"))
+		fmt.Fprintln(bw, "| Module | Version | Licence |")
+		fmt.Fprintln(bw, "| --- | --- | --- |")
+		for _, e := range changelog.New {
+			fmt.Fprintf(bw, "| `%s` | %s | %s |\n", e.Path, e.NewVersion, prCommentLicences(e.NewLicences))
+		}
+	}
+
+	if len(changelog.LicenceChanged) > 0 {
+		if len(changelog.New) > 0 {
+			fmt.Fprintln(bw)
+		}
+		fmt.Fprintf(bw, "**%s**\n\n", plural(len(changelog.LicenceChanged), "licence change", "licence changes"))
+		fmt.Fprintln(bw, "| Module | Version | Before | After |")
+		fmt.Fprintln(bw, "| --- | --- | --- | --- |")
+		for _, e := range changelog.LicenceChanged {
+			fmt.Fprintf(bw, "| `%s` | %s => %s | %s | %s |\n", e.Path, e.OldVersion, e.NewVersion, prCommentLicences(e.OldLicences), prCommentLicences(e.NewLicences))
+		}
+	}
+
+	if len(changelog.Removed) > 0 {
+		paths := make([]string, len(changelog.Removed))
+		for i, e := range changelog.Removed {
+			paths[i] = "`" + e.Path + "`"
+		}
+		fmt.Fprintf(bw, "\nRemoved: %s\n", strings.Join(paths, ", "))
+	}
+
+	concerns := mkViolationsReport(dependencies, checkPolicy(dependencies)).Violations
+	if len(concerns) > 0 {
+		fmt.Fprintf(bw, "\n**:warning: %s**\n\n", plural(len(concerns), "policy concern", "policy concerns"))
+		for _, c := range concerns {
+			fmt.Fprintf(bw, "- `%s` %s: %s\n", c.Dependency.Path, HumanVersion(c.Dependency), c.Explanation)
+		}
+	}
+
+	return bw.Flush()
+}
+
+func prCommentLicences(licences []string) string {
+	if len(licences) == 0 {
+		return "Unknown"
+	}
+	return strings.Join(licences, " AND ")
+}
+
+func plural(n int, singular, plural string) string {
+	if n == 1 {
+		return "1 " + singular
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportPRComment(t *testing.T) {
+	defer func(p *policy) { currentPolicy = p }(currentPolicy)
+	currentPolicy = &policy{Deny: []string{"GPL-3.0"}}
+
+	dependencies := &detector.Dependencies{
+		Direct: []detector.LicenceInfo{
+			{Module: detector.Module{Path: "example.com/gpl", Version: "v1.0.0"}, Licences: []string{"GPL-3.0"}},
+			{Module: detector.Module{Path: "example.com/mit", Version: "v1.2.0"}, Licences: []string{"MIT"}},
+		},
+		Changelog: &detector.Changelog{
+			New:            []detector.ChangelogEntry{{Path: "example.com/gpl", NewVersion: "v1.0.0", NewLicences: []string{"GPL-3.0"}}},
+			LicenceChanged: []detector.ChangelogEntry{{Path: "example.com/mit", OldVersion: "v1.1.0", NewVersion: "v1.2.0", OldLicences: []string{"Apache-2.0"}, NewLicences: []string{"MIT"}}},
+			Removed:        []detector.ChangelogEntry{{Path: "example.com/old", OldVersion: "v0.1.0"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, exportPRComment(&buf, dependencies))
+	require.Equal(t, "### Licence impact\n"+
+		"\n"+
+		"**1 new dependency**\n"+
+		"\n"+
+		"| Module | Version | Licence |\n"+
+		"| --- | --- | --- |\n"+
+		"| `example.com/gpl` | v1.0.0 | GPL-3.0 |\n"+
+		"\n"+
+		"**1 licence change**\n"+
+		"\n"+
+		"| Module | Version | Before | After |\n"+
+		"| --- | --- | --- | --- |\n"+
+		"| `example.com/mit` | v1.1.0 => v1.2.0 | Apache-2.0 | MIT |\n"+
+		"\n"+
+		"Removed: `example.com/old`\n"+
+		"\n"+
+		"**:warning: 1 policy concern**\n"+
+		"\n"+
+		"- `example.com/gpl` v1.0.0: GPL-3.0 is in the deny list of the policy\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, exportPRComment(&buf, &detector.Dependencies{Changelog: &detector.Changelog{}}))
+	require.Equal(t, "### Licence impact\n\nNo new dependencies or licence changes.\n", buf.String())
+}
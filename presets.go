@@ -0,0 +1,183 @@
+package main
+
+import (
+	"sort"
+)
+
+// presets are the built-in templates selectable with -preset instead of providing a template file.
+var presets = map[string]string{
+	"apache":     apachePreset,
+	"by-licence": byLicencePreset,
+	"html":       htmlPreset,
+	"markdown":   markdownPreset,
+	"notice":     noticePreset,
+}
+
+func presetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// noticePreset lists every dependency with the full text of its licence.
+const noticePreset = `{{- define "depInfo" -}}
+{{- range $i, $dep := . }}
+{{ "-" | line }}
+{{ if $dep.Replace -}}
+Module  : {{ $dep.Path }} => {{ $dep.Replace.Path }}
+Version : {{ $dep.Replace.Version }}
+{{- else -}}
+Module  : {{ $dep.Path }}
+Version : {{ $dep.Version }}
+{{- end }}
+Licence : {{ if $dep.Licences }}{{ join $dep.Licences " AND " }}{{ else }}Unknown{{ end }}
+
+{{ $dep | licenceText }}
+{{ end }}
+{{- end -}}
+
+{{ "=" | line }}
+Third party libraries
+{{ "=" | line }}
+{{ template "depInfo" .Direct }}
+{{- if .Indirect }}
+{{ "=" | line }}
+Indirect dependencies
+{{ "=" | line }}
+{{ template "depInfo" .Indirect }}
+{{- end }}
+{{- if .Tools }}
+{{ "=" | line }}
+Build tools
+{{ "=" | line }}
+{{ template "depInfo" .Tools }}
+{{- end }}
+`
+
+// apachePreset follows the Apache NOTICE conventions: attributions only, the licence texts being distributed
+// separately.
+const apachePreset = `This product includes software developed by third parties:
+{{ range $dep := .Direct }}
+{{ template "attribution" $dep }}
+{{- end }}
+{{- range $dep := .Indirect }}
+{{ template "attribution" $dep }}
+{{- end }}
+{{ define "attribution" -}}
+* {{ displayName . }}{{ with .Version }} {{ . }}{{ end }}
+{{- if upstream . }}, as forked by {{ .Replace.Path }} {{ .Replace.Version }}{{ end }}
+  Licensed under {{ if .Licences }}{{ join .Licences " AND " }}{{ else }}an unknown licence{{ end }}
+{{- with copyrightText . }}
+  {{ . }}
+{{- end }}
+{{- end }}`
+
+// byLicencePreset groups the dependencies by licence expression, followed by the copyright statements of each group.
+const byLicencePreset = `{{- define "groups" -}}
+{{- range $group := groupByLicence . }}
+{{ $group.Name }}
+{{ "-" | line }}
+{{- range $dep := $group.Dependencies }}
+  {{ $dep.Path }}{{ with $dep.Version }} {{ . }}{{ end }}
+{{- end }}
+{{- with copyrights $group.Dependencies }}
+{{ range . }}
+  {{ . }}
+{{- end }}
+{{- end }}
+{{ end }}
+{{- end -}}
+
+{{ "=" | line }}
+Direct dependencies by licence
+{{ "=" | line }}
+{{ template "groups" .Direct }}
+{{- if .Indirect }}
+{{ "=" | line }}
+Indirect dependencies by licence
+{{ "=" | line }}
+{{ template "groups" .Indirect }}
+{{- end }}
+{{- if .Tools }}
+{{ "=" | line }}
+Build tools by licence
+{{ "=" | line }}
+{{ template "groups" .Tools }}
+{{- end }}`
+
+// markdownPreset renders a CREDITS.md file with a summary table followed by the licence texts.
+const markdownPreset = `# Credits
+
+| Module | Version | Licence |
+| ------ | ------- | ------- |
+{{- range $dep := .Direct }}
+| {{ displayName $dep }} | {{ humanVersion $dep }} | {{ if $dep.Licences }}{{ join $dep.Licences " AND " }}{{ else }}Unknown{{ end }} |
+{{- end }}
+{{- range $dep := .Indirect }}
+| {{ displayName $dep }} | {{ humanVersion $dep }} | {{ if $dep.Licences }}{{ join $dep.Licences " AND " }}{{ else }}Unknown{{ end }} |
+{{- end }}
+{{- range $dep := .Direct }}
+{{ template "licence" $dep }}
+{{- end }}
+{{- range $dep := .Indirect }}
+{{ template "licence" $dep }}
+{{- end }}
+{{ define "licence" }}
+## {{ displayName . }}
+
+{{ with .Error -}}
+{{ . }}
+{{- else -}}
+From {{ licencePath . }}:
+
+` + "```" + `
+{{ licenceContents . }}
+` + "```" + `
+{{- end }}
+{{- end }}`
+
+// htmlPreset renders a standalone HTML report. Every value is escaped with the html function as text/template does
+// not escape its output.
+const htmlPreset = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Third party licences</title>
+</head>
+<body>
+<h1>Third party licences</h1>
+<table>
+<tr><th>Module</th><th>Version</th><th>Licence</th></tr>
+{{- range $dep := .Direct }}
+{{ template "row" $dep }}
+{{- end }}
+{{- range $dep := .Indirect }}
+{{ template "row" $dep }}
+{{- end }}
+</table>
+{{- range $dep := .Direct }}
+{{ template "licence" $dep }}
+{{- end }}
+{{- range $dep := .Indirect }}
+{{ template "licence" $dep }}
+{{- end }}
+</body>
+</html>
+{{ define "row" -}}
+<tr><td>{{ displayName . | html }}</td><td>{{ humanVersion . | html }}</td><td>{{ if .Licences }}{{ join .Licences " AND " | html }}{{ else }}Unknown{{ end }}</td></tr>
+{{- end }}
+{{- define "licence" -}}
+<h2 id="{{ .Path | html }}">{{ displayName . | html }}</h2>
+{{- with vulnerabilities . }}
+<p>Known vulnerabilities: {{ join . ", " | html }}</p>
+{{- end }}
+{{ with .Error -}}
+<p>{{ . | html }}</p>
+{{- else -}}
+<p>From {{ licencePath . | html }}:</p>
+<pre>{{ licenceContents . | html }}</pre>
+{{- end }}
+{{- end }}`
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGoldenFlag = flag.Bool("update", false, "Update the golden files of the preset tests")
+
+func TestPresets(t *testing.T) {
+	dependencies := &detector.Dependencies{
+		Direct: []detector.LicenceInfo{
+			{
+				Module:      detector.Module{Path: "github.com/example/apache", Version: "v1.2.0"},
+				LicenceFile: "testdata/presets/LICENSE-APACHE",
+				Licences:    []string{"Apache-2.0"},
+			},
+			{
+				Module: detector.Module{
+					Path:    "github.com/example/mit",
+					Version: "v0.1.0",
+					Replace: &detector.Module{Path: "github.com/fork/mit", Version: "v0.1.1"},
+				},
+				LicenceFile: "testdata/presets/LICENSE-MIT",
+				Licences:    []string{"MIT"},
+			},
+		},
+		Indirect: []detector.LicenceInfo{
+			{
+				Module: detector.Module{Path: "golang.org/x/unknown", Version: "v0.0.0-20200101000000-0123456789ab", Indirect: true},
+				Error:  errors.New("failed to detect licence"),
+			},
+		},
+	}
+
+	for _, name := range presetNames() {
+		t.Run(name, func(t *testing.T) {
+			tmpl, err := loadTemplate("", name)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			require.NoError(t, tmpl.Execute(&buf, dependencies))
+
+			golden := filepath.Join("testdata", "presets", name+".golden")
+			if *updateGoldenFlag {
+				require.NoError(t, ioutil.WriteFile(golden, buf.Bytes(), 0644))
+			}
+
+			want, err := ioutil.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(want), buf.String())
+		})
+	}
+}
+
+func TestPresetsHeaderFooter(t *testing.T) {
+	defer func() { headerText, footerText = "", "" }()
+	headerText, footerText = "ACME Corp. third party notices\n", "End of notices\n"
+
+	tmpl, err := loadTemplate("", "by-licence")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.Execute(&buf, &detector.Dependencies{}))
+	require.True(t, strings.HasPrefix(buf.String(), headerText), buf.String())
+	require.True(t, strings.HasSuffix(buf.String(), footerText), buf.String())
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+type moduleTiming struct {
+	path    string
+	version string
+	elapsed time.Duration
+}
+
+// profiler records how long the detection of each module took.
+type profiler struct {
+	timings []moduleTiming
+}
+
+func (p *profiler) record(dep detector.LicenceInfo, elapsed time.Duration) {
+	mod := effectiveModule(dep)
+	p.timings = append(p.timings, moduleTiming{path: mod.Path, version: mod.Version, elapsed: elapsed})
+}
+
+// writeReport writes the timings of all modules, slowest first.
+func (p *profiler) writeReport(w io.Writer) error {
+	timings := make([]moduleTiming, len(p.timings))
+	copy(timings, p.timings)
+	sort.SliceStable(timings, func(i, j int) bool {
+		return timings[i].elapsed > timings[j].elapsed
+	})
+
+	var total time.Duration
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "DURATION\tMODULE\tVERSION")
+	for _, t := range timings {
+		total += t.elapsed
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", t.elapsed.Round(time.Microsecond), t.path, t.version)
+	}
+	fmt.Fprintf(tw, "%s\ttotal (%d modules)\t\n", total.Round(time.Microsecond), len(timings))
+
+	return tw.Flush()
+}
+
+func writeProfile(prof *profiler, path string) error {
+	w, cleanup, err := mkWriter(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	return prof.writeReport(w)
+}
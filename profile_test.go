@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfilerWriteReport(t *testing.T) {
+	dep := func(path, version string) detector.LicenceInfo {
+		return detector.LicenceInfo{Module: detector.Module{Path: path, Version: version}}
+	}
+	fork := dep("example.com/upstream", "v1.0.0")
+	fork.Replace = &detector.Module{Path: "example.com/fork", Version: "v1.0.1-fork"}
+
+	testCases := []struct {
+		name   string
+		record func(p *profiler)
+		want   string
+	}{
+		{
+			name:   "Empty",
+			record: func(p *profiler) {},
+			want: "DURATION  MODULE             VERSION\n" +
+				"0s        total (0 modules)  \n",
+		},
+		{
+			name: "SlowestFirst",
+			record: func(p *profiler) {
+				p.record(dep("example.com/fast", "v1.0.0"), 2*time.Millisecond)
+				p.record(dep("example.com/slow", "v0.1.0"), 1500*time.Millisecond)
+				p.record(fork, 30*time.Millisecond)
+			},
+			want: "DURATION  MODULE             VERSION\n" +
+				"1.5s      example.com/slow   v0.1.0\n" +
+				"30ms      example.com/fork   v1.0.1-fork\n" +
+				"2ms       example.com/fast   v1.0.0\n" +
+				"1.532s    total (3 modules)  \n",
+		},
+		{
+			name: "TiesKeepRecordOrder",
+			record: func(p *profiler) {
+				p.record(dep("example.com/b", "v1.0.0"), time.Millisecond)
+				p.record(dep("example.com/a", "v1.0.0"), time.Millisecond)
+			},
+			want: "DURATION  MODULE             VERSION\n" +
+				"1ms       example.com/b      v1.0.0\n" +
+				"1ms       example.com/a      v1.0.0\n" +
+				"2ms       total (2 modules)  \n",
+		},
+		{
+			name: "RoundedToMicroseconds",
+			record: func(p *profiler) {
+				p.record(dep("example.com/a", "v1.0.0"), 1234567*time.Nanosecond)
+			},
+			want: "DURATION  MODULE             VERSION\n" +
+				"1.235ms   example.com/a      v1.0.0\n" +
+				"1.235ms   total (1 modules)  \n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &profiler{}
+			tc.record(p)
+
+			var buf bytes.Buffer
+			require.NoError(t, p.writeReport(&buf))
+			require.Equal(t, tc.want, buf.String())
+		})
+	}
+}
+
+func TestProfilerWriteReportKeepsRecordOrder(t *testing.T) {
+	p := &profiler{}
+	p.record(detector.LicenceInfo{Module: detector.Module{Path: "example.com/fast"}}, time.Millisecond)
+	p.record(detector.LicenceInfo{Module: detector.Module{Path: "example.com/slow"}}, time.Second)
+
+	var buf bytes.Buffer
+	require.NoError(t, p.writeReport(&buf))
+	require.Equal(t, "example.com/fast", p.timings[0].path, "writing the report must not reorder the recorded timings")
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"sort"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// exportProtobuf writes the report as a serialized licencedetector.v1.Report message (see proto/report.proto). The
+// wire format is simple enough to be encoded without depending on a protobuf runtime; the field numbers used below
+// must be kept in sync with the schema.
+func exportProtobuf(w io.Writer, dependencies *detector.Dependencies) error {
+	r := mkReport(dependencies)
+
+	var msg protoMessage
+	if r.Metadata != nil {
+		msg.message(1, func(m *protoMessage) {
+			m.string(1, r.Metadata.ToolVersion)
+			m.string(2, r.Metadata.GeneratedAt)
+			m.string(3, r.Metadata.InputDigest)
+
+			names := make([]string, 0, len(r.Metadata.Flags))
+			for name := range r.Metadata.Flags {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				value := r.Metadata.Flags[name]
+				m.message(4, func(entry *protoMessage) {
+					entry.string(1, name)
+					entry.string(2, value)
+				})
+			}
+		})
+	}
+
+	for i, deps := range [][]reportDependency{r.Direct, r.Indirect, r.Removed} {
+		for _, dep := range deps {
+			dep := dep
+			msg.message(protoField(i+2), func(m *protoMessage) {
+				writeProtoDependency(m, dep)
+			})
+		}
+	}
+
+	for _, dep := range r.Tools {
+		dep := dep
+		msg.message(6, func(m *protoMessage) {
+			writeProtoDependency(m, dep)
+		})
+	}
+
+	for _, s := range r.Skipped {
+		s := s
+		msg.message(5, func(m *protoMessage) {
+			m.string(1, s.Path)
+			m.string(2, s.Version)
+			m.string(3, s.Reason)
+			m.string(4, s.Detail)
+		})
+	}
+
+	_, err := w.Write(msg.buf)
+	return err
+}
+
+func writeProtoDependency(m *protoMessage, dep reportDependency) {
+	m.string(1, dep.Path)
+	m.string(2, dep.DisplayName)
+	m.string(3, dep.Version)
+	m.string(4, dep.Time)
+	m.bool(5, dep.Indirect)
+	if dep.Replace != nil {
+		m.message(6, func(rm *protoMessage) {
+			rm.string(1, dep.Replace.Path)
+			rm.string(2, dep.Replace.Version)
+			rm.bool(3, dep.Replace.Fork)
+		})
+	}
+	m.strings(7, dep.Licences)
+	m.string(8, dep.LicenceFile)
+	for _, c := range dep.CandidateFiles {
+		c := c
+		m.message(9, func(cm *protoMessage) {
+			cm.string(1, c.Path)
+			cm.bool(2, c.Chosen)
+		})
+	}
+	m.string(10, dep.Source)
+	m.strings(11, dep.Warnings)
+	m.string(12, dep.Change)
+	m.string(13, dep.Error)
+	m.string(14, dep.Verification)
+	m.string(15, dep.Owner)
+	for _, sc := range dep.Subcomponents {
+		sc := sc
+		m.message(16, func(sm *protoMessage) {
+			sm.string(1, sc.Dir)
+			sm.strings(2, sc.Licences)
+			sm.string(3, sc.LicenceFile)
+		})
+	}
+	m.string(17, dep.CopyrightFile)
+	if dep.PseudoVersion != nil {
+		m.message(18, func(pm *protoMessage) {
+			pm.string(1, dep.PseudoVersion.Commit)
+			pm.string(2, dep.PseudoVersion.Time)
+			pm.string(3, dep.PseudoVersion.BaseVersion)
+		})
+	}
+	m.string(19, dep.Language)
+	m.strings(20, dep.Targets)
+	m.string(21, dep.Purl)
+	m.strings(22, dep.Vulnerabilities)
+	for _, e := range dep.Evidence {
+		e := e
+		m.message(23, func(em *protoMessage) {
+			em.string(1, e.Path)
+			em.string(2, e.Reason)
+		})
+	}
+	m.string(24, dep.LicenceExpression)
+}
+
+type protoField uint64
+
+const (
+	protoVarint          = 0
+	protoLengthDelimited = 2
+)
+
+// protoMessage encodes a protobuf message. As in proto3, fields holding their default value are omitted.
+type protoMessage struct {
+	buf []byte
+}
+
+func (m *protoMessage) tag(field protoField, wireType uint64) {
+	m.varint(uint64(field)<<3 | wireType)
+}
+
+func (m *protoMessage) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	m.buf = append(m.buf, b[:binary.PutUvarint(b[:], v)]...)
+}
+
+func (m *protoMessage) bytes(field protoField, data []byte) {
+	m.tag(field, protoLengthDelimited)
+	m.varint(uint64(len(data)))
+	m.buf = append(m.buf, data...)
+}
+
+func (m *protoMessage) string(field protoField, s string) {
+	if s != "" {
+		m.bytes(field, []byte(s))
+	}
+}
+
+func (m *protoMessage) strings(field protoField, values []string) {
+	// repeated fields keep empty elements
+	for _, s := range values {
+		m.bytes(field, []byte(s))
+	}
+}
+
+func (m *protoMessage) bool(field protoField, b bool) {
+	if b {
+		m.tag(field, protoVarint)
+		m.varint(1)
+	}
+}
+
+func (m *protoMessage) message(field protoField, build func(*protoMessage)) {
+	var nested protoMessage
+	build(&nested)
+	m.bytes(field, nested.buf)
+}
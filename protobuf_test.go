@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+// protoSchema holds the fields of the messages of proto/report.proto, by message name and field number.
+type protoSchema map[string]map[uint64]protoSchemaField
+
+// protoValues holds the decoded values of the fields of a message, by field name.
+type protoValues = map[string][]interface{}
+
+type protoSchemaField struct {
+	name string
+	typ  string
+}
+
+var (
+	protoMessageRegex = regexp.MustCompile(`^message (\w+) \{`)
+	protoFieldRegex   = regexp.MustCompile(`^\s*(?:repeated\s+)?(map<[^>]*>|\w+)\s+(\w+)\s*=\s*(\d+);`)
+)
+
+func loadProtoSchema(t *testing.T) protoSchema {
+	f, err := os.Open("proto/report.proto")
+	require.NoError(t, err)
+	defer f.Close()
+
+	schema := make(protoSchema)
+	var fields map[uint64]protoSchemaField
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if m := protoMessageRegex.FindStringSubmatch(scanner.Text()); m != nil {
+			fields = make(map[uint64]protoSchemaField)
+			schema[m[1]] = fields
+		} else if m := protoFieldRegex.FindStringSubmatch(scanner.Text()); m != nil {
+			num, err := strconv.ParseUint(m[3], 10, 64)
+			require.NoError(t, err)
+			fields[num] = protoSchemaField{name: m[2], typ: m[1]}
+		}
+	}
+	require.NoError(t, scanner.Err())
+	return schema
+}
+
+// decode decodes an encoded message of the schema into the values of its fields by name, failing on the fields that
+// are not in the schema or whose wire type does not match their type.
+func (s protoSchema) decode(t *testing.T, msgType string, buf []byte) protoValues {
+	fields, ok := s[msgType]
+	require.True(t, ok, fmt.Sprintf("unknown message type %s", msgType))
+
+	values := make(protoValues)
+	for len(buf) > 0 {
+		tag, n := binary.Uvarint(buf)
+		require.True(t, n > 0, fmt.Sprintf("invalid tag in %s", msgType))
+		buf = buf[n:]
+
+		field, ok := fields[tag>>3]
+		require.True(t, ok, fmt.Sprintf("field %d of %s is not in the schema", tag>>3, msgType))
+
+		var value interface{}
+		switch wireType := tag & 7; wireType {
+		case protoVarint:
+			require.Equal(t, "bool", field.typ, fmt.Sprintf("%s.%s", msgType, field.name))
+			v, n := binary.Uvarint(buf)
+			require.True(t, n > 0)
+			buf, value = buf[n:], v != 0
+		case protoLengthDelimited:
+			size, n := binary.Uvarint(buf)
+			require.True(t, n > 0 && uint64(len(buf)-n) >= size, fmt.Sprintf("truncated %s.%s", msgType, field.name))
+			data := buf[n : n+int(size)]
+			buf = buf[n+int(size):]
+
+			switch {
+			case field.typ == "string":
+				value = string(data)
+			case field.typ == "map<string, string>":
+				value = s.decodeMapEntry(t, data)
+			default:
+				value = s.decode(t, field.typ, data)
+			}
+		default:
+			t.Fatalf("unexpected wire type %d for %s.%s", wireType, msgType, field.name)
+		}
+		values[field.name] = append(values[field.name], value)
+	}
+
+	return values
+}
+
+// decodeMapEntry decodes an entry of a map<string, string> field, which is a message holding the key as field 1 and
+// the value as field 2.
+func (s protoSchema) decodeMapEntry(t *testing.T, buf []byte) string {
+	s["mapEntry"] = map[uint64]protoSchemaField{1: {name: "key", typ: "string"}, 2: {name: "value", typ: "string"}}
+	defer delete(s, "mapEntry")
+
+	entry := s.decode(t, "mapEntry", buf)
+	return fmt.Sprintf("%s=%s", entry["key"][0], entry["value"][0])
+}
+
+func TestProtoMessageEncoding(t *testing.T) {
+	var msg protoMessage
+	msg.string(1, "a")
+	msg.string(2, "")
+	msg.bool(5, true)
+	msg.bool(3, false)
+	msg.strings(7, []string{"MIT", ""})
+	msg.message(6, func(m *protoMessage) {
+		m.string(1, "b")
+	})
+	msg.string(23, "x")
+
+	require.Equal(t, []byte{
+		0x0a, 0x01, 'a', // field 1, length-delimited
+		0x28, 0x01, // field 5, varint
+		0x3a, 0x03, 'M', 'I', 'T', 0x3a, 0x00, // repeated field 7, keeping the empty element
+		0x32, 0x03, 0x0a, 0x01, 'b', // field 6, nested message
+		0xba, 0x01, 0x01, 'x', // field 23, whose tag takes two bytes
+	}, msg.buf)
+}
+
+func TestExportProtobufDependency(t *testing.T) {
+	schema := loadProtoSchema(t)
+
+	dep := reportDependency{
+		Path:              "example.com/a",
+		DisplayName:       "A",
+		Owner:             "team-a",
+		Version:           "v0.0.0-20200101000000-abcdefabcdef",
+		Purl:              "pkg:golang/example.com/fork@v1.0.1",
+		PseudoVersion:     &reportPseudoVersion{Commit: "abcdefabcdef", Time: "2020-01-01T00:00:00Z", BaseVersion: "v0.1.0"},
+		Time:              "2020-01-02T00:00:00Z",
+		Indirect:          true,
+		Replace:           &reportReplace{Path: "example.com/fork", Version: "v1.0.1", Fork: true},
+		Licences:          []string{"MIT", "Apache-2.0"},
+		LicenceExpression: "MIT OR Apache-2.0",
+		Language:          "fr",
+		Targets:           []string{"server", "cli"},
+		LicenceFile:       "LICENSE",
+		CopyrightFile:     "AUTHORS",
+		CandidateFiles:    []reportCandidate{{Path: "LICENSE", Chosen: true}, {Path: "docs/COPYING"}},
+		Evidence:          []reportEvidence{{Path: "LICENSE"}, {Path: "big/LICENSE", Reason: "too large"}},
+		Source:            "file",
+		Verification:      "go.sum",
+		Subcomponents:     []reportSubcomponent{{Dir: "third_party/x", Licences: []string{"BSD-3-Clause"}, LicenceFile: "third_party/x/LICENSE"}},
+		Warnings:          []string{"warning"},
+		Vulnerabilities:   []string{"GO-2020-0001"},
+		Change:            "updated",
+		Error:             "error",
+	}
+
+	var msg protoMessage
+	writeProtoDependency(&msg, dep)
+	got := schema.decode(t, "Dependency", msg.buf)
+
+	want := protoValues{
+		"path":               {"example.com/a"},
+		"display_name":       {"A"},
+		"version":            {"v0.0.0-20200101000000-abcdefabcdef"},
+		"time":               {"2020-01-02T00:00:00Z"},
+		"indirect":           {true},
+		"replace":            {protoValues{"path": {"example.com/fork"}, "version": {"v1.0.1"}, "fork": {true}}},
+		"licences":           {"MIT", "Apache-2.0"},
+		"licence_file":       {"LICENSE"},
+		"candidate_files":    {protoValues{"path": {"LICENSE"}, "chosen": {true}}, protoValues{"path": {"docs/COPYING"}}},
+		"source":             {"file"},
+		"warnings":           {"warning"},
+		"change":             {"updated"},
+		"error":              {"error"},
+		"verification":       {"go.sum"},
+		"owner":              {"team-a"},
+		"subcomponents":      {protoValues{"dir": {"third_party/x"}, "licences": {"BSD-3-Clause"}, "licence_file": {"third_party/x/LICENSE"}}},
+		"copyright_file":     {"AUTHORS"},
+		"pseudo_version":     {protoValues{"commit": {"abcdefabcdef"}, "time": {"2020-01-01T00:00:00Z"}, "base_version": {"v0.1.0"}}},
+		"language":           {"fr"},
+		"targets":            {"server", "cli"},
+		"purl":               {"pkg:golang/example.com/fork@v1.0.1"},
+		"vulnerabilities":    {"GO-2020-0001"},
+		"evidence":           {protoValues{"path": {"LICENSE"}}, protoValues{"path": {"big/LICENSE"}, "reason": {"too large"}}},
+		"licence_expression": {"MIT OR Apache-2.0"},
+	}
+	require.Equal(t, want, got)
+
+	// every field of the schema must be written, so that fields added to the schema are not forgotten
+	require.Len(t, got, len(schema["Dependency"]))
+}
+
+func TestExportProtobufReport(t *testing.T) {
+	schema := loadProtoSchema(t)
+
+	defer func(run runMetadata) { currentRun = run }(currentRun)
+	currentRun = runMetadata{ToolVersion: "v1.0.0", GeneratedAt: "2020-01-01T00:00:00Z", InputDigest: "sha256:00", Flags: map[string]string{"b": "2", "a": "1"}}
+
+	dep := func(path string) detector.LicenceInfo {
+		return detector.LicenceInfo{Module: detector.Module{Path: path, Version: "v1.0.0"}, Licences: []string{"MIT"}}
+	}
+	dependencies := &detector.Dependencies{
+		Direct:    []detector.LicenceInfo{dep("example.com/direct")},
+		Indirect:  []detector.LicenceInfo{dep("example.com/indirect")},
+		Tools:     []detector.LicenceInfo{dep("example.com/tool")},
+		Skipped:   []detector.SkippedModule{{Module: detector.Module{Path: "example.com/skipped", Version: "v1.0.0"}, Reason: "reason", Detail: "detail"}},
+		Changelog: &detector.Changelog{Removed: []detector.ChangelogEntry{{Path: "example.com/removed", OldVersion: "v0.9.0", OldLicences: []string{"MIT"}}}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, exportProtobuf(&buf, dependencies))
+	got := schema.decode(t, "Report", buf.Bytes())
+
+	paths := func(name string) []interface{} {
+		var paths []interface{}
+		for _, v := range got[name] {
+			paths = append(paths, v.(protoValues)["path"][0])
+		}
+		return paths
+	}
+	require.Equal(t, []interface{}{"example.com/direct"}, paths("direct"))
+	require.Equal(t, []interface{}{"example.com/indirect"}, paths("indirect"))
+	require.Equal(t, []interface{}{"example.com/tool"}, paths("tools"))
+	require.Equal(t, []interface{}{"example.com/removed"}, paths("removed"))
+	require.Equal(t, []interface{}{protoValues{"path": {"example.com/skipped"}, "version": {"v1.0.0"}, "reason": {"reason"}, "detail": {"detail"}}}, got["skipped"])
+	require.Equal(t, []interface{}{protoValues{
+		"tool_version": {"v1.0.0"},
+		"generated_at": {"2020-01-01T00:00:00Z"},
+		"input_digest": {"sha256:00"},
+		"flags":        {"a=1", "b=2"},
+	}}, got["metadata"])
+}
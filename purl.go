@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// purlEscape percent-encodes the characters of a package URL component that are not unreserved.
+func purlEscape(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '.', c == '-', c == '_', c == '~':
+			sb.WriteByte(c)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+/* Template functions */
+
+// Purl returns the package URL of the module built for the dependency, as in pkg:golang/github.com/foo/bar@v1.2.3,
+// which identifies it in vulnerability databases. A dependency replaced by a local directory keeps the module path
+// and version it replaces.
+func Purl(licInfo detector.LicenceInfo) string {
+	mod := licInfo.Module
+	if licInfo.Replace != nil && licInfo.Replace.Version != "" {
+		mod = *licInfo.Replace
+	}
+
+	segments := strings.Split(mod.Path, "/")
+	for i, s := range segments {
+		segments[i] = purlEscape(s)
+	}
+
+	purl := "pkg:golang/" + strings.Join(segments, "/")
+	if mod.Version != "" {
+		purl += "@" + purlEscape(mod.Version)
+	}
+	return purl
+}
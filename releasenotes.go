@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"text/template"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// releaseNotes is the data passed to the template of the release notes fragment: the changes since the baseline and
+// the dependencies of the run.
+type releaseNotes struct {
+	detector.Changelog
+	Dependencies *detector.Dependencies
+}
+
+// defaultReleaseNotesTemplate is used when -release-notes-template is not set.
+const defaultReleaseNotesTemplate = `{{- define "licences" }}{{ if . }}{{ join . " AND " }}{{ else }}unknown licence{{ end }}{{ end -}}
+### Third-party changes
+{{- if .Empty }}
+
+No changes to the third-party dependencies.
+{{- end }}
+{{- if .New }}
+
+New dependencies:
+{{ range .New }}
+- {{ .Path }} {{ .NewVersion }} ({{ template "licences" .NewLicences }})
+{{- end }}
+{{- end }}
+{{- if .LicenceChanged }}
+
+Licence changes:
+{{ range .LicenceChanged }}
+- {{ .Path }} {{ .OldVersion }}{{ if ne .OldVersion .NewVersion }} => {{ .NewVersion }}{{ end }}: {{ template "licences" .OldLicences }} => {{ template "licences" .NewLicences }}
+{{- end }}
+{{- end }}
+{{- if .VersionChanged }}
+
+Updated dependencies:
+{{ range .VersionChanged }}
+- {{ .Path }} {{ .OldVersion }} => {{ .NewVersion }}
+{{- end }}
+{{- end }}
+{{- if .Removed }}
+
+Removed dependencies:
+{{ range .Removed }}
+- {{ .Path }} {{ .OldVersion }}
+{{- end }}
+{{- end }}
+`
+
+// writeReleaseNotes renders the changes since the baseline with the template at templatePath, or the default
+// template.
+func writeReleaseNotes(path, templatePath string, dependencies *detector.Dependencies) error {
+	var tmpl *template.Template
+	var err error
+	if templatePath == "" {
+		tmpl, err = template.New("release-notes").Funcs(templateFuncs).Parse(defaultReleaseNotesTemplate)
+	} else {
+		tmpl, err = template.New(filepath.Base(templatePath)).Funcs(templateFuncs).ParseFiles(templatePath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	notes := &releaseNotes{Dependencies: dependencies}
+	if dependencies.Changelog != nil {
+		notes.Changelog = *dependencies.Changelog
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, notes); err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+	return writeOutput(path, buf.Bytes())
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteReleaseNotes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-notes")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dependencies := &detector.Dependencies{
+		Changelog: &detector.Changelog{
+			New:            []detector.ChangelogEntry{{Path: "example.com/new", NewVersion: "v1.0.0", NewLicences: []string{"MIT"}}},
+			Removed:        []detector.ChangelogEntry{{Path: "example.com/old", OldVersion: "v0.1.0", OldLicences: []string{"BSD-3-Clause"}}},
+			VersionChanged: []detector.ChangelogEntry{{Path: "example.com/bumped", OldVersion: "v1.0.0", NewVersion: "v1.1.0"}},
+			LicenceChanged: []detector.ChangelogEntry{{Path: "example.com/relicensed", OldVersion: "v1.0.0", NewVersion: "v2.0.0", OldLicences: []string{"Apache-2.0"}}},
+		},
+	}
+
+	out := filepath.Join(dir, "notes.md")
+	require.NoError(t, writeReleaseNotes(out, "", dependencies))
+	data, err := ioutil.ReadFile(out)
+	require.NoError(t, err)
+	require.Equal(t, `### Third-party changes
+
+New dependencies:
+
+- example.com/new v1.0.0 (MIT)
+
+Licence changes:
+
+- example.com/relicensed v1.0.0 => v2.0.0: Apache-2.0 => unknown licence
+
+Updated dependencies:
+
+- example.com/bumped v1.0.0 => v1.1.0
+
+Removed dependencies:
+
+- example.com/old v0.1.0
+`, string(data))
+
+	require.NoError(t, writeReleaseNotes(out, "", &detector.Dependencies{Changelog: &detector.Changelog{}}))
+	data, err = ioutil.ReadFile(out)
+	require.NoError(t, err)
+	require.Equal(t, "### Third-party changes\n\nNo changes to the third-party dependencies.\n", string(data))
+}
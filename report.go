@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// report is the machine-readable representation of the detection results.
+type report struct {
+	Metadata *runMetadata       `json:"metadata,omitempty"`
+	Direct   []reportDependency `json:"direct"`
+	Indirect []reportDependency `json:"indirect,omitempty"`
+	Tools    []reportDependency `json:"tools,omitempty"`
+	Removed  []reportDependency `json:"removed,omitempty"`
+	Skipped  []reportSkipped    `json:"skipped,omitempty"`
+}
+
+type reportDependency struct {
+	Path              string               `json:"path"`
+	DisplayName       string               `json:"displayName,omitempty"`
+	Owner             string               `json:"owner,omitempty"`
+	Version           string               `json:"version,omitempty"`
+	Purl              string               `json:"purl,omitempty"`
+	PseudoVersion     *reportPseudoVersion `json:"pseudoVersion,omitempty"`
+	Time              string               `json:"time,omitempty"`
+	Indirect          bool                 `json:"indirect,omitempty"`
+	Replace           *reportReplace       `json:"replace,omitempty"`
+	Licences          []string             `json:"licences,omitempty"`
+	LicenceExpression string               `json:"licenceExpression,omitempty"`
+	Language          string               `json:"language,omitempty"`
+	Targets           []string             `json:"targets,omitempty"`
+	LicenceFile       string               `json:"licenceFile,omitempty"`
+	CopyrightFile     string               `json:"copyrightFile,omitempty"`
+	CandidateFiles    []reportCandidate    `json:"candidateFiles,omitempty"`
+	Evidence          []reportEvidence     `json:"evidence,omitempty"`
+	Source            string               `json:"source,omitempty"`
+	Verification      string               `json:"verification,omitempty"`
+	Subcomponents     []reportSubcomponent `json:"subcomponents,omitempty"`
+	Warnings          []string             `json:"warnings,omitempty"`
+	Vulnerabilities   []string             `json:"vulnerabilities,omitempty"`
+	Change            string               `json:"change,omitempty"`
+	Error             string               `json:"error,omitempty"`
+}
+
+// reportCandidate is a file that looks like a licence. Chosen is set for the file used as the licence of the
+// dependency.
+type reportCandidate struct {
+	Path   string `json:"path"`
+	Chosen bool   `json:"chosen,omitempty"`
+}
+
+// reportEvidence is a file examined during the detection. Reason is empty for the licence candidates and explains why
+// the other files were rejected.
+type reportEvidence struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// reportPseudoVersion is the commit identified by the pseudo-version of a dependency.
+type reportPseudoVersion struct {
+	Commit      string `json:"commit"`
+	Time        string `json:"time"`
+	BaseVersion string `json:"baseVersion,omitempty"`
+}
+
+// reportSubcomponent is a copy of another project inside a dependency, with a licence of its own.
+type reportSubcomponent struct {
+	Dir         string   `json:"dir"`
+	Licences    []string `json:"licences,omitempty"`
+	LicenceFile string   `json:"licenceFile"`
+}
+
+// reportSkipped is a module of the input left out of the results.
+type reportSkipped struct {
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+	Reason  string `json:"reason"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// reportReplace is the module replacing a dependency. Fork is set if it is a different module forked from the
+// dependency, which then identifies the original project.
+type reportReplace struct {
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+	Fork    bool   `json:"fork,omitempty"`
+}
+
+func mkReport(dependencies *detector.Dependencies) report {
+	metadata := currentRun
+	r := report{
+		Metadata: &metadata,
+		Direct:   mkReportDependencies(dependencies.Direct),
+		Indirect: mkReportDependencies(dependencies.Indirect),
+		Tools:    mkReportDependencies(dependencies.Tools),
+	}
+
+	for _, s := range dependencies.Skipped {
+		r.Skipped = append(r.Skipped, reportSkipped{Path: s.Path, Version: s.Version, Reason: s.Reason, Detail: s.Detail})
+	}
+
+	if dependencies.Changelog != nil {
+		for _, e := range dependencies.Changelog.Removed {
+			r.Removed = append(r.Removed, reportDependency{
+				Path:     e.Path,
+				Version:  e.OldVersion,
+				Licences: e.OldLicences,
+				Change:   string(detector.ChangeRemoved),
+			})
+		}
+	}
+
+	return r
+}
+
+func mkReportDependencies(deps []detector.LicenceInfo) []reportDependency {
+	reportDeps := make([]reportDependency, 0, len(deps))
+	for _, dep := range deps {
+		reportDeps = append(reportDeps, mkReportDependency(dep))
+	}
+	return reportDeps
+}
+
+func mkReportDependency(dep detector.LicenceInfo) reportDependency {
+	rd := reportDependency{
+		Path:              dep.Path,
+		DisplayName:       displayNames[dep.Path],
+		Owner:             Owner(dep),
+		Version:           dep.Version,
+		Purl:              Purl(dep),
+		Indirect:          dep.Indirect,
+		Licences:          dep.Licences,
+		LicenceExpression: dep.LicenceExpression,
+		Language:          dep.Language,
+		Targets:           Targets(dep),
+		LicenceFile:       displayPath(dep.LicenceFile),
+		CopyrightFile:     displayPath(dep.CopyrightFile),
+		Source:            dep.Source,
+		Warnings:          dep.Warnings,
+		Vulnerabilities:   Vulnerabilities(dep),
+		Change:            string(dep.Change),
+	}
+
+	for _, f := range dep.CandidateFiles {
+		rd.CandidateFiles = append(rd.CandidateFiles, reportCandidate{Path: displayPath(f), Chosen: f == dep.LicenceFile})
+	}
+
+	for _, e := range dep.Evidence {
+		rd.Evidence = append(rd.Evidence, reportEvidence{Path: displayPath(e.Path), Reason: e.Reason})
+	}
+
+	for _, sc := range dep.Subcomponents {
+		rd.Subcomponents = append(rd.Subcomponents, reportSubcomponent{Dir: sc.Dir, Licences: sc.Licences, LicenceFile: displayPath(sc.LicenceFile)})
+	}
+
+	mod := effectiveModule(dep)
+	rd.Verification = mod.Verification
+	if pv, ok := detector.ParsePseudoVersion(mod.Version); ok {
+		rd.PseudoVersion = &reportPseudoVersion{Commit: pv.Commit, Time: pv.Time.Format(time.RFC3339), BaseVersion: pv.Base}
+	}
+	if mod.Time != nil {
+		rd.Time = mod.Time.UTC().Format(time.RFC3339)
+	}
+
+	if dep.Replace != nil {
+		rd.Replace = &reportReplace{Path: dep.Replace.Path, Version: dep.Replace.Version, Fork: dep.IsFork()}
+	}
+
+	if dep.Error != nil {
+		rd.Error = dep.Error.Error()
+	}
+
+	return rd
+}
+
+// writePorcelain writes one JSON object per dependency and line.
+func writePorcelain(w io.Writer, dependencies *detector.Dependencies) error {
+	enc := json.NewEncoder(w)
+	for _, dep := range allDependencies(dependencies) {
+		if err := enc.Encode(mkReportDependency(dep)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func exportJSON(w io.Writer, dependencies *detector.Dependencies) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(mkReport(dependencies))
+}
+
+// loadBaseline reads a report written by -format json and returns the dependencies it recorded.
+func loadBaseline(path string) ([]detector.BaselineEntry, error) {
+	r, err := loadReport(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var baseline []detector.BaselineEntry
+	for _, deps := range [][]reportDependency{r.Direct, r.Indirect, r.Tools} {
+		for _, dep := range deps {
+			version := dep.Version
+			if dep.Replace != nil {
+				version = dep.Replace.Version
+			}
+			baseline = append(baseline, detector.BaselineEntry{Path: dep.Path, Version: version, Licences: dep.Licences})
+		}
+	}
+
+	return baseline, nil
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// selftestModules is the fixture module cache used by the selftest command, as file contents indexed by path. It
+// covers the licence file lookup, nested licences, several candidates, non-UTF-8 encodings and missing licences.
+var selftestModules = map[string]string{
+	"example.com/apache@v2.0.0/LICENSE.txt": "Apache License\nVersion 2.0, January 2004\n",
+	"example.com/apache@v2.0.0/apache.go":   "package apache\n",
+	"example.com/bsd@v1.3.0/docs/COPYING":   "Redistribution and use in source and binary forms, with or without modification, are permitted.\n",
+	"example.com/bsd@v1.3.0/bsd.go":         "package bsd\n",
+	"example.com/dual@v0.2.0/COPYING":       "Permission is hereby granted, free of charge, to any person obtaining a copy of this software.\n",
+	"example.com/dual@v0.2.0/LICENSE":       "Apache License\nVersion 2.0, January 2004\n",
+	"example.com/latin1@v1.0.0/LICENSE":     "Copyright (c) 2020 J\xfcrgen\n\nPermission is hereby granted, free of charge, to any person obtaining a copy of this software.\n",
+	"example.com/missing@v0.1.0/missing.go": "package missing\n",
+	"example.com/utf16@v1.0.0/LICENSE":      "\xff\xfeI\x00S\x00C\x00 \x00L\x00i\x00c\x00e\x00n\x00s\x00e\x00\n\x00",
+}
+
+// selftestInput is the dependency list of the fixture. The module directories are set once the cache is written.
+var selftestInput = []detector.Module{
+	{Path: "example.com/app", Main: true},
+	{Path: "example.com/apache", Version: "v2.0.0"},
+	{Path: "example.com/bsd", Version: "v1.3.0"},
+	{Path: "example.com/dual", Version: "v0.2.0"},
+	{Path: "example.com/latin1", Version: "v1.0.0", Indirect: true},
+	{Path: "example.com/missing", Version: "v0.1.0"},
+	{Path: "example.com/utf16", Version: "v0.9.0", Replace: &detector.Module{Path: "example.com/utf16", Version: "v1.0.0"}},
+}
+
+// selftestSummary is the expected detection result for each module of the fixture.
+const selftestSummary = `example.com/apache v2.0.0: Apache-2.0 from LICENSE.txt (candidates: LICENSE.txt)
+example.com/bsd v1.3.0: BSD-2-Clause from docs/COPYING (candidates: docs/COPYING)
+example.com/dual v0.2.0: MIT from COPYING (candidates: COPYING, LICENSE)
+example.com/missing v0.1.0: licence not found
+example.com/utf16 v1.0.0: unknown licence from LICENSE (candidates: LICENSE)
+example.com/latin1 v1.0.0: MIT from LICENSE (candidates: LICENSE)
+`
+
+// selftestNotice is the expected rendering of the fixture with the notice preset.
+const selftestNotice = `================================================================================
+Third party libraries
+================================================================================
+
+--------------------------------------------------------------------------------
+Module  : example.com/apache
+Version : v2.0.0
+Licence : Apache-2.0
+
+Contents of probable licence file example.com/apache@v2.0.0/LICENSE.txt:
+
+Apache License
+Version 2.0, January 2004
+
+
+--------------------------------------------------------------------------------
+Module  : example.com/bsd
+Version : v1.3.0
+Licence : BSD-2-Clause
+
+Contents of probable licence file example.com/bsd@v1.3.0/docs/COPYING:
+
+Redistribution and use in source and binary forms, with or without modification, are permitted.
+
+
+--------------------------------------------------------------------------------
+Module  : example.com/dual
+Version : v0.2.0
+Licence : MIT
+
+Contents of probable licence file example.com/dual@v0.2.0/COPYING:
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software.
+
+
+--------------------------------------------------------------------------------
+Module  : example.com/missing
+Version : v0.1.0
+Licence : Unknown
+
+failed to detect licence
+
+--------------------------------------------------------------------------------
+Module  : example.com/utf16 => example.com/utf16
+Version : v1.0.0
+Licence : Unknown
+
+Contents of probable licence file example.com/utf16@v1.0.0/LICENSE:
+
+ISC License
+
+
+================================================================================
+Indirect dependencies
+================================================================================
+
+--------------------------------------------------------------------------------
+Module  : example.com/latin1
+Version : v1.0.0
+Licence : MIT
+
+Contents of probable licence file example.com/latin1@v1.0.0/LICENSE:
+
+Copyright (c) 2020 Jürgen
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software.
+
+
+`
+
+// selftest runs the detection against a fixture module cache and compares the results with the expected ones, to
+// check that the binary behaves as expected in the local environment.
+func selftest() {
+	if err := runSelftest(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runSelftest(w io.Writer) error {
+	printVersion(w)
+	fmt.Fprintf(w, "Platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	for _, name := range []string{"LANG", "LC_ALL", "LC_CTYPE"} {
+		if value := os.Getenv(name); value != "" {
+			fmt.Fprintf(w, "%s: %s\n", name, value)
+		}
+	}
+
+	cache, err := ioutil.TempDir("", "licence-detector-selftest")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(cache)
+
+	if err := writeSelftestModules(cache); err != nil {
+		return fmt.Errorf("failed to write the fixture module cache: %w", err)
+	}
+
+	input, err := selftestDependencies(cache)
+	if err != nil {
+		return err
+	}
+
+	dependencies, err := detector.DetectWithOptions(input, &detector.Options{IncludeIndirect: true})
+	if err != nil {
+		return fmt.Errorf("failed to detect licences: %w", err)
+	}
+
+	failed := !checkSelftest(w, "detection", selftestSummary, summariseSelftest(cache, dependencies))
+
+	tmpl, err := loadTemplate("", "notice")
+	if err != nil {
+		return err
+	}
+
+	var notice bytes.Buffer
+	if err := tmpl.Execute(&notice, dependencies); err != nil {
+		return fmt.Errorf("failed to render notice: %w", err)
+	}
+	rendered := strings.Replace(notice.String(), cache+string(filepath.Separator), "", -1)
+	failed = !checkSelftest(w, "notice", selftestNotice, filepath.ToSlash(rendered)) || failed
+
+	if failed {
+		return fmt.Errorf("selftest failed: the results cannot be trusted in this environment")
+	}
+	return nil
+}
+
+func writeSelftestModules(cache string) error {
+	for name, content := range selftestModules {
+		path := filepath.Join(cache, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selftestDependencies returns the fixture dependency list with the directories of the modules in the cache.
+func selftestDependencies(cache string) (io.Reader, error) {
+	mods := make([]detector.Module, len(selftestInput))
+	for i, mod := range selftestInput {
+		src := mod
+		if mod.Replace != nil {
+			replace := *mod.Replace
+			mod.Replace, src = &replace, replace
+		}
+		if !mod.Main {
+			mod.Dir = detector.ModuleCacheDir(cache, src.Path, src.Version)
+			if mod.Replace != nil {
+				mod.Replace.Dir = mod.Dir
+			}
+		}
+		mods[i] = mod
+	}
+
+	return encodeModules(mods)
+}
+
+func summariseSelftest(cache string, dependencies *detector.Dependencies) string {
+	rel := func(dep detector.LicenceInfo, path string) string {
+		mod := effectiveModule(dep)
+		r, err := filepath.Rel(detector.ModuleCacheDir(cache, mod.Path, mod.Version), path)
+		if err != nil {
+			return path
+		}
+		return filepath.ToSlash(r)
+	}
+
+	var buf bytes.Buffer
+	for _, dep := range allDependencies(dependencies) {
+		fmt.Fprintf(&buf, "%s %s: ", dep.Path, effectiveModule(dep).Version)
+		if dep.LicenceFile == "" {
+			buf.WriteString("licence not found\n")
+			continue
+		}
+
+		licence := "unknown licence"
+		if len(dep.Licences) > 0 {
+			licence = strings.Join(dep.Licences, " AND ")
+		}
+
+		candidates := make([]string, len(dep.CandidateFiles))
+		for i, f := range dep.CandidateFiles {
+			candidates[i] = rel(dep, f)
+		}
+		fmt.Fprintf(&buf, "%s from %s (candidates: %s)\n", licence, rel(dep, dep.LicenceFile), strings.Join(candidates, ", "))
+	}
+
+	return buf.String()
+}
+
+// checkSelftest reports whether the output of a check matches the expected one, showing the first difference.
+func checkSelftest(w io.Writer, name, want, got string) bool {
+	if want == got {
+		fmt.Fprintf(w, "ok   %s\n", name)
+		return true
+	}
+
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; ; i++ {
+		var wantLine, gotLine string
+		if i < len(wantLines) {
+			wantLine = wantLines[i]
+		}
+		if i < len(gotLines) {
+			gotLine = gotLines[i]
+		}
+
+		if wantLine != gotLine || i >= len(wantLines) || i >= len(gotLines) {
+			fmt.Fprintf(w, "FAIL %s: line %d differs\n  want: %q\n  got:  %q\n", name, i+1, wantLine, gotLine)
+			return false
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestSelftest keeps the expected results of the selftest command in sync with the detection and the notice preset.
+func TestSelftest(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, runSelftest(&buf), buf.String())
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// signOutput writes a detached signature of the output file to <output>.sig. The signature is base64 encoded so
+// that ECDSA signatures can be checked with `cosign verify-blob --key`.
+func signOutput(outputPath, keyPath string) error {
+	signer, err := loadSigningKey(keyPath)
+	if err != nil {
+		return fmt.Errorf("failed to load signing key from %s: %w", keyPath, err)
+	}
+
+	data, err := ioutil.ReadFile(outputPath)
+	if err != nil {
+		return err
+	}
+
+	var sig []byte
+	if _, ok := signer.(ed25519.PrivateKey); ok {
+		sig, err = signer.Sign(rand.Reader, data, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(data)
+		sig, err = signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sign %s: %w", outputPath, err)
+	}
+
+	return ioutil.WriteFile(outputPath+".sig", []byte(base64.StdEncoding.EncodeToString(sig)), 0644)
+}
+
+func loadSigningKey(keyPath string) (crypto.Signer, error) {
+	keyPEM, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		switch k := key.(type) {
+		case *ecdsa.PrivateKey:
+			return k, nil
+		case *rsa.PrivateKey:
+			return k, nil
+		case ed25519.PrivateKey:
+			return k, nil
+		}
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q: encrypted keys must be decrypted first", block.Type)
+	}
+}
+
+// writeChecksum writes the SHA-256 digest of the output file to <output>.sha256 in the format understood by
+// `sha256sum -c`.
+func writeChecksum(outputPath string) error {
+	digest, err := sha256File(outputPath)
+	if err != nil {
+		return err
+	}
+
+	line := fmt.Sprintf("%s  %s\n", digest, filepath.Base(outputPath))
+	return ioutil.WriteFile(outputPath+".sha256", []byte(line), 0644)
+}
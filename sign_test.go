@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignOutput(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+	pkcs8 := func(key interface{}) []byte {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		return der
+	}
+
+	// the ECDSA signatures are ASN.1 encoded, as expected by cosign
+	verifyECDSA := func(data, sig []byte) bool {
+		var esig struct{ R, S *big.Int }
+		if rest, err := asn1.Unmarshal(sig, &esig); err != nil || len(rest) > 0 {
+			return false
+		}
+		digest := sha256.Sum256(data)
+		return ecdsa.Verify(&ecKey.PublicKey, digest[:], esig.R, esig.S)
+	}
+	verifyRSA := func(data, sig []byte) bool {
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, digest[:], sig) == nil
+	}
+	verifyEd25519 := func(data, sig []byte) bool {
+		return ed25519.Verify(edKey.Public().(ed25519.PublicKey), data, sig)
+	}
+
+	testCases := []struct {
+		name   string
+		block  *pem.Block
+		verify func(data, sig []byte) bool
+	}{
+		{name: "ECDSA", block: &pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}, verify: verifyECDSA},
+		{name: "ECDSAPKCS8", block: &pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8(ecKey)}, verify: verifyECDSA},
+		{name: "RSA", block: &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}, verify: verifyRSA},
+		{name: "RSAPKCS8", block: &pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8(rsaKey)}, verify: verifyRSA},
+		{name: "Ed25519", block: &pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8(edKey)}, verify: verifyEd25519},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "sign")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			keyPath := filepath.Join(dir, "key.pem")
+			require.NoError(t, ioutil.WriteFile(keyPath, pem.EncodeToMemory(tc.block), 0600))
+			outputPath := filepath.Join(dir, "NOTICE.txt")
+			data := []byte("notice contents\n")
+			require.NoError(t, ioutil.WriteFile(outputPath, data, 0644))
+
+			require.NoError(t, signOutput(outputPath, keyPath))
+
+			encoded, err := ioutil.ReadFile(outputPath + ".sig")
+			require.NoError(t, err)
+			sig, err := base64.StdEncoding.DecodeString(string(encoded))
+			require.NoError(t, err)
+			require.True(t, tc.verify(data, sig))
+			require.False(t, tc.verify([]byte("tampered\n"), sig))
+		})
+	}
+}
+
+func TestLoadSigningKeyErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sign")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	testCases := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{name: "NotPEM", data: []byte("not a key"), wantErr: "no PEM data found"},
+		{name: "Encrypted", data: pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: []byte{0}}), wantErr: "encrypted keys must be decrypted first"},
+		{name: "Invalid", data: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte{0}}), wantErr: "asn1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			keyPath := filepath.Join(dir, tc.name+".pem")
+			require.NoError(t, ioutil.WriteFile(keyPath, tc.data, 0600))
+
+			_, err := loadSigningKey(keyPath)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestWriteChecksum(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checksum")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	outputPath := filepath.Join(dir, "NOTICE.txt")
+	require.NoError(t, ioutil.WriteFile(outputPath, []byte("hello"), 0644))
+	require.NoError(t, writeChecksum(outputPath))
+
+	line, err := ioutil.ReadFile(outputPath + ".sha256")
+	require.NoError(t, err)
+	require.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824  NOTICE.txt\n", string(line))
+}
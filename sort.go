@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// sortKeys order dependencies by a key, falling back to the module path.
+var sortKeys = map[string]func(detector.LicenceInfo) string{
+	"licence": func(dep detector.LicenceInfo) string { return strings.Join(dep.Licences, " AND ") },
+	"org":     Org,
+	"path":    func(detector.LicenceInfo) string { return "" },
+	"project": Project,
+}
+
+func sortKeyNames() []string {
+	names := make([]string, 0, len(sortKeys))
+	for name := range sortKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedDependencies returns a copy of the dependencies with every list sorted by the given key.
+func sortedDependencies(dependencies *detector.Dependencies, key string) (*detector.Dependencies, error) {
+	sorted := *dependencies
+
+	var err error
+	if sorted.Direct, err = SortBy(key, dependencies.Direct); err != nil {
+		return nil, err
+	}
+	if sorted.Indirect, err = SortBy(key, dependencies.Indirect); err != nil {
+		return nil, err
+	}
+	if sorted.Tools, err = SortBy(key, dependencies.Tools); err != nil {
+		return nil, err
+	}
+
+	return &sorted, nil
+}
+
+/* Template functions */
+
+// SortBy returns a copy of the dependencies sorted by path, licence or org.
+func SortBy(key string, deps []detector.LicenceInfo) ([]detector.LicenceInfo, error) {
+	keyFn, ok := sortKeys[key]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort key %q: must be one of %s", key, strings.Join(sortKeyNames(), ", "))
+	}
+
+	if deps == nil {
+		return nil, nil
+	}
+
+	sorted := make([]detector.LicenceInfo, len(deps))
+	copy(sorted, deps)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ki, kj := keyFn(sorted[i]), keyFn(sorted[j])
+		if ki != kj {
+			return ki < kj
+		}
+		return sorted[i].Path < sorted[j].Path
+	})
+
+	return sorted, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func mkSortDep(path, version string, licences ...string) detector.LicenceInfo {
+	return detector.LicenceInfo{Module: detector.Module{Path: path, Version: version}, Licences: licences}
+}
+
+func sortedIDs(deps []detector.LicenceInfo) []string {
+	ids := make([]string, len(deps))
+	for i, dep := range deps {
+		ids[i] = dep.Path + "@" + dep.Version
+	}
+	return ids
+}
+
+func TestSortBy(t *testing.T) {
+	deps := []detector.LicenceInfo{
+		mkSortDep("k8s.io/klog/v2", "v2.0.0", "Apache-2.0"),
+		mkSortDep("github.com/foo/bar/v2", "v2.1.0", "MIT"),
+		mkSortDep("github.com/elastic/go-ucfg", "v0.8.0", "Apache-2.0"),
+		mkSortDep("github.com/foo/bar-baz", "v1.0.0", "ISC"),
+		mkSortDep("github.com/foo/bar", "v1.4.0", "MIT"),
+		mkSortDep("gopkg.in/yaml.v2", "v2.3.0", "Apache-2.0", "MIT"),
+		mkSortDep("github.com/elastic-x/a", "v1.0.0", "BSD-3-Clause"),
+		mkSortDep("k8s.io/api", "v0.18.0"),
+	}
+
+	testCases := []struct {
+		key  string
+		want []string
+	}{
+		{
+			key: "path",
+			want: []string{
+				"github.com/elastic-x/a@v1.0.0",
+				"github.com/elastic/go-ucfg@v0.8.0",
+				"github.com/foo/bar@v1.4.0",
+				"github.com/foo/bar-baz@v1.0.0",
+				"github.com/foo/bar/v2@v2.1.0",
+				"gopkg.in/yaml.v2@v2.3.0",
+				"k8s.io/api@v0.18.0",
+				"k8s.io/klog/v2@v2.0.0",
+			},
+		},
+		{
+			key: "licence",
+			want: []string{
+				"k8s.io/api@v0.18.0",
+				"github.com/elastic/go-ucfg@v0.8.0",
+				"k8s.io/klog/v2@v2.0.0",
+				"gopkg.in/yaml.v2@v2.3.0",
+				"github.com/elastic-x/a@v1.0.0",
+				"github.com/foo/bar-baz@v1.0.0",
+				"github.com/foo/bar@v1.4.0",
+				"github.com/foo/bar/v2@v2.1.0",
+			},
+		},
+		{
+			// github.com/elastic sorts before github.com/elastic-x although the paths sort the other way round
+			key: "org",
+			want: []string{
+				"github.com/elastic/go-ucfg@v0.8.0",
+				"github.com/elastic-x/a@v1.0.0",
+				"github.com/foo/bar@v1.4.0",
+				"github.com/foo/bar-baz@v1.0.0",
+				"github.com/foo/bar/v2@v2.1.0",
+				"gopkg.in/yaml.v2@v2.3.0",
+				"k8s.io/api@v0.18.0",
+				"k8s.io/klog/v2@v2.0.0",
+			},
+		},
+		{
+			// the major versions of github.com/foo/bar are kept together
+			key: "project",
+			want: []string{
+				"github.com/elastic-x/a@v1.0.0",
+				"github.com/elastic/go-ucfg@v0.8.0",
+				"github.com/foo/bar@v1.4.0",
+				"github.com/foo/bar/v2@v2.1.0",
+				"github.com/foo/bar-baz@v1.0.0",
+				"gopkg.in/yaml.v2@v2.3.0",
+				"k8s.io/api@v0.18.0",
+				"k8s.io/klog/v2@v2.0.0",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.key, func(t *testing.T) {
+			input := make([]detector.LicenceInfo, len(deps))
+			copy(input, deps)
+
+			have, err := SortBy(tc.key, input)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, sortedIDs(have))
+			require.Equal(t, deps, input, "the input must not be modified")
+		})
+	}
+}
+
+func TestSortByStable(t *testing.T) {
+	// dependencies with the same key and path keep their relative order
+	deps := []detector.LicenceInfo{
+		mkSortDep("github.com/foo/bar", "v1.2.0", "MIT"),
+		mkSortDep("github.com/elastic/go-ucfg", "v0.8.0", "MIT"),
+		mkSortDep("github.com/foo/bar", "v1.0.0", "MIT"),
+		mkSortDep("github.com/foo/bar", "v1.1.0", "MIT"),
+	}
+
+	for _, key := range sortKeyNames() {
+		t.Run(key, func(t *testing.T) {
+			have, err := SortBy(key, deps)
+			require.NoError(t, err)
+			require.Equal(t, []string{
+				"github.com/elastic/go-ucfg@v0.8.0",
+				"github.com/foo/bar@v1.2.0",
+				"github.com/foo/bar@v1.0.0",
+				"github.com/foo/bar@v1.1.0",
+			}, sortedIDs(have))
+		})
+	}
+}
+
+func TestSortByUnknownKey(t *testing.T) {
+	for _, key := range []string{"", "Path", "version", "licences"} {
+		t.Run(key, func(t *testing.T) {
+			_, err := SortBy(key, []detector.LicenceInfo{mkSortDep("github.com/foo/bar", "v1.0.0")})
+			require.EqualError(t, err, `unknown sort key "`+key+`": must be one of licence, org, path, project`)
+		})
+	}
+
+	// the key is validated even when there is nothing to sort
+	_, err := SortBy("version", nil)
+	require.Error(t, err)
+
+	_, err = sortedDependencies(&detector.Dependencies{}, "version")
+	require.Error(t, err)
+}
+
+func TestSortedDependencies(t *testing.T) {
+	dependencies := &detector.Dependencies{
+		Direct:   []detector.LicenceInfo{mkSortDep("github.com/foo/bar", "v1.0.0", "MIT"), mkSortDep("github.com/elastic/go-ucfg", "v0.8.0", "Apache-2.0")},
+		Indirect: []detector.LicenceInfo{mkSortDep("k8s.io/api", "v0.18.0", "Apache-2.0"), mkSortDep("gopkg.in/yaml.v2", "v2.3.0", "MIT")},
+	}
+
+	sorted, err := sortedDependencies(dependencies, "licence")
+	require.NoError(t, err)
+	require.Equal(t, []string{"github.com/elastic/go-ucfg@v0.8.0", "github.com/foo/bar@v1.0.0"}, sortedIDs(sorted.Direct))
+	require.Equal(t, []string{"k8s.io/api@v0.18.0", "gopkg.in/yaml.v2@v2.3.0"}, sortedIDs(sorted.Indirect))
+	require.Nil(t, sorted.Tools)
+
+	// the original lists are left untouched
+	require.Equal(t, "github.com/foo/bar", dependencies.Direct[0].Path)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"strings"
+)
+
+// spdxNames are the readable names of the common licences and exceptions, by SPDX identifier. Other identifiers are
+// rendered as they are.
+var spdxNames = map[string]string{
+	"0BSD":                    "BSD Zero Clause License",
+	"AGPL-3.0":                "GNU Affero General Public License v3.0",
+	"Apache-2.0":              "Apache License 2.0",
+	"BSD-2-Clause":            "BSD 2-Clause License",
+	"BSD-3-Clause":            "BSD 3-Clause License",
+	"BSL-1.0":                 "Boost Software License 1.0",
+	"BUSL-1.1":                "Business Source License 1.1",
+	"CC0-1.0":                 "Creative Commons Zero v1.0 Universal",
+	"Classpath-exception-2.0": "Classpath exception 2.0",
+	"EPL-1.0":                 "Eclipse Public License 1.0",
+	"EPL-2.0":                 "Eclipse Public License 2.0",
+	"GPL-2.0":                 "GNU General Public License v2.0",
+	"GPL-3.0":                 "GNU General Public License v3.0",
+	"ISC":                     "ISC License",
+	"LGPL-2.0":                "GNU Library General Public License v2",
+	"LGPL-2.1":                "GNU Lesser General Public License v2.1",
+	"LGPL-3.0":                "GNU Lesser General Public License v3.0",
+	"LLVM-exception":          "LLVM exception",
+	"MIT":                     "MIT",
+	"MPL-2.0":                 "Mozilla Public License 2.0",
+	"Unlicense":               "The Unlicense",
+	"Zlib":                    "zlib License",
+}
+
+// spdxNode is a node of a parsed SPDX licence expression: a licence identifier, or an operator (AND, OR, WITH) and
+// its operands.
+type spdxNode struct {
+	op       string
+	id       string
+	operands []*spdxNode
+}
+
+// parseSPDXExpression parses an SPDX licence expression, such as (MIT AND BSD-3-Clause) OR Apache-2.0. It returns
+// false if the expression is invalid.
+func parseSPDXExpression(expr string) (*spdxNode, bool) {
+	tokens := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr))
+	p := &spdxParser{tokens: tokens}
+	node := p.parse("OR")
+	if node == nil || p.pos != len(tokens) {
+		return nil, false
+	}
+	return node, true
+}
+
+type spdxParser struct {
+	tokens []string
+	pos    int
+}
+
+// spdxPrecedence lists the operators from the loosest to the tightest.
+var spdxPrecedence = []string{"OR", "AND", "WITH"}
+
+func (p *spdxParser) parse(op string) *spdxNode {
+	level := 0
+	for spdxPrecedence[level] != op {
+		level++
+	}
+
+	var operand func() *spdxNode
+	if level+1 < len(spdxPrecedence) {
+		next := spdxPrecedence[level+1]
+		operand = func() *spdxNode { return p.parse(next) }
+	} else {
+		operand = p.parsePrimary
+	}
+
+	node := operand()
+	if node == nil {
+		return nil
+	}
+	for p.pos < len(p.tokens) && strings.ToUpper(p.tokens[p.pos]) == op {
+		p.pos++
+		right := operand()
+		if right == nil {
+			return nil
+		}
+		if node.op != op {
+			node = &spdxNode{op: op, operands: []*spdxNode{node}}
+		}
+		node.operands = append(node.operands, right)
+	}
+	return node
+}
+
+func (p *spdxParser) parsePrimary() *spdxNode {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+
+	tok := p.tokens[p.pos]
+	p.pos++
+	switch strings.ToUpper(tok) {
+	case "(":
+		node := p.parse("OR")
+		if node == nil || p.pos >= len(p.tokens) || p.tokens[p.pos] != ")" {
+			return nil
+		}
+		p.pos++
+		// parentheses are kept so that nested alternatives are not merged with the enclosing ones
+		return &spdxNode{op: "()", operands: []*spdxNode{node}}
+	case ")", "AND", "OR", "WITH":
+		return nil
+	}
+	return &spdxNode{id: tok}
+}
+
+// spdxName returns the readable name of the licence or exception identifier.
+func spdxName(id string) string {
+	base, suffix := id, ""
+	switch {
+	case strings.HasSuffix(id, "+"):
+		base, suffix = strings.TrimSuffix(id, "+"), " or later"
+	case strings.HasSuffix(id, "-or-later"):
+		base, suffix = strings.TrimSuffix(id, "-or-later"), " or later"
+	case strings.HasSuffix(id, "-only"):
+		base, suffix = strings.TrimSuffix(id, "-only"), " only"
+	}
+
+	if name, ok := spdxNames[base]; ok {
+		return name + suffix
+	}
+	return id
+}
+
+func (n *spdxNode) prose(top bool) string {
+	switch n.op {
+	case "":
+		return spdxName(n.id)
+	case "()":
+		return n.operands[0].prose(top)
+	case "WITH":
+		return n.operands[0].prose(false) + " with " + n.operands[1].prose(false)
+	}
+
+	parts := make([]string, len(n.operands))
+	for i, o := range n.operands {
+		parts[i] = o.prose(false)
+	}
+
+	if n.op == "AND" {
+		return joinProse(parts, "and")
+	}
+	if top {
+		return joinProse(parts, "or") + ", at your option"
+	}
+	return "either " + joinProse(parts, "or")
+}
+
+// joinProse joins the parts as in "A, B and C".
+func joinProse(parts []string, conj string) string {
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " " + conj + " " + parts[len(parts)-1]
+}
+
+/* Template functions */
+
+// SPDXPretty renders an SPDX licence expression as readable prose, for example "MIT or Apache License 2.0, at your
+// option" for MIT OR Apache-2.0. Invalid expressions are returned unchanged.
+func SPDXPretty(expr string) string {
+	node, ok := parseSPDXExpression(expr)
+	if !ok {
+		return expr
+	}
+	return node.prose(true)
+}
+
+// SPDXURL returns the URL of the page of the licence or exception on spdx.org, or an empty string for the licences
+// outside the SPDX list (LicenseRef-).
+func SPDXURL(id string) string {
+	id = strings.TrimSuffix(id, "+")
+	if id == "" || strings.HasPrefix(id, "LicenseRef-") || strings.ContainsAny(id, " ()") {
+		return ""
+	}
+	return "https://spdx.org/licenses/" + id + ".html"
+}
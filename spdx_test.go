@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSPDXPretty(t *testing.T) {
+	testCases := []struct {
+		expr string
+		want string
+	}{
+		{expr: "MIT", want: "MIT"},
+		{expr: "MIT OR Apache-2.0", want: "MIT or Apache License 2.0, at your option"},
+		{expr: "MIT or BSD-2-Clause or ISC", want: "MIT, BSD 2-Clause License or ISC License, at your option"},
+		{expr: "MIT AND BSD-3-Clause", want: "MIT and BSD 3-Clause License"},
+		{expr: "(MIT AND Zlib) OR Apache-2.0", want: "MIT and zlib License or Apache License 2.0, at your option"},
+		{expr: "MIT AND (GPL-2.0-only OR GPL-3.0-or-later)", want: "MIT and either GNU General Public License v2.0 only or GNU General Public License v3.0 or later"},
+		{expr: "GPL-2.0+ WITH Classpath-exception-2.0", want: "GNU General Public License v2.0 or later with Classpath exception 2.0"},
+		{expr: "LicenseRef-Custom", want: "LicenseRef-Custom"},
+		{expr: "MIT OR", want: "MIT OR"},
+		{expr: "(MIT", want: "(MIT"},
+		{expr: "", want: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			require.Equal(t, tc.want, SPDXPretty(tc.expr))
+		})
+	}
+}
+
+func TestSPDXURL(t *testing.T) {
+	require.Equal(t, "https://spdx.org/licenses/Apache-2.0.html", SPDXURL("Apache-2.0"))
+	require.Equal(t, "https://spdx.org/licenses/GPL-2.0.html", SPDXURL("GPL-2.0+"))
+	require.Equal(t, "", SPDXURL("LicenseRef-Custom"))
+}
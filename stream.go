@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// streamFormat writes each dependency as a JSON line as soon as its licence has been detected, so that consumers
+// can process the results while the detection is still running. Changes since the baseline are not included
+// because they are only known once all dependencies have been detected.
+const streamFormat = "ndjson"
+
+type streamWriter struct {
+	encoder *json.Encoder
+	cleanup func()
+	err     error
+}
+
+func newStreamWriter(outputPath string) (*streamWriter, error) {
+	w, cleanup, err := mkWriter(outputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &streamWriter{encoder: json.NewEncoder(w), cleanup: cleanup}, nil
+}
+
+func (s *streamWriter) record(dep detector.LicenceInfo, _ time.Duration) {
+	if s.err == nil {
+		s.err = s.encoder.Encode(mkReportDependency(dep))
+	}
+}
+
+// close releases the output and returns the first error encountered while writing.
+func (s *streamWriter) close() error {
+	s.cleanup()
+	return s.err
+}
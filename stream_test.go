@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stream")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	outPath := filepath.Join(dir, "deps.ndjson")
+	s, err := newStreamWriter(outPath)
+	require.NoError(t, err)
+
+	deps := allDependencies(mkExportDeps())
+	for i, dep := range deps {
+		s.record(dep, 0)
+
+		// each record is written out as a complete line as soon as it is recorded
+		contents, err := ioutil.ReadFile(outPath)
+		require.NoError(t, err)
+		require.True(t, bytes.HasSuffix(contents, []byte("\n")))
+
+		lines := strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n")
+		require.Len(t, lines, i+1)
+
+		var got reportDependency
+		require.NoError(t, json.Unmarshal([]byte(lines[i]), &got))
+		require.Equal(t, mkReportDependency(dep), got)
+	}
+
+	require.NoError(t, s.close())
+}
+
+func TestStreamWriterCreateError(t *testing.T) {
+	_, err := newStreamWriter(filepath.Join("testdata", "missing", "deps.ndjson"))
+	require.Error(t, err)
+}
+
+// failingWriter fails every write after the first n.
+type failingWriter struct {
+	n      int
+	writes int
+	buf    bytes.Buffer
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.writes > w.n {
+		return 0, errors.New("disk full")
+	}
+	return w.buf.Write(p)
+}
+
+func TestStreamWriterError(t *testing.T) {
+	fw := &failingWriter{n: 1}
+	closed := false
+	s := &streamWriter{encoder: json.NewEncoder(fw), cleanup: func() { closed = true }}
+
+	deps := allDependencies(mkExportDeps())
+	for _, dep := range deps {
+		s.record(dep, 0)
+	}
+
+	// the records after the first failure are dropped rather than written out of sequence
+	require.Equal(t, 2, fw.writes)
+	require.Equal(t, 1, strings.Count(fw.buf.String(), "\n"))
+
+	err := s.close()
+	require.EqualError(t, err, "disk full")
+	require.True(t, closed)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+const defaultGOSUMDB = "sum.golang.org"
+
+// Verification statuses of downloaded module sources. The checksum database records are not authenticated, so the
+// sources matching them are not reported as verified.
+const (
+	verifiedGoSum   = "go.sum"
+	unverifiedSumDB = "sumdb-unverified"
+	unverified      = "unverified"
+)
+
+// checksumDB looks up module hashes in a Go checksum database. Only the records are used: neither the note signatures
+// nor the signed tree heads are checked against the transparency log, so the database is trusted on the basis of its
+// TLS certificate alone.
+type checksumDB struct {
+	url     string   // empty if GOSUMDB=off
+	private []string // GONOSUMDB patterns of modules that are not looked up
+	client  *http.Client
+}
+
+func newChecksumDB(client *http.Client) *checksumDB {
+	db := &checksumDB{client: client}
+
+	private := os.Getenv("GONOSUMDB")
+	if private == "" {
+		private = os.Getenv("GOPRIVATE")
+	}
+	for _, p := range strings.Split(private, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			db.private = append(db.private, p)
+		}
+	}
+
+	gosumdb := os.Getenv("GOSUMDB")
+	if gosumdb == "" {
+		gosumdb = defaultGOSUMDB
+	}
+	if gosumdb == "off" {
+		return db
+	}
+
+	// GOSUMDB is "name[+key] [url]"
+	fields := strings.Fields(gosumdb)
+	db.url = "https://" + strings.SplitN(fields[0], "+", 2)[0]
+	if len(fields) > 1 {
+		db.url = fields[1]
+	}
+	db.url = strings.TrimSuffix(db.url, "/")
+
+	return db
+}
+
+// verify checks the hash of module sources against go.sum, then against the checksum database for modules missing
+// from go.sum, and returns the verification status.
+func (db *checksumDB) verify(modPath, version, hash string, sums detector.GoSum) (string, error) {
+	if want := sums.Hash(modPath, version); want != "" {
+		if hash != want {
+			return "", permanentError{fmt.Errorf("checksum mismatch for %s@%s: go.sum has %s, downloaded %s", modPath, version, want, hash)}
+		}
+		return verifiedGoSum, nil
+	}
+
+	if db.url == "" || matchPrefixPatterns(db.private, modPath) {
+		return unverified, nil
+	}
+
+	want, err := db.lookup(modPath, version)
+	if err != nil {
+		return "", err
+	}
+	if hash != want {
+		return "", permanentError{fmt.Errorf("checksum mismatch for %s@%s: checksum database has %s, downloaded %s", modPath, version, want, hash)}
+	}
+	return unverifiedSumDB, nil
+}
+
+func (db *checksumDB) lookup(modPath, version string) (string, error) {
+	url := fmt.Sprintf("%s/lookup/%s@%s", db.url, detector.EscapeModulePath(modPath), detector.EscapeModulePath(version))
+
+	var hash string
+	err := remote.do(func() error {
+		resp, err := db.client.Get(url)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if err := checkResponseStatus(resp); err != nil {
+			return fmt.Errorf("%s: %w", url, err)
+		}
+
+		// the record lists the go.sum lines of the module version, followed by the signed tree head
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 3 && fields[0] == modPath && fields[1] == version {
+				hash = fields[2]
+				return nil
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+
+		return permanentError{fmt.Errorf("%s: no hash recorded for %s@%s", url, modPath, version)}
+	})
+
+	return hash, err
+}
+
+// matchPrefixPatterns reports whether any of the glob patterns, as used by GOPRIVATE, matches a prefix of the module
+// path made of the same number of elements.
+func matchPrefixPatterns(patterns []string, modPath string) bool {
+	for _, pattern := range patterns {
+		n := strings.Count(pattern, "/") + 1
+		prefix := modPath
+		for i := 0; i < len(modPath); i++ {
+			if modPath[i] == '/' {
+				if n--; n == 0 {
+					prefix = modPath[:i]
+					break
+				}
+			}
+		}
+
+		if n > 1 {
+			continue
+		}
+		if ok, _ := path.Match(pattern, prefix); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksumDBVerify(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/lookup/example.com/!upper@v1.0.0", r.URL.Path)
+		w.Write([]byte("12345\nexample.com/Upper v1.0.0 h1:sumdb=\nexample.com/Upper v1.0.0/go.mod h1:gomod=\n\ngo.sum database tree\n"))
+	}))
+	defer srv.Close()
+
+	db := &checksumDB{url: srv.URL, private: []string{"corp.example.com"}, client: srv.Client()}
+	sums := detector.GoSum{"example.com/pinned": {"v1.0.0": "h1:gosum="}}
+
+	testCases := []struct {
+		name       string
+		modPath    string
+		hash       string
+		wantStatus string
+		wantErr    bool
+	}{
+		{name: "GoSum", modPath: "example.com/pinned", hash: "h1:gosum=", wantStatus: verifiedGoSum},
+		{name: "GoSumMismatch", modPath: "example.com/pinned", hash: "h1:other=", wantErr: true},
+		{name: "SumDB", modPath: "example.com/Upper", hash: "h1:sumdb=", wantStatus: unverifiedSumDB},
+		{name: "SumDBMismatch", modPath: "example.com/Upper", hash: "h1:other=", wantErr: true},
+		{name: "Private", modPath: "corp.example.com/private", hash: "h1:private=", wantStatus: unverified},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, err := db.verify(tc.modPath, "v1.0.0", tc.hash, sums)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.wantStatus, status)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// tableColumns are the columns rendered by the table template function, with their header and the function giving
+// the value of each dependency.
+var tableColumns = map[string]struct {
+	header string
+	value  func(detector.LicenceInfo) string
+}{
+	"module":  {header: "Module", value: DisplayName},
+	"version": {header: "Version", value: HumanVersion},
+	"licence": {header: "Licence", value: func(dep detector.LicenceInfo) string {
+		if len(dep.Licences) == 0 {
+			return "Unknown"
+		}
+		return strings.Join(dep.Licences, " AND ")
+	}},
+}
+
+var defaultTableColumns = []string{"module", "version", "licence"}
+
+func tableColumnNames() []string {
+	names := make([]string, 0, len(tableColumns))
+	for name := range tableColumns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/* Template functions */
+
+// PadRight pads s with spaces to width characters. Longer strings are returned unchanged.
+func PadRight(width int, s string) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// Columns returns the width of each column of the table of the dependencies (module, version and licence), which is
+// the width of the longest value or header, so that templates can align their own layouts with padRight.
+func Columns(deps []detector.LicenceInfo) map[string]int {
+	widths := make(map[string]int, len(tableColumns))
+	for name, col := range tableColumns {
+		widths[name] = utf8.RuneCountInString(col.header)
+		for _, dep := range deps {
+			if n := utf8.RuneCountInString(col.value(dep)); n > widths[name] {
+				widths[name] = n
+			}
+		}
+	}
+	return widths
+}
+
+// Table renders the dependencies as a plain-text table with a header, aligning the given columns (module, version
+// and licence by default) whatever the length of the values.
+func Table(deps []detector.LicenceInfo, columns ...string) (string, error) {
+	if len(columns) == 0 {
+		columns = defaultTableColumns
+	}
+	for _, name := range columns {
+		if _, ok := tableColumns[name]; !ok {
+			return "", fmt.Errorf("unknown table column %q: must be one of %s", name, strings.Join(tableColumnNames(), ", "))
+		}
+	}
+
+	widths := Columns(deps)
+	var sb strings.Builder
+	writeRow := func(cell func(name string) string) {
+		cells := make([]string, len(columns))
+		for i, name := range columns {
+			cells[i] = PadRight(widths[name], cell(name))
+		}
+		sb.WriteString(strings.TrimRight(strings.Join(cells, "  "), " "))
+		sb.WriteByte('\n')
+	}
+
+	writeRow(func(name string) string { return tableColumns[name].header })
+	writeRow(func(name string) string { return strings.Repeat("-", widths[name]) })
+	for _, dep := range deps {
+		dep := dep
+		writeRow(func(name string) string { return tableColumns[name].value(dep) })
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTable(t *testing.T) {
+	deps := []detector.LicenceInfo{
+		{Module: detector.Module{Path: "github.com/a/very-long-module-path", Version: "v1.0.0"}, Licences: []string{"MIT"}},
+		{Module: detector.Module{Path: "github.com/b/lib", Version: "v12.3.4"}},
+	}
+
+	got, err := Table(deps)
+	require.NoError(t, err)
+	require.Equal(t, `Module                              Version  Licence
+----------------------------------  -------  -------
+github.com/a/very-long-module-path  v1.0.0   MIT
+github.com/b/lib                    v12.3.4  Unknown
+`, got)
+
+	got, err = Table(deps, "licence", "module")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(got, "Licence  Module\n-------  ----------------------------------\nMIT      github.com/a/very-long-module-path\n"))
+
+	_, err = Table(deps, "owner")
+	require.Error(t, err)
+}
+
+func TestPadRightColumns(t *testing.T) {
+	deps := []detector.LicenceInfo{
+		{Module: detector.Module{Path: "github.com/a/lib", Version: "v1.0.0"}, Licences: []string{"MIT"}},
+		{Module: detector.Module{Path: "github.com/b/lïb", Version: "v2.0.0"}, Licences: []string{"Apache-2.0"}},
+	}
+
+	tmpl := template.Must(template.New("test").Funcs(templateFuncs).Parse(`{{ $w := columns . }}{{ range . }}{{ padRight $w.module .Path }}|{{ padRight $w.licence (join .Licences " AND ") }}|
+{{ end }}`))
+	var sb strings.Builder
+	require.NoError(t, tmpl.Execute(&sb, deps))
+	require.Equal(t, "github.com/a/lib|MIT       |\ngithub.com/b/lïb|Apache-2.0|\n", sb.String())
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// targetLabelRegex matches the labels of build targets, which cannot be confused with the scheme of a URL.
+var targetLabelRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// buildTarget is a dependency list labelled with the build target, such as the OS, of the artifact it applies to.
+type buildTarget struct {
+	label string
+	path  string
+}
+
+// inputFlag is the -in flag. It names a single dependency list or, repeated as label=path, the dependency lists of
+// several build targets.
+type inputFlag struct {
+	path    string
+	targets []buildTarget
+	set     bool
+}
+
+func (f *inputFlag) String() string {
+	if len(f.targets) == 0 {
+		return f.path
+	}
+
+	values := make([]string, len(f.targets))
+	for i, t := range f.targets {
+		values[i] = t.label + "=" + t.path
+	}
+	return strings.Join(values, ",")
+}
+
+func (f *inputFlag) Set(value string) error {
+	idx := strings.Index(value, "=")
+	if idx < 0 || !targetLabelRegex.MatchString(value[:idx]) {
+		if f.set {
+			return fmt.Errorf("only labelled dependency lists, as in linux=deps.json, can be given several times")
+		}
+		f.path, f.set = value, true
+		return nil
+	}
+
+	if f.set && len(f.targets) == 0 {
+		return fmt.Errorf("labelled and unlabelled dependency lists cannot be mixed")
+	}
+	label := value[:idx]
+	for _, t := range f.targets {
+		if t.label == label {
+			return fmt.Errorf("duplicate build target %q", label)
+		}
+	}
+	f.targets, f.set = append(f.targets, buildTarget{label: label, path: value[idx+1:]}), true
+	return nil
+}
+
+// buildTargets returns the build targets given by the labelled -in values or by -platforms.
+func buildTargets() []buildTarget {
+	if *platformsFlag == "" {
+		return inputsFlag.targets
+	}
+
+	platforms, _ := parsePlatforms(*platformsFlag)
+	targets := make([]buildTarget, len(platforms))
+	for i, p := range platforms {
+		targets[i] = buildTarget{label: p, path: p}
+	}
+	return targets
+}
+
+// targetLabels returns the labels of the build targets, in the order they were given.
+func targetLabels() []string {
+	targets := buildTargets()
+	labels := make([]string, len(targets))
+	for i, t := range targets {
+		labels[i] = t.label
+	}
+	return labels
+}
+
+// parsePlatforms parses a comma-separated list of GOOS/GOARCH pairs.
+func parsePlatforms(value string) ([]string, error) {
+	var platforms []string
+	seen := make(map[string]bool)
+	for _, p := range strings.Split(value, ",") {
+		p = strings.TrimSpace(p)
+		parts := strings.Split(p, "/")
+		if len(parts) != 2 || !targetLabelRegex.MatchString(parts[0]) || !targetLabelRegex.MatchString(parts[1]) {
+			return nil, fmt.Errorf("invalid platform %q: must be GOOS/GOARCH", p)
+		}
+		if !seen[p] {
+			seen[p] = true
+			platforms = append(platforms, p)
+		}
+	}
+	return platforms, nil
+}
+
+// platformReader lists the modules providing the packages built for a GOOS/GOARCH platform by running go list -deps
+// in the current directory, in the format of go list -m -json.
+func platformReader(platform string) (io.ReadCloser, error) {
+	parts := strings.Split(platform, "/")
+	cmd := exec.Command("go", "list", "-deps", "-json", "./...")
+	cmd.Env = append(os.Environ(), "GOOS="+parts[0], "GOARCH="+parts[1])
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run go list for %s: %w", platform, err)
+	}
+
+	mods, err := packageModules(bytes.NewReader(out))
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := encodeModules(mods)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(r), nil
+}
+
+// packageModules returns the modules providing the packages listed in the output of go list -deps -json, in the order
+// they are first seen.
+func packageModules(r io.Reader) ([]detector.Module, error) {
+	var mods []detector.Module
+	seen := make(map[string]bool)
+	decoder := json.NewDecoder(r)
+	for {
+		var pkg struct {
+			Module *detector.Module
+		}
+		if err := decoder.Decode(&pkg); err != nil {
+			if err == io.EOF {
+				return mods, nil
+			}
+			return nil, fmt.Errorf("failed to parse package list: %w", err)
+		}
+
+		// standard library packages have no module
+		if pkg.Module == nil {
+			continue
+		}
+		key := pkg.Module.Path + "@" + pkg.Module.Version
+		if !seen[key] {
+			seen[key] = true
+			mods = append(mods, *pkg.Module)
+		}
+	}
+}
+
+// detectInput opens the dependency list given by -in, merging the dependency lists of the build targets if labelled
+// or computed for -platforms.
+func detectInput(inputFn func(string) (io.ReadCloser, error)) (io.ReadCloser, error) {
+	if *platformsFlag != "" {
+		return mergeTargetInputs(platformReader, buildTargets(), "go-list")
+	}
+
+	if len(inputsFlag.targets) > 0 {
+		return mergeTargetInputs(inputFn, inputsFlag.targets, *inputFormatFlag)
+	}
+
+	dependencyTargets = nil
+	depInput, err := inputFn(*inFlag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reader for %s: %w", *inFlag, err)
+	}
+	return depInput, nil
+}
+
+// dependencyTargets holds the labels of the build targets of each module version, by path@version.
+var dependencyTargets map[string][]string
+
+// mergeTargetInputs reads the dependency lists of the build targets and returns their union as the output of go list
+// -m -json, recording the targets of every module version in dependencyTargets. A module is only indirect if it is
+// an indirect dependency of every target it applies to.
+func mergeTargetInputs(inputFn func(string) (io.ReadCloser, error), targets []buildTarget, format string) (io.ReadCloser, error) {
+	dependencyTargets = make(map[string][]string)
+
+	var mods []detector.Module
+	index := make(map[string]int)
+	for _, t := range targets {
+		targetMods, err := readTargetModules(inputFn, t.path, format)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dependencies of %s from %s: %w", t.label, t.path, err)
+		}
+
+		for _, mod := range targetMods {
+			key := mod.Path + "@" + mod.Version
+			dependencyTargets[key] = append(dependencyTargets[key], t.label)
+
+			i, ok := index[key]
+			if !ok {
+				index[key] = len(mods)
+				mods = append(mods, mod)
+				continue
+			}
+			mods[i].Indirect = mods[i].Indirect && mod.Indirect
+		}
+	}
+
+	r, err := encodeModules(mods)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(r), nil
+}
+
+func readTargetModules(inputFn func(string) (io.ReadCloser, error), path, format string) ([]detector.Module, error) {
+	rc, err := inputFn(path)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	r, err := convertInput(rc, format)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeModules(r)
+}
+
+// decodeModules reads a dependency list in the format of go list -m -json.
+func decodeModules(r io.Reader) ([]detector.Module, error) {
+	var mods []detector.Module
+	decoder := json.NewDecoder(r)
+	for {
+		var mod detector.Module
+		if err := decoder.Decode(&mod); err != nil {
+			if err == io.EOF {
+				return mods, nil
+			}
+			return nil, err
+		}
+		mods = append(mods, mod)
+	}
+}
+
+/* Template functions */
+
+// Targets returns the labels of the build targets the dependency applies to, or nil if a single dependency list was
+// given.
+func Targets(licInfo detector.LicenceInfo) []string {
+	return dependencyTargets[licInfo.Path+"@"+licInfo.Version]
+}
+
+// exportMatrix writes a CSV matrix of the dependencies and the build targets they apply to.
+func exportMatrix(w io.Writer, dependencies *detector.Dependencies) error {
+	labels := targetLabels()
+	if len(labels) == 0 {
+		return fmt.Errorf("the matrix format requires the dependency lists of several build targets, as in -in linux=deps.json, or -platforms")
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"Module", "Version", "Licence"}, labels...)); err != nil {
+		return err
+	}
+
+	deps := allDependencies(dependencies)
+	sort.SliceStable(deps, func(i, j int) bool { return deps[i].Path < deps[j].Path })
+	for _, dep := range deps {
+		applies := make(map[string]bool)
+		for _, label := range Targets(dep) {
+			applies[label] = true
+		}
+
+		record := []string{dep.Path, effectiveModule(dep).Version, licenceExpression(dep)}
+		for _, label := range labels {
+			if applies[label] {
+				record = append(record, "x")
+			} else {
+				record = append(record, "")
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputFlag(t *testing.T) {
+	testCases := []struct {
+		name        string
+		values      []string
+		wantPath    string
+		wantTargets []buildTarget
+		wantErr     bool
+	}{
+		{name: "path", values: []string{"deps.json"}, wantPath: "deps.json"},
+		{name: "url", values: []string{"https://example.com/deps.json?ref=main"}, wantPath: "https://example.com/deps.json?ref=main"},
+		{
+			name:        "targets",
+			values:      []string{"linux=deps-linux.json", "windows=deps-win.json"},
+			wantPath:    "-",
+			wantTargets: []buildTarget{{label: "linux", path: "deps-linux.json"}, {label: "windows", path: "deps-win.json"}},
+		},
+		{name: "several paths", values: []string{"a.json", "b.json"}, wantErr: true},
+		{name: "mixed", values: []string{"a.json", "linux=b.json"}, wantErr: true},
+		{name: "duplicate label", values: []string{"linux=a.json", "linux=b.json"}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := inputFlag{path: "-"}
+			var err error
+			for _, v := range tc.values {
+				if err = f.Set(v); err != nil {
+					break
+				}
+			}
+
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.wantPath, f.path)
+			require.Equal(t, tc.wantTargets, f.targets)
+		})
+	}
+}
+
+func TestMergeTargetInputs(t *testing.T) {
+	inputs := map[string]string{
+		"linux.json": `{"Path": "example.com/a", "Version": "v1.0.0"}
+{"Path": "example.com/b", "Version": "v1.0.0", "Indirect": true}
+{"Path": "example.com/c", "Version": "v1.0.0", "Indirect": true}`,
+		"windows.json": `{"Path": "example.com/a", "Version": "v1.0.0"}
+{"Path": "example.com/b", "Version": "v1.0.0"}
+{"Path": "example.com/d", "Version": "v1.0.0"}`,
+	}
+	inputFn := func(path string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader(inputs[path])), nil
+	}
+
+	targets := []buildTarget{{label: "linux", path: "linux.json"}, {label: "windows", path: "windows.json"}}
+	r, err := mergeTargetInputs(inputFn, targets, "go-list")
+	require.NoError(t, err)
+	defer func() { dependencyTargets = nil }()
+
+	mods, err := readTargetModules(func(string) (io.ReadCloser, error) { return r, nil }, "", "go-list")
+	require.NoError(t, err)
+	require.Equal(t, []detector.Module{
+		{Path: "example.com/a", Version: "v1.0.0"},
+		{Path: "example.com/b", Version: "v1.0.0"},
+		{Path: "example.com/c", Version: "v1.0.0", Indirect: true},
+		{Path: "example.com/d", Version: "v1.0.0"},
+	}, mods)
+
+	require.Equal(t, []string{"linux", "windows"}, Targets(detector.LicenceInfo{Module: detector.Module{Path: "example.com/a", Version: "v1.0.0"}}))
+	require.Equal(t, []string{"linux"}, Targets(detector.LicenceInfo{Module: detector.Module{Path: "example.com/c", Version: "v1.0.0"}}))
+	require.Equal(t, []string{"windows"}, Targets(detector.LicenceInfo{Module: detector.Module{Path: "example.com/d", Version: "v1.0.0"}}))
+}
+
+func TestParsePlatforms(t *testing.T) {
+	platforms, err := parsePlatforms("linux/amd64, windows/arm64,linux/amd64")
+	require.NoError(t, err)
+	require.Equal(t, []string{"linux/amd64", "windows/arm64"}, platforms)
+
+	_, err = parsePlatforms("linux")
+	require.Error(t, err)
+}
+
+func TestPackageModules(t *testing.T) {
+	packages := `{"ImportPath": "fmt"}
+{"ImportPath": "example.com/a/x", "Module": {"Path": "example.com/a", "Version": "v1.0.0"}}
+{"ImportPath": "example.com/a/y", "Module": {"Path": "example.com/a", "Version": "v1.0.0"}}
+{"ImportPath": "example.com/main", "Module": {"Path": "example.com/main", "Main": true}}`
+
+	mods, err := packageModules(strings.NewReader(packages))
+	require.NoError(t, err)
+	require.Equal(t, []detector.Module{
+		{Path: "example.com/a", Version: "v1.0.0"},
+		{Path: "example.com/main", Main: true},
+	}, mods)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// loadTools returns the package paths of the build tools of the module in dir, as declared by the tool directives of
+// its go.mod file and the imports of its tools.go file. Either file may be missing.
+func loadTools(dir string) ([]string, error) {
+	var tools []string
+
+	if f, err := os.Open(filepath.Join(dir, "go.mod")); err == nil {
+		gomod, err := detector.ParseGoMod(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, gomod.Tools...)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if f, err := os.Open(filepath.Join(dir, "tools.go")); err == nil {
+		imports, err := detector.ParseToolsGo(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, imports...)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return tools, nil
+}
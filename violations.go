@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"text/template"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// Kinds of violations listed in the violations report.
+const (
+	violationDenied  = "denied-licence"    // licence in the deny list of the policy
+	violationExpired = "expired-exception" // licence in the deny list, exempted by an exception that expired
+	violationRule    = "rule"              // matched by a deny rule of the policy
+	violationUnknown = "unknown-licence"   // no licence detected
+)
+
+// Remediation actions suggested in the violations report.
+const (
+	remediationOverride    = "add-override"
+	remediationException   = "request-exception"
+	remediationAlternative = "find-alternative"
+	remediationUpgrade     = "upgrade-version"
+)
+
+// violationsReport is the data passed to the template of the violations report.
+type violationsReport struct {
+	Violations []violationEntry
+}
+
+type violationEntry struct {
+	Dependency   detector.LicenceInfo
+	Kind         string
+	Licence      string
+	Explanation  string
+	Remediations []remediation
+}
+
+type remediation struct {
+	Action string
+	Hint   string
+}
+
+// defaultViolationsTemplate is used when -violations-template is not set.
+const defaultViolationsTemplate = `{{- if not .Violations -}}
+No policy violations.
+{{ else -}}
+{{ len .Violations }} policy violation(s)
+{{ range .Violations }}
+{{ "-" | line }}
+Module  : {{ .Dependency.Path }}
+Version : {{ humanVersion .Dependency }}
+Licence : {{ if .Licence }}{{ .Licence }}{{ else }}Unknown{{ end }}
+Problem : {{ .Kind }}: {{ .Explanation }}
+{{ range .Remediations }}
+  * {{ .Action }}: {{ .Hint }}
+{{- end }}
+{{ end -}}
+{{ end -}}
+`
+
+func mkViolationsReport(dependencies *detector.Dependencies, violations []policyViolation) violationsReport {
+	var r violationsReport
+	for _, v := range violations {
+		r.Violations = append(r.Violations, mkViolationEntry(v))
+	}
+
+	for _, dep := range allDependencies(dependencies) {
+		if len(dep.Licences) > 0 {
+			continue
+		}
+		r.Violations = append(r.Violations, violationEntry{
+			Dependency:  dep,
+			Kind:        violationUnknown,
+			Explanation: "no licence was detected",
+			Remediations: []remediation{
+				{Action: remediationOverride, Hint: fmt.Sprintf("map %s to the directory holding its licence with -licence-dirs", dep.Path)},
+				{Action: remediationUpgrade, Hint: "upgrade to a version shipping a licence file"},
+				{Action: remediationAlternative, Hint: "replace the module with a licensed alternative"},
+			},
+		})
+	}
+
+	return r
+}
+
+func mkViolationEntry(v policyViolation) violationEntry {
+	e := violationEntry{Dependency: v.Dependency, Licence: v.Licence}
+	switch {
+	case v.Rule != nil:
+		e.Kind = violationRule
+		e.Explanation = fmt.Sprintf("rule %s: %s", v.Rule.Name, v.Rule.explain())
+		e.Remediations = []remediation{
+			{Action: remediationException, Hint: fmt.Sprintf("add an allow rule to the policy, e.g. path == %q", v.Dependency.Path)},
+			{Action: remediationAlternative, Hint: "replace the module with one the rule does not match"},
+		}
+	case v.Expired != nil:
+		e.Kind = violationExpired
+		e.Explanation = fmt.Sprintf("%s is denied and the exception owned by %s expired on %s", v.Licence, v.Expired.Owner, v.Expired.Expires)
+		e.Remediations = []remediation{
+			{Action: remediationException, Hint: fmt.Sprintf("ask %s to extend the expiry date of the exception", v.Expired.Owner)},
+			{Action: remediationUpgrade, Hint: fmt.Sprintf("upgrade to a version not licensed under %s, if any", v.Licence)},
+			{Action: remediationAlternative, Hint: fmt.Sprintf("replace the module with one not licensed under %s", v.Licence)},
+		}
+	default:
+		e.Kind = violationDenied
+		e.Explanation = fmt.Sprintf("%s is in the deny list of the policy", v.Licence)
+		e.Remediations = []remediation{
+			{Action: remediationException, Hint: fmt.Sprintf("add an exception for %s and %s, with an owner, a justification and an expiry date, to the policy", v.Dependency.Path, v.Licence)},
+			{Action: remediationUpgrade, Hint: fmt.Sprintf("upgrade to a version not licensed under %s, if any", v.Licence)},
+			{Action: remediationAlternative, Hint: fmt.Sprintf("replace the module with one not licensed under %s", v.Licence)},
+		}
+	}
+	return e
+}
+
+// writeViolationsReport renders the violations report with the template at templatePath, or the default template.
+func writeViolationsReport(path, templatePath string, report violationsReport) error {
+	var tmpl *template.Template
+	var err error
+	if templatePath == "" {
+		tmpl, err = template.New("violations").Funcs(templateFuncs).Parse(defaultViolationsTemplate)
+	} else {
+		tmpl, err = template.New(filepath.Base(templatePath)).Funcs(templateFuncs).ParseFiles(templatePath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, report); err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+	return writeOutput(path, buf.Bytes())
+}
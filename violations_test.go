@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestViolationsReport(t *testing.T) {
+	dep := func(path string, licences ...string) detector.LicenceInfo {
+		return detector.LicenceInfo{Module: detector.Module{Path: path, Version: "v1.0.0"}, Licences: licences}
+	}
+	rule := &policyRule{Name: "copyleft-direct", Deny: "copyleft && direct", Reason: "no copyleft"}
+	require.NoError(t, rule.compile())
+
+	dependencies := &detector.Dependencies{
+		Direct: []detector.LicenceInfo{
+			dep("example.com/denied", "GPL-3.0"),
+			dep("example.com/unknown"),
+		},
+	}
+	violations := []policyViolation{
+		{Dependency: dependencies.Direct[0], Licence: "GPL-3.0"},
+		{Dependency: dependencies.Direct[0], Licence: "GPL-3.0", Expired: &policyException{Owner: "legal", Expires: "2020-05-31"}},
+		{Dependency: dependencies.Direct[0], Licence: "GPL-3.0", Rule: rule},
+	}
+
+	report := mkViolationsReport(dependencies, violations)
+
+	var kinds, actions []string
+	for _, v := range report.Violations {
+		kinds = append(kinds, v.Kind)
+		actions = append(actions, v.Remediations[0].Action)
+	}
+	require.Equal(t, []string{violationDenied, violationExpired, violationRule, violationUnknown}, kinds)
+	require.Equal(t, []string{remediationException, remediationException, remediationException, remediationOverride}, actions)
+	require.Equal(t, "rule copyleft-direct: no copyleft", report.Violations[2].Explanation)
+
+	dir, err := ioutil.TempDir("", "violations")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	out := filepath.Join(dir, "violations.txt")
+	require.NoError(t, writeViolationsReport(out, "", report))
+	data, err := ioutil.ReadFile(out)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "4 policy violation(s)")
+	require.Contains(t, string(data), "Licence : Unknown")
+	require.Contains(t, string(data), "  * find-alternative: replace the module with one the rule does not match")
+
+	tmplPath := filepath.Join(dir, "violations.tmpl")
+	require.NoError(t, ioutil.WriteFile(tmplPath, []byte(`{{ range .Violations }}{{ .Dependency.Path }} {{ .Kind }}
+{{ end }}`), 0644))
+	require.NoError(t, writeViolationsReport(out, tmplPath, violationsReport{Violations: report.Violations[3:]}))
+	data, err = ioutil.ReadFile(out)
+	require.NoError(t, err)
+	require.Equal(t, "example.com/unknown unknown-licence\n", string(data))
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// warmBatchSize is the number of modules passed to each go mod download invocation, which keeps the command lines
+// short on large dependency trees.
+const warmBatchSize = 500
+
+func init() {
+	subcommands["warm"] = warm
+}
+
+// warm downloads the sources of the modules of the dependency list given with -in to the Go module cache, so that
+// the notice can later be generated without network access. The gomod and image input formats download the
+// sources while reading the dependency list.
+func warm() {
+	input, err := detectInput(mkReader)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer input.Close()
+
+	format := *inputFormatFlag
+	if len(buildTargets()) > 0 {
+		format = "go-list"
+	}
+	r, err := convertInput(input, format)
+	if err != nil {
+		log.Fatalf("Failed to read dependencies from %s: %v", inputsFlag.String(), err)
+	}
+
+	mods, err := decodeModules(r)
+	if err != nil {
+		log.Fatalf("Failed to read dependencies from %s: %v", inputsFlag.String(), err)
+	}
+
+	missing := missingModules(mods)
+	for start := 0; start < len(missing); start += warmBatchSize {
+		end := start + warmBatchSize
+		if end > len(missing) {
+			end = len(missing)
+		}
+		if err := goModDownload(missing[start:end]); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	logInfo("Warmed the module cache: %d modules downloaded", len(missing))
+}
+
+// missingModules returns the path@version queries of the modules whose sources are not available locally. Local
+// replacements are never downloaded.
+func missingModules(mods []detector.Module) []string {
+	var missing []string
+	seen := make(map[string]struct{})
+	for _, mod := range mods {
+		if mod.Main {
+			continue
+		}
+
+		src := mod
+		if mod.Replace != nil {
+			src = *mod.Replace
+		}
+		if src.Version == "" {
+			continue
+		}
+
+		dir := src.Dir
+		if dir == "" {
+			dir = mod.Dir
+		}
+		if dir != "" {
+			if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+				continue
+			}
+		}
+
+		query := src.Path + "@" + src.Version
+		if _, ok := seen[query]; !ok {
+			seen[query] = struct{}{}
+			missing = append(missing, query)
+		}
+	}
+	return missing
+}
+
+// goModDownload downloads the module versions to the Go module cache. The go command downloads them in parallel and
+// verifies them against the checksum database.
+func goModDownload(queries []string) error {
+	cmd := exec.Command("go", append([]string{"mod", "download", "-json"}, queries...)...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+
+	// the failures of individual modules are reported in the output
+	var failed []string
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var result struct {
+			Path    string
+			Version string
+			Error   string
+		}
+		if decodeErr := decoder.Decode(&result); decodeErr != nil {
+			break
+		}
+		if result.Error != "" {
+			failed = append(failed, fmt.Sprintf("\n  %s@%s: %s", result.Path, result.Version, result.Error))
+		}
+	}
+
+	switch {
+	case len(failed) > 0:
+		return fmt.Errorf("failed to download %d modules:%s", len(failed), strings.Join(failed, ""))
+	case err != nil:
+		return fmt.Errorf("failed to run go mod download: %w", err)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissingModules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "warm")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	mods := []detector.Module{
+		{Path: "example.com/main", Main: true},
+		{Path: "example.com/cached", Version: "v1.0.0", Dir: dir},
+		{Path: "example.com/missing", Version: "v1.0.0"},
+		{Path: "example.com/deleted", Version: "v1.0.0", Dir: dir + "/deleted"},
+		{Path: "example.com/replaced", Version: "v1.0.0", Replace: &detector.Module{Path: "example.com/fork", Version: "v1.0.1"}},
+		{Path: "example.com/local", Version: "v1.0.0", Replace: &detector.Module{Path: "../local"}},
+		{Path: "example.com/missing", Version: "v1.0.0"},
+	}
+
+	require.Equal(t, []string{"example.com/missing@v1.0.0", "example.com/deleted@v1.0.0", "example.com/fork@v1.0.1"}, missingModules(mods))
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+const watchInterval = time.Second
+
+// watch regenerates the output whenever one of the watched files changes and logs the dependency changes. When
+// reading from stdin, the dependency list is obtained by running go list in the current directory instead.
+func watch() {
+	if *outFlag == "-" {
+		log.Fatal("Watch mode requires -out to be a file")
+	}
+
+	watched := []string{"go.mod", "go.sum"}
+	inputFn := goListReader
+	if len(inputsFlag.targets) > 0 {
+		for _, t := range inputsFlag.targets {
+			watched = append(watched, t.path)
+		}
+		inputFn = mkReader
+	} else if *inFlag != "-" {
+		watched = append(watched, *inFlag)
+		inputFn = mkReader
+	}
+
+	var previous *detector.Dependencies
+	var lastState string
+	for {
+		state := fileState(watched)
+		if state != lastState {
+			lastState = state
+			current, err := run(inputFn)
+			if err != nil {
+				log.Printf("Failed to regenerate %s: %v", *outFlag, err)
+			} else {
+				if previous != nil {
+					changes := diffDependencies(previous, current)
+					if len(changes) == 0 {
+						logInfo("Regenerated %s: no dependency changes", *outFlag)
+					} else {
+						logInfo("Regenerated %s:\n%s", *outFlag, strings.Join(changes, "\n"))
+					}
+				} else {
+					logInfo("Generated %s, watching %v for changes", *outFlag, watched)
+				}
+				previous = current
+			}
+		}
+
+		time.Sleep(watchInterval)
+	}
+}
+
+func goListReader(_ string) (io.ReadCloser, error) {
+	cmd := exec.Command("go", "list", "-m", "-json", "all")
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run go list: %w", err)
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(out)), nil
+}
+
+func fileState(paths []string) string {
+	var buf bytes.Buffer
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			fmt.Fprintf(&buf, "%s:missing;", p)
+			continue
+		}
+		fmt.Fprintf(&buf, "%s:%d:%d;", p, fi.Size(), fi.ModTime().UnixNano())
+	}
+
+	return buf.String()
+}
+
+// diffDependencies lists the dependencies that were added, removed or changed version between two runs.
+func diffDependencies(previous, current *detector.Dependencies) []string {
+	before := make(map[string]string)
+	for _, dep := range allDependencies(previous) {
+		before[dep.Path] = effectiveModule(dep).Version
+	}
+
+	var changes []string
+	seen := make(map[string]bool)
+	for _, dep := range allDependencies(current) {
+		seen[dep.Path] = true
+		version := effectiveModule(dep).Version
+		oldVersion, ok := before[dep.Path]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("+ %s %s", dep.Path, version))
+		case oldVersion != version:
+			changes = append(changes, fmt.Sprintf("~ %s %s => %s", dep.Path, oldVersion, version))
+		}
+	}
+
+	for _, dep := range allDependencies(previous) {
+		if !seen[dep.Path] {
+			changes = append(changes, fmt.Sprintf("- %s %s", dep.Path, effectiveModule(dep).Version))
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charith-elastic/licence-detector/detector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffDependencies(t *testing.T) {
+	dep := func(path, version string) detector.LicenceInfo {
+		return detector.LicenceInfo{Module: detector.Module{Path: path, Version: version}}
+	}
+	replaced := func(path, version, replaceVersion string) detector.LicenceInfo {
+		d := dep(path, version)
+		d.Replace = &detector.Module{Path: "example.com/fork", Version: replaceVersion}
+		return d
+	}
+
+	testCases := []struct {
+		name     string
+		previous *detector.Dependencies
+		current  *detector.Dependencies
+		want     []string
+	}{
+		{
+			name:     "Unchanged",
+			previous: &detector.Dependencies{Direct: []detector.LicenceInfo{dep("example.com/a", "v1.0.0")}},
+			current:  &detector.Dependencies{Direct: []detector.LicenceInfo{dep("example.com/a", "v1.0.0")}},
+		},
+		{
+			name:     "Added",
+			previous: &detector.Dependencies{Direct: []detector.LicenceInfo{dep("example.com/a", "v1.0.0")}},
+			current:  &detector.Dependencies{Direct: []detector.LicenceInfo{dep("example.com/a", "v1.0.0")}, Indirect: []detector.LicenceInfo{dep("example.com/b", "v0.1.0")}},
+			want:     []string{"+ example.com/b v0.1.0"},
+		},
+		{
+			name:     "Removed",
+			previous: &detector.Dependencies{Direct: []detector.LicenceInfo{dep("example.com/a", "v1.0.0")}, Tools: []detector.LicenceInfo{dep("example.com/tool", "v2.0.0")}},
+			current:  &detector.Dependencies{Direct: []detector.LicenceInfo{dep("example.com/a", "v1.0.0")}},
+			want:     []string{"- example.com/tool v2.0.0"},
+		},
+		{
+			name:     "Upgraded",
+			previous: &detector.Dependencies{Direct: []detector.LicenceInfo{dep("example.com/a", "v1.0.0")}},
+			current:  &detector.Dependencies{Direct: []detector.LicenceInfo{dep("example.com/a", "v1.1.0")}},
+			want:     []string{"~ example.com/a v1.0.0 => v1.1.0"},
+		},
+		{
+			name:     "ReplacementChanged",
+			previous: &detector.Dependencies{Direct: []detector.LicenceInfo{replaced("example.com/a", "v1.0.0", "v1.0.1")}},
+			current:  &detector.Dependencies{Direct: []detector.LicenceInfo{replaced("example.com/a", "v1.0.0", "v1.0.2")}},
+			want:     []string{"~ example.com/a v1.0.1 => v1.0.2"},
+		},
+		{
+			name:     "MovedToIndirect",
+			previous: &detector.Dependencies{Direct: []detector.LicenceInfo{dep("example.com/a", "v1.0.0")}},
+			current:  &detector.Dependencies{Indirect: []detector.LicenceInfo{dep("example.com/a", "v1.0.0")}},
+		},
+		{
+			name:     "Everything",
+			previous: &detector.Dependencies{Direct: []detector.LicenceInfo{dep("example.com/a", "v1.0.0"), dep("example.com/gone", "v0.1.0")}},
+			current:  &detector.Dependencies{Direct: []detector.LicenceInfo{dep("example.com/new", "v0.2.0"), dep("example.com/a", "v2.0.0")}},
+			want:     []string{"+ example.com/new v0.2.0", "~ example.com/a v1.0.0 => v2.0.0", "- example.com/gone v0.1.0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, diffDependencies(tc.previous, tc.current))
+		})
+	}
+}
+
+func TestFileState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	goMod := filepath.Join(dir, "go.mod")
+	goSum := filepath.Join(dir, "go.sum")
+	paths := []string{goMod, goSum}
+	mtime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	write := func(path, content string, modTime time.Time) {
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	write(goMod, "module example.com/m\n", mtime)
+
+	testCases := []struct {
+		name    string
+		change  func()
+		changed bool
+	}{
+		{name: "Unchanged", change: func() {}, changed: false},
+		{name: "Created", change: func() { write(goSum, "", mtime) }, changed: true},
+		{name: "SameContentRewritten", change: func() { write(goMod, "module example.com/m\n", mtime) }, changed: false},
+		{name: "SizeChanged", change: func() { write(goMod, "module example.com/m\n\ngo 1.13\n", mtime) }, changed: true},
+		{name: "ModTimeChanged", change: func() { write(goMod, "module example.com/m\n\ngo 1.13\n", mtime.Add(time.Second)) }, changed: true},
+		{name: "SameSizeSameModTime", change: func() { write(goMod, "module example.com/n\n\ngo 1.13\n", mtime.Add(time.Second)) }, changed: false},
+		{name: "Removed", change: func() { require.NoError(t, os.Remove(goSum)) }, changed: true},
+	}
+
+	state := fileState(paths)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.change()
+			next := fileState(paths)
+			if tc.changed {
+				require.NotEqual(t, state, next)
+			} else {
+				require.Equal(t, state, next)
+			}
+			state = next
+		})
+	}
+	require.Contains(t, state, goSum+":missing;")
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// wrapLines breaks the lines of the text longer than maxLen characters, at the last space that fits where possible
+// and within words otherwise. The text is returned unchanged if maxLen is not positive.
+func wrapLines(text []byte, maxLen int) []byte {
+	if maxLen <= 0 {
+		return text
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(text))
+	for len(text) > 0 {
+		line := text
+		if idx := bytes.IndexByte(text, '\n'); idx >= 0 {
+			line, text = text[:idx+1], text[idx+1:]
+		} else {
+			text = nil
+		}
+
+		for utf8.RuneCount(bytes.TrimRight(line, "\r\n")) > maxLen {
+			// byte offset of the first character beyond the limit
+			end := 0
+			for i := 0; i < maxLen; i++ {
+				_, size := utf8.DecodeRune(line[end:])
+				end += size
+			}
+
+			if space := bytes.LastIndexByte(line[:end+1], ' '); space > 0 {
+				buf.Write(line[:space])
+				line = line[space+1:]
+			} else {
+				buf.Write(line[:end])
+				line = line[end:]
+			}
+			buf.WriteByte('\n')
+		}
+		buf.Write(line)
+	}
+
+	return buf.Bytes()
+}
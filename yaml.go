@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charith-elastic/licence-detector/detector"
+)
+
+// exportYAML writes the report in YAML. The report is converted from its JSON encoding so that both formats share
+// the same schema and field order.
+func exportYAML(w io.Writer, dependencies *detector.Dependencies) error {
+	data, err := json.Marshal(mkReport(dependencies))
+	if err != nil {
+		return err
+	}
+
+	return writeYAML(w, data)
+}
+
+// writeYAML writes the JSON document as YAML, keeping the order of the object keys.
+func writeYAML(w io.Writer, data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := parseYAMLNode(dec)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString("---\n"); err != nil {
+		return err
+	}
+	node.write(bw, 0, "")
+	return bw.Flush()
+}
+
+// yamlNode is a JSON value that keeps the order of object keys.
+type yamlNode struct {
+	scalar string      // rendered value of strings, numbers, booleans and null
+	keys   []string    // object keys, nil for other values
+	values []*yamlNode // object values or array items
+	isList bool
+}
+
+func parseYAMLNode(dec *json.Decoder) (*yamlNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		node := &yamlNode{isList: t == '[', keys: []string{}}
+		for dec.More() {
+			if !node.isList {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.keys = append(node.keys, key.(string))
+			}
+
+			value, err := parseYAMLNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			node.values = append(node.values, value)
+		}
+
+		// closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case string:
+		return &yamlNode{scalar: strconv.Quote(t)}, nil
+	case json.Number:
+		return &yamlNode{scalar: yamlNumber(t.String())}, nil
+	case bool:
+		return &yamlNode{scalar: strconv.FormatBool(t)}, nil
+	case nil:
+		return &yamlNode{scalar: "null"}, nil
+	default:
+		return nil, fmt.Errorf("unexpected JSON token %v", tok)
+	}
+}
+
+// yamlNumber rewrites the exponent form of a JSON number, such as 1e-7, as 1.0e-7: YAML 1.1 parsers read floats
+// without a dot or with an unsigned exponent as strings.
+func yamlNumber(n string) string {
+	i := strings.IndexAny(n, "eE")
+	if i < 0 {
+		return n
+	}
+
+	mantissa, exponent := n[:i], n[i+1:]
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	if !strings.HasPrefix(exponent, "-") && !strings.HasPrefix(exponent, "+") {
+		exponent = "+" + exponent
+	}
+	return mantissa + "e" + exponent
+}
+
+func (n *yamlNode) isScalar() bool {
+	return n.keys == nil
+}
+
+// inline returns the representation of scalars and empty collections, which fit on the line of their key.
+func (n *yamlNode) inline() (string, bool) {
+	switch {
+	case n.isScalar():
+		return n.scalar, true
+	case len(n.values) == 0 && n.isList:
+		return "[]", true
+	case len(n.values) == 0:
+		return "{}", true
+	default:
+		return "", false
+	}
+}
+
+// write writes the node at the given indentation. If lead is set, it replaces the indentation of the first line so
+// that the first entry of a list item can follow its dash.
+func (n *yamlNode) write(w *bufio.Writer, indent int, lead string) {
+	if s, ok := n.inline(); ok {
+		fmt.Fprintf(w, "%s%s\n", strings.Repeat(" ", indent), s)
+		return
+	}
+
+	for i, value := range n.values {
+		prefix := strings.Repeat(" ", indent)
+		if i == 0 && lead != "" {
+			prefix = lead
+		}
+
+		if n.isList {
+			prefix += "-"
+		} else {
+			prefix += yamlKey(n.keys[i]) + ":"
+		}
+
+		if s, ok := value.inline(); ok {
+			fmt.Fprintf(w, "%s %s\n", prefix, s)
+			continue
+		}
+
+		if n.isList {
+			value.write(w, indent+2, prefix+" ")
+			continue
+		}
+
+		fmt.Fprintln(w, prefix)
+		value.write(w, indent+2, "")
+	}
+}
+
+var plainYAMLKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// yamlReservedWords are read as booleans or null rather than strings when they are not quoted, as in YAML 1.1.
+var yamlReservedWords = map[string]bool{
+	"true": true, "false": true, "null": true, "yes": true, "no": true, "on": true, "off": true, "y": true, "n": true,
+}
+
+func yamlKey(key string) string {
+	if plainYAMLKey.MatchString(key) && !yamlReservedWords[strings.ToLower(key)] {
+		return key
+	}
+	return strconv.Quote(key)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestWriteYAML renders a document covering nested lists of objects, empty collections, keys that must be quoted and
+// strings with escapes or non-ASCII characters.
+func TestWriteYAML(t *testing.T) {
+	input, err := ioutil.ReadFile(filepath.Join("testdata", "yaml", "report.json"))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeYAML(&buf, input))
+
+	golden := filepath.Join("testdata", "yaml", "report.golden")
+	if *updateGoldenFlag {
+		require.NoError(t, ioutil.WriteFile(golden, buf.Bytes(), 0644))
+	}
+
+	want, err := ioutil.ReadFile(golden)
+	require.NoError(t, err)
+	require.Equal(t, string(want), buf.String())
+
+	// YAML is a superset of JSON in theory only, so the output is checked with a real parser when one is available
+	python, err := exec.LookPath("python3")
+	if err != nil || exec.Command(python, "-c", "import yaml").Run() != nil {
+		t.Skip("python3 with PyYAML is required to parse the output")
+	}
+
+	cmd := exec.Command(python, "-c", "import json, sys, yaml; json.dump(yaml.safe_load(sys.stdin), sys.stdout)")
+	cmd.Stdin = &buf
+	parsed, err := cmd.Output()
+	require.NoError(t, err)
+
+	var got, wantValue interface{}
+	require.NoError(t, json.Unmarshal(parsed, &got))
+	require.NoError(t, json.Unmarshal(input, &wantValue))
+	require.Equal(t, wantValue, got)
+}